@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ! WithAdaptiveConcurrency lets an AIMD controller, in the spirit of TCP congestion control,
+// ! decide how many tasks run at once instead of relying on a guessed fixed number. The limit
+// ! starts at 1 and grows by roughly one for every limit tasks that succeed within
+// ! targetLatency; a task that fails, leaks or runs longer than targetLatency cuts it by 10%.
+// ! The limit never drops below 1 or rises above the number of workers, which stays the hard
+// ! cap. Workers that pick up a task beyond the limit wait for a running one to finish.
+// ! Latency is measured from when the task starts running, retries included. Stats reports
+// ! the current limit as ConcurrencyLimit.
+func WithAdaptiveConcurrency(targetLatency time.Duration) Option {
+	return func(pool *Pool) {
+		limiter := &adaptiveLimiter{target: targetLatency, limit: adaptiveMinLimit}
+		limiter.released.L = &limiter.mutex
+		pool.adaptiveConcurrency = limiter
+	}
+}
+
+// ! Tuning of the adaptive concurrency controller.
+const (
+	adaptiveDecreaseFactor = 0.9
+	adaptiveMinLimit       = 1
+)
+
+// ! adaptiveLimiter is an AIMD concurrency limit. Its methods are safe on a nil limiter.
+type adaptiveLimiter struct {
+	mutex    sync.Mutex
+	released sync.Cond
+	target   time.Duration
+	limit    float64
+	maxLimit float64 //! Set by New to the number of workers.
+	inFlight int
+}
+
+// ! acquire blocks until one more task fits in the current limit.
+func (limiter *adaptiveLimiter) acquire() {
+	if limiter == nil {
+		return
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	for limiter.inFlight >= int(limiter.limit) {
+		limiter.released.Wait()
+	}
+	limiter.inFlight++
+}
+
+// ! done frees the slot of a finished task and adjusts the limit by how it went.
+func (limiter *adaptiveLimiter) done(latency time.Duration, failed bool) {
+	if limiter == nil {
+		return
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	if failed || latency > limiter.target {
+		limiter.limit = max(limiter.limit*adaptiveDecreaseFactor, adaptiveMinLimit)
+	} else {
+		limiter.limit = min(limiter.limit+1/limiter.limit, limiter.maxLimit)
+	}
+	limiter.inFlight--
+	limiter.released.Broadcast()
+}
+
+// ! abandon frees the slot of a task that stopped without a meaningful outcome, such as one
+// ! interrupted by Shutdown while backing off, leaving the limit as it is.
+func (limiter *adaptiveLimiter) abandon() {
+	if limiter == nil {
+		return
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.inFlight--
+	limiter.released.Broadcast()
+}
+
+// ! currentLimit returns the limit rounded down to whole tasks, or -1 for a nil limiter.
+func (limiter *adaptiveLimiter) currentLimit() int {
+	if limiter == nil {
+		return -1
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	return int(limiter.limit)
+}
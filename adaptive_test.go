@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveConcurrencyStartsAtOneTask(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(4), WithAdaptiveConcurrency(time.Second))
+	if limit := pool.Stats().ConcurrencyLimit; limit != 1 {
+		t.Fatalf("ConcurrencyLimit = %d at start, want 1", limit)
+	}
+	release := make(chan struct{})
+	first, _ := pool.Submit(TaskFunc(func(context.Context) error {
+		<-release
+		return nil
+	}))
+	second, _ := pool.Submit(noopTask)
+	if finished(second) {
+		t.Error("a second task ran beside the first under a limit of 1")
+	}
+	close(release)
+	returnsWithin(t, time.Second, "both tasks", func() { pool.Flush(first, second) })
+}
+
+func TestAdaptiveConcurrencyGrowsAdditivelyAndShrinksMultiplicatively(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(4), WithClock(clock), WithAdaptiveConcurrency(100*time.Millisecond))
+	run := func(latency time.Duration, err error) {
+		handle, _ := pool.Submit(TaskFunc(func(context.Context) error {
+			clock.advance(latency)
+			return err
+		}))
+		<-handle.Done()
+	}
+	var limits []int
+	for range 4 {
+		run(time.Millisecond, nil)
+		limits = append(limits, pool.Stats().ConcurrencyLimit)
+	}
+	//! 1, then 2, 2.5, 2.9 and 3.24: each success adds one over the limit.
+	if want := []int{2, 2, 2, 3}; !slices.Equal(limits, want) {
+		t.Errorf("limits after fast successes %v, want %v", limits, want)
+	}
+	for range 20 {
+		run(time.Millisecond, nil)
+	}
+	if limit := pool.Stats().ConcurrencyLimit; limit != 4 {
+		t.Fatalf("ConcurrencyLimit = %d after many fast successes, want the 4 workers", limit)
+	}
+
+	run(time.Second, nil) //! 4 * 0.9 = 3.6
+	if limit := pool.Stats().ConcurrencyLimit; limit != 3 {
+		t.Errorf("ConcurrencyLimit = %d after a slow task, want 3", limit)
+	}
+	run(time.Millisecond, errors.New("downstream overloaded")) //! 3.24
+	run(time.Millisecond, errors.New("downstream overloaded")) //! 2.916
+	if limit := pool.Stats().ConcurrencyLimit; limit != 2 {
+		t.Errorf("ConcurrencyLimit = %d after two failures, want 2", limit)
+	}
+	if limit := newTestPool(t).Stats().ConcurrencyLimit; limit != -1 {
+		t.Errorf("ConcurrencyLimit = %d without the option, want -1", limit)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "fmt"
+
+// ! WithAdmissionController lets admit decide, for every submission that goes through Submit's
+// ! admission, whether the pool takes the task, based on signals the pool knows nothing about,
+// ! such as process memory, the state of an external circuit breaker or business rules. It is
+// ! called on the submitting goroutine before the pool is locked or the task is queued, with
+// ! the task's priority and tags; the id is not assigned yet and is zero. A refusal returns a
+// ! *RejectionError with reason RejectedByController wrapping ErrAdmissionDenied and the
+// ! reason given. The built-in gates, such as the queue size and WithRateLimit, still apply
+// ! to tasks the controller admits. Without it nothing is called.
+func WithAdmissionController(admit func(info TaskInfo) (admitted bool, reason string)) Option {
+	return func(pool *Pool) {
+		pool.admissionController = admit
+	}
+}
+
+// ! consultController asks the admission controller about a task, if there is one, and returns
+// ! the rejection to report if it refused.
+func (pool *Pool) consultController(newTask *task) error {
+	if pool.admissionController == nil {
+		return nil
+	}
+	admitted, reason := pool.admissionController(TaskInfo{Priority: newTask.priority, Tags: newTask.tags})
+	if admitted {
+		return nil
+	}
+	return pool.rejectUnlocked(RejectedByController, fmt.Errorf("%w: %s", ErrAdmissionDenied, reason))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ! AutoTuneWorkers is a one-time calibration for picking a worker count on the hardware and
+// ! task mix at hand instead of guessing: for every candidate it runs a trial pool of that many
+// ! workers for duration, kept saturated with tasks made by sample, and returns the candidate
+// ! that completed the most tasks without an error, the smallest one on a tie. Further options
+// ! configure the trial pools, for instance to match the queue size used in production. Every
+// ! trial pool is closed, its leftover queue cancelled, before the next one starts. It is a
+// ! heuristic: a short trial is noisy, sensitive to whatever else the machine is doing, and
+// ! blind to effects that only appear under sustained load, such as a downstream throttling,
+// ! so run it with a representative sample and a duration of seconds rather than milliseconds.
+// ! It returns 0 if no candidate is at least 1.
+func AutoTuneWorkers(sample func() func() error, candidates []int, duration time.Duration, options ...Option) int {
+	best, bestThroughput := 0, int64(-1)
+	for _, workers := range candidates {
+		if workers < 1 {
+			continue
+		}
+		if throughput := trialThroughput(sample, workers, duration, options); throughput > bestThroughput {
+			best, bestThroughput = workers, throughput
+		}
+	}
+	return best
+}
+
+// ! trialThroughput counts the sample tasks a pool of the given size completes successfully
+// ! within duration, keeping its queue full the whole time.
+func trialThroughput(sample func() func() error, workers int, duration time.Duration, options []Option) int64 {
+	trialOptions := append([]Option{WithQueueSize(2 * workers)}, options...)
+	pool, err := New(append(trialOptions, WithWorkers(workers))...)
+	if err != nil {
+		return -1
+	}
+	deadline := time.Now().Add(duration)
+	var completed atomic.Int64
+	for time.Now().Before(deadline) {
+		run := sample()
+		pool.SubmitFunc(func() {
+			if run() == nil && time.Now().Before(deadline) {
+				completed.Add(1)
+			}
+		})
+	}
+	pool.CancelQueued()
+	pool.Close()
+	return completed.Load()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestAutoTuneWorkersPrefersConcurrencyForWaitingTasks(t *testing.T) {
+	sample := func() func() error {
+		return func() error {
+			time.Sleep(2 * time.Millisecond) //! Waits on a downstream, so more workers help.
+			return nil
+		}
+	}
+	before := runtime.NumGoroutine()
+	if best := AutoTuneWorkers(sample, []int{1, 8}, 50*time.Millisecond); best != 8 {
+		t.Fatalf("AutoTuneWorkers picked %d workers for sleeping tasks, want 8", best)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("%d goroutines left behind by the trial pools", after-before)
+	}
+	if best := AutoTuneWorkers(sample, []int{0, -1}, time.Millisecond); best != 0 {
+		t.Fatalf("AutoTuneWorkers with no valid candidate = %d", best)
+	}
+}
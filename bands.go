@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ! WithPriorityBands reserves workers for priority bands, so critical tasks keep guaranteed
+// ! capacity however much lower-priority work floods the pool, which a shared priority queue
+// ! alone cannot promise once the flood occupies every worker. reserved maps a band's lowest
+// ! priority to its number of workers: a task belongs to the band with the highest level at or
+// ! below its priority at dispatch, and tasks below every level to none. Tasks of a band may
+// ! run on its own workers, those of the bands below it and the unreserved ones, but never on
+// ! the workers reserved for higher bands, so a band waits for one of its workers rather than
+// ! borrow theirs. With workConserving, idle reserved workers help lower bands instead, which
+// ! keeps every worker busy but gives the guarantee up while borrowed workers are busy, since
+// ! a running task is never preempted. Once the pool is closing, reservations no longer apply.
+// ! New fails with ErrBandsExceedWorkers if more workers are reserved than the pool has.
+func WithPriorityBands(reserved map[int]int, workConserving bool) Option {
+	return func(pool *Pool) {
+		bands := &priorityBands{workConserving: workConserving}
+		bands.levels = slices.Sorted(func(yield func(int) bool) {
+			for level := range reserved {
+				if !yield(level) {
+					return
+				}
+			}
+		})
+		for _, level := range bands.levels {
+			bands.reserved = append(bands.reserved, max(reserved[level], 0))
+		}
+		bands.running = make([]int, len(bands.levels)+1)
+		pool.priorityBands = bands
+	}
+}
+
+// ! BandStats describes one priority band, see PriorityBandStats.
+type BandStats struct {
+	Level    int //! The band's lowest priority.
+	Reserved int //! Workers reserved for the band.
+	Running  int //! Tasks of the band running right now, on any worker.
+	//! Running divided by Reserved; above 1 when the band also uses unreserved or lower workers.
+	Utilization float64
+}
+
+// ! PriorityBandStats returns a snapshot of every band of WithPriorityBands, lowest first, or
+// ! nil without the option.
+func (pool *Pool) PriorityBandStats() []BandStats {
+	bands := pool.priorityBands
+	if bands == nil {
+		return nil
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	stats := make([]BandStats, len(bands.levels))
+	for index, level := range bands.levels {
+		stats[index] = BandStats{Level: level, Reserved: bands.reserved[index], Running: bands.running[index+1]}
+		if stats[index].Reserved > 0 {
+			stats[index].Utilization = float64(stats[index].Running) / float64(stats[index].Reserved)
+		}
+	}
+	return stats
+}
+
+// ! priorityBands tracks how many tasks of each band are running. The pool mutex guards it.
+type priorityBands struct {
+	levels         []int //! Lowest priority of each band, ascending.
+	reserved       []int //! Workers reserved per band, by index into levels.
+	running        []int //! Running tasks per band; index 0 is for tasks below every band.
+	workConserving bool
+}
+
+// ! check validates the reservations against the worker count.
+func (bands *priorityBands) check(workers int) error {
+	if bands == nil {
+		return nil
+	}
+	total := 0
+	for _, reserved := range bands.reserved {
+		total += reserved
+	}
+	if total > workers {
+		return fmt.Errorf("%w: %d workers reserved for priority bands, the pool has %d", ErrBandsExceedWorkers, total, workers)
+	}
+	return nil
+}
+
+// ! bandOf returns the index into running of the band a priority belongs to.
+func (bands *priorityBands) bandOf(priority int) int {
+	band := 0
+	for index, level := range bands.levels {
+		if priority >= level {
+			band = index + 1
+		}
+	}
+	return band
+}
+
+// ! enterBand starts a band task on a worker if no higher band's reservation forbids it, and
+// ! reports whether it did. It always succeeds without bands. The pool mutex must be held.
+func (pool *Pool) enterBand(nextTask *task) bool {
+	bands := pool.priorityBands
+	if bands == nil {
+		return true
+	}
+	band := bands.bandOf(nextTask.priority)
+	if !bands.workConserving && !pool.closed {
+		//! Each higher band keeps its reserved workers out of reach of the bands below it.
+		reservedAbove, runningBelow := 0, 0
+		for index := range bands.reserved {
+			reservedAbove += bands.reserved[index]
+		}
+		for higher := 1; higher < len(bands.running); higher++ {
+			runningBelow += bands.running[higher-1]
+			if higher > band && runningBelow+1 > pool.totalWorkers-reservedAbove {
+				return false
+			}
+			reservedAbove -= bands.reserved[higher-1]
+		}
+	}
+	nextTask.band = band
+	bands.running[band]++
+	return true
+}
+
+// ! leaveBand accounts for a band task that finished and lets waiting workers look again.
+func (pool *Pool) leaveBand(finishedTask *task) {
+	if pool.priorityBands == nil {
+		return
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pool.priorityBands.running[finishedTask.band]--
+	pool.taskAvailable.Broadcast()
+}
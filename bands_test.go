@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPriorityBandsKeepReservedWorkers(t *testing.T) {
+	for _, workConserving := range []bool{false, true} {
+		pool := newTestPool(t, WithWorkers(3), WithPriorityBands(map[int]int{10: 1}, workConserving))
+		release := make(chan struct{})
+		var lowRunning, lowPeak atomic.Int64
+		for range 5 {
+			pool.Submit(TaskFunc(func(context.Context) error {
+				storeMax(&lowPeak, lowRunning.Add(1))
+				<-release
+				lowRunning.Add(-1)
+				return nil
+			}))
+		}
+		time.Sleep(20 * time.Millisecond) //! Let the flood take every worker it may.
+
+		wantPeak := int64(2)
+		if workConserving {
+			wantPeak = 3
+		}
+		if peak := lowPeak.Load(); peak != wantPeak {
+			t.Errorf("workConserving=%v: %d low-priority tasks ran at once, want %d", workConserving, peak, wantPeak)
+		}
+		if !workConserving {
+			started := make(chan struct{})
+			pool.SubmitWithPriority(10, TaskFunc(func(context.Context) error { close(started); <-release; return nil }))
+			returnsWithin(t, time.Second, "a high-priority task during the flood", func() { <-started })
+			if stats := pool.PriorityBandStats(); len(stats) != 1 || stats[0].Running != 1 || stats[0].Utilization != 1 {
+				t.Errorf("PriorityBandStats returned %+v, want the band's one worker busy", stats)
+			}
+		}
+		close(release)
+		pool.Wait()
+	}
+	if _, err := New(WithWorkers(2), WithPriorityBands(map[int]int{5: 2, 10: 1}, false)); !errors.Is(err, ErrBandsExceedWorkers) {
+		t.Errorf("New with 3 reserved of 2 workers returned %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import "sync"
+
+// ! SubmitCallback is like Submit, but calls callback with the task's final error once it has
+// ! finished, however it ended: ran, failed, or was dropped, as by CancelQueued. By default the
+// ! callback runs on whichever goroutine finished the task, usually the worker, which is held up
+// ! until it returns; see WithCallbackDispatcher to keep slow callbacks off the workers.
+func (pool *Pool) SubmitCallback(work Task, callback func(err error)) (*TaskHandle, error) {
+	return pool.enqueue(&task{work: work, run: runTask(work), callback: callback})
+}
+
+// ! WithCallbackDispatcher runs SubmitCallback's callbacks on n goroutines of their own instead
+// ! of the workers, so callbacks doing I/O never slow task processing down, and at most n of
+// ! them run at once. Finished tasks hand their callback over without waiting, however far
+// ! behind the dispatchers are, so a backlog of callbacks grows in memory. Callbacks start in
+// ! the order their tasks finished, but with n above 1 they run concurrently and may complete
+// ! in any order; n of 1 runs them one after another in that order. Close waits for every
+// ! pending callback before it returns.
+func WithCallbackDispatcher(n int) Option {
+	return func(pool *Pool) {
+		pool.callbacks = &callbackDispatcher{goroutines: max(n, 1)}
+	}
+}
+
+// ! callbackDispatcher is an unbounded queue of callbacks drained by a fixed set of goroutines.
+type callbackDispatcher struct {
+	mutex      sync.Mutex
+	ready      *sync.Cond //! Signalled when a callback is queued or the dispatcher is stopped.
+	pending    []func()
+	stopped    bool
+	goroutines int
+	running    sync.WaitGroup
+}
+
+// ! startCallbackDispatcher spawns the dispatcher goroutines, if the pool has a dispatcher.
+func (pool *Pool) startCallbackDispatcher() {
+	dispatcher := pool.callbacks
+	if dispatcher == nil {
+		return
+	}
+	dispatcher.ready = sync.NewCond(&dispatcher.mutex)
+	for range dispatcher.goroutines {
+		dispatcher.running.Add(1)
+		pool.spawn(dispatcher.drain, dispatcher.running.Done)
+	}
+}
+
+// ! dispatch runs callback on a dispatcher goroutine, or right away without a running dispatcher.
+func (dispatcher *callbackDispatcher) dispatch(callback func()) {
+	if dispatcher == nil {
+		callback()
+		return
+	}
+	dispatcher.mutex.Lock()
+	if dispatcher.stopped { //! A straggler after Close, such as a task Shutdown gave up on late.
+		dispatcher.mutex.Unlock()
+		callback()
+		return
+	}
+	dispatcher.pending = append(dispatcher.pending, callback)
+	dispatcher.ready.Signal()
+	dispatcher.mutex.Unlock()
+}
+
+// ! drain runs queued callbacks until the dispatcher is stopped and nothing is left.
+func (dispatcher *callbackDispatcher) drain() {
+	for {
+		dispatcher.mutex.Lock()
+		for len(dispatcher.pending) == 0 && !dispatcher.stopped {
+			dispatcher.ready.Wait()
+		}
+		if len(dispatcher.pending) == 0 {
+			dispatcher.mutex.Unlock()
+			return
+		}
+		callback := dispatcher.pending[0]
+		dispatcher.pending[0] = nil
+		dispatcher.pending = dispatcher.pending[1:]
+		dispatcher.mutex.Unlock()
+		callback()
+	}
+}
+
+// ! stop lets the dispatcher goroutines finish the pending callbacks and waits until they have.
+func (dispatcher *callbackDispatcher) stop() {
+	if dispatcher == nil {
+		return
+	}
+	dispatcher.mutex.Lock()
+	dispatcher.stopped = true
+	dispatcher.ready.Broadcast()
+	dispatcher.mutex.Unlock()
+	dispatcher.running.Wait()
+}
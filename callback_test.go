@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCallbackDispatcherKeepsWorkersFree(t *testing.T) {
+	pool, err := New(WithWorkers(1), WithCallbackDispatcher(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	release := make(chan struct{})
+	var calls atomic.Int32
+	failure := errors.New("failed")
+	var reported atomic.Value
+	pool.SubmitCallback(noopTask, func(error) { <-release; calls.Add(1) }) //! A slow callback.
+	pool.SubmitCallback(TaskFunc(func(context.Context) error { return failure }), func(err error) {
+		reported.Store(err)
+		calls.Add(1)
+	})
+	returnsWithin(t, time.Second, "Wait while a callback blocks", pool.Wait)
+
+	close(release)
+	pool.Close()
+	if calls.Load() != 2 || reported.Load() != failure {
+		t.Fatalf("%d callbacks ran, the failing task reported %v", calls.Load(), reported.Load())
+	}
+}
+
+func TestSubmitCallbackReportsCancellation(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	got := make(chan error, 1)
+	pool.SubmitCallback(noopTask, func(err error) { got <- err })
+	pool.CancelQueued()
+	close(release)
+	if err := <-got; !errors.Is(err, ErrTaskCancelled) {
+		t.Fatalf("callback got %v, want ErrTaskCancelled", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+)
+
+// ! CancelQueued drops every task that is still waiting in the queue, including any spilled
+// ! to disk, and reports how many were dropped. Tasks that are already running are left
+// ! alone, and the pool stays open, so more tasks can be submitted afterwards. Each dropped
+// ! task has its onCancel callback invoked (see SubmitWithCancel) and its handle marked as
+// ! cancelled and done, with ErrTaskCancelled as its error.
+func (pool *Pool) CancelQueued() int {
+	pool.mutex.Lock()
+	dropped := pool.queue.removeAll()
+	dropped = append(dropped, pool.diskSpill.removeAll()...)
+	pool.spaceAvailable.Broadcast()
+	pool.mutex.Unlock()
+
+	//! Callbacks run outside the lock so they are free to submit new tasks.
+	for _, droppedTask := range dropped {
+		pool.cancelTask(droppedTask)
+	}
+	return len(dropped)
+}
+
+// ! CancelByTag cancels every queued or running task tagged key=value (see SubmitTagged), for
+// ! example all jobs of a user who just logged out, and reports how many tasks it affected.
+// ! Queued matches are dropped as by CancelQueued. Running matches have their context
+// ! cancelled and are not retried; they still finish with whatever error they return, so
+// ! tasks that ignore their context run to completion. Tasks spilled to disk carry no tags
+// ! and are never matched.
+func (pool *Pool) CancelByTag(key string, value string) int {
+	matches := func(candidate *task) bool {
+		tagValue, ok := candidate.tags[key]
+		return ok && tagValue == value
+	}
+
+	pool.mutex.Lock()
+	dropped := pool.queue.removeMatching(matches)
+	if len(dropped) > 0 {
+		pool.spaceAvailable.Broadcast()
+	}
+	interrupted := 0
+	for runningTask, cancel := range pool.runningCancels {
+		if matches(runningTask) {
+			runningTask.handle.requestCancel()
+			cancel()
+			interrupted++
+		}
+	}
+	pool.mutex.Unlock()
+
+	for _, droppedTask := range dropped {
+		droppedTask.handle.requestCancel()
+		pool.cancelTask(droppedTask)
+	}
+	return len(dropped) + interrupted
+}
+
+// ! trackRunning gives a running task a context of its own that CancelByTag and Cancel can
+// ! cancel, carrying the task's handle for SubmitChild, and returns it with a function to call
+// ! once the task has finished.
+func (pool *Pool) trackRunning(ctx context.Context, runningTask *task) (context.Context, func()) {
+	taskContext, cancel := context.WithCancel(context.WithValue(ctx, taskHandleKey{}, runningTask.handle))
+	pool.mutex.Lock()
+	pool.runningCancels[runningTask] = cancel
+	pool.mutex.Unlock()
+	return taskContext, func() {
+		pool.mutex.Lock()
+		delete(pool.runningCancels, runningTask)
+		pool.mutex.Unlock()
+		cancel()
+	}
+}
+
+// ! cancelTask finishes a task that was removed from the queue without running it.
+func (pool *Pool) cancelTask(cancelledTask *task) {
+	pool.cancelTaskWith(cancelledTask, ErrTaskCancelled)
+}
+
+// ! cancelTaskWith is cancelTask reporting err, which must match ErrTaskCancelled, as the reason.
+func (pool *Pool) cancelTaskWith(cancelledTask *task, err error) {
+	defer pool.finishTask()
+	defer pool.forgetProgress(cancelledTask)
+	pool.counters.cancelled.Add(1)
+	pool.recordUnprocessed(cancelledTask)
+	if cancelledTask.onCancel != nil {
+		cancelledTask.onCancel()
+	}
+	cancelledTask.handle.finish(outcomeCancelled, err)
+	pool.forgetSpilled(cancelledTask)
+}
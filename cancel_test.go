@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCancelByTagCancelsQueuedAndRunningMatches(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithRetry(3))
+	loggedOut := map[string]string{"user": "7"}
+	started := make(chan struct{}, 3)
+	var attempts atomic.Int32
+	running, _ := pool.SubmitTagged(loggedOut, TaskFunc(func(ctx context.Context) error {
+		attempts.Add(1)
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	<-started
+	queued, _ := pool.SubmitTagged(loggedOut, noopTask)
+	other, _ := pool.SubmitTagged(map[string]string{"user": "8"}, noopTask)
+
+	if affected := pool.CancelByTag("user", "7"); affected != 2 {
+		t.Errorf("CancelByTag affected %d tasks, want the running and the queued one", affected)
+	}
+	returnsWithin(t, time.Second, "the tasks after CancelByTag", func() { pool.Flush(running, queued, other) })
+	if !errors.Is(running.Err(), context.Canceled) || attempts.Load() != 1 {
+		t.Errorf("the running match ended with %v after %d attempts, want its own error without a retry",
+			running.Err(), attempts.Load())
+	}
+	if !errors.Is(queued.Err(), ErrTaskCancelled) {
+		t.Errorf("the queued match ended with %v, want ErrTaskCancelled", queued.Err())
+	}
+	if other.Err() != nil {
+		t.Errorf("another user's task ended with %v, want it run", other.Err())
+	}
+	if affected := pool.CancelByTag("user", "7"); affected != 0 {
+		t.Errorf("a second CancelByTag affected %d tasks, want none left", affected)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ! CapabilityPolicy decides what SubmitRequiring does with a task requiring a capability
+// ! that no worker advertises.
+type CapabilityPolicy int
+
+const (
+	//! RejectUnavailable makes SubmitRequiring return ErrNoCapableWorker. This is the default.
+	RejectUnavailable CapabilityPolicy = iota
+	//! QueueUnavailable accepts the task anyway. It waits in the queue, and is cancelled by
+	//! Close, unless a capable worker comes along.
+	QueueUnavailable
+)
+
+// ! WithWorkerCapabilities gives workers capability labels, such as "gpu", for pools
+// ! whose workers differ in what they can do. capabilities is called once per worker id
+// ! when the pool is created; replacement workers keep the labels of the id they take over.
+// ! See SubmitRequiring.
+func WithWorkerCapabilities(capabilities func(workerId int) []string) Option {
+	return func(pool *Pool) {
+		pool.capabilities = capabilities
+	}
+}
+
+// ! WithCapabilityPolicy sets what happens to tasks requiring a capability no worker has.
+func WithCapabilityPolicy(policy CapabilityPolicy) Option {
+	return func(pool *Pool) {
+		pool.capabilityPolicy = policy
+	}
+}
+
+// ! SubmitRequiring is like Submit, but the task only runs on a worker that advertises the
+// ! capability (see WithWorkerCapabilities). While such workers are busy the task waits in
+// ! the queue, counting against its size, and does not hold up other tasks. If no worker
+// ! advertises the capability at all, the capability policy applies. Synchronous pools run
+// ! the task inline regardless.
+func (pool *Pool) SubmitRequiring(capability string, work Task) (*TaskHandle, error) {
+	if capability != "" && pool.capabilityPolicy == RejectUnavailable && !pool.hasCapability(capability) {
+		return nil, pool.rejectUnlocked(RejectedNoCapableWorker, fmt.Errorf("%w: %q", ErrNoCapableWorker, capability))
+	}
+	return pool.enqueue(&task{work: work, run: runTask(work), capability: capability})
+}
+
+// ! assignCapabilities records the capabilities of every worker id. It is called by New.
+func (pool *Pool) assignCapabilities() {
+	if pool.capabilities == nil {
+		return
+	}
+	pool.workerCapabilities = make(map[int][]string, pool.totalWorkers)
+	for workerId := 1; workerId <= pool.totalWorkers; workerId++ {
+		pool.workerCapabilities[workerId] = slices.Clone(pool.capabilities(workerId))
+	}
+}
+
+// ! hasCapability reports whether any worker advertises the capability.
+func (pool *Pool) hasCapability(capability string) bool {
+	for _, capabilities := range pool.workerCapabilities {
+		if slices.Contains(capabilities, capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// ! signalTaskAvailable wakes workers for a task that was just queued. Any worker can run
+// ! a plain task, but a task requiring a capability has to wake them all, since the one
+// ! woken by Signal might not be able to run it. So does every task under WithReplay, whose
+// ! recording names the worker. The pool mutex must be held.
+func (pool *Pool) signalTaskAvailable(queuedTask *task) {
+	if queuedTask.capability == "" && pool.dispatchReplay == nil {
+		pool.taskAvailable.Signal()
+		return
+	}
+	pool.taskAvailable.Broadcast()
+}
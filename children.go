@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+)
+
+// ! taskHandleKey is the context key under which a running task's handle is stored.
+type taskHandleKey struct{}
+
+// ! Cancel cancels the task: if it is still queued, held for its dependencies or delayed, it
+// ! is dropped as by CancelQueued; if it is running, its context is cancelled and it is not
+// ! retried, though it still finishes with whatever error it returns. Tasks submitted with
+// ! SubmitChild from the task's context are cancelled in turn, however deep the tree goes.
+// ! Cancelling a finished task, or one spilled to disk, has no effect beyond that cascade,
+// ! except on resubmissions: a task replayed with Replay, before or after the Cancel, is
+// ! cancelled too.
+func (handle *TaskHandle) Cancel() {
+	handle.requestCancel()
+	pool := handle.pool
+	if pool == nil {
+		return
+	}
+	isThis := func(candidate *task) bool { return candidate.handle.identity == handle.identity } //! Resubmissions included.
+
+	pool.mutex.Lock()
+	dropped := pool.queue.removeMatching(isThis)
+	if len(dropped) > 0 {
+		pool.spaceAvailable.Broadcast()
+	}
+	for heldTask := range pool.held {
+		if isThis(heldTask) {
+			delete(pool.held, heldTask)
+			dropped = append(dropped, heldTask)
+		}
+	}
+	for delayedTask, timer := range pool.scheduled {
+		if isThis(delayedTask) {
+			timer.Stop()
+			delete(pool.scheduled, delayedTask)
+			dropped = append(dropped, delayedTask)
+		}
+	}
+	for runningTask, cancel := range pool.runningCancels {
+		if isThis(runningTask) {
+			cancel()
+		}
+	}
+	pool.mutex.Unlock()
+
+	for _, droppedTask := range dropped {
+		pool.cancelTask(droppedTask)
+	}
+}
+
+// ! requestCancel records that the task was cancelled, releasing its SubmitChild watchers.
+func (handle *TaskHandle) requestCancel() {
+	handle.identity.cancel()
+}
+
+// ! SubmitChild is like Submit for subtasks of a tree-shaped workload: the new task is cancelled,
+// ! as by its handle's Cancel, when its parent is. If parent is the context of a running task,
+// ! the parent is that task, and cancelling it through Cancel or CancelByTag cascades to the
+// ! child; the parent merely finishing, or one of its attempts timing out, does not, so
+// ! children may outlive a parent that does not wait for them. Any other context counts as
+// ! cancelled once it is done. Children of a cancelled child are cancelled too. To tie a
+// ! SubPool to a context instead, use context.AfterFunc(ctx, sub.Cancel).
+func (pool *Pool) SubmitChild(parent context.Context, work Task) (*TaskHandle, error) {
+	handle, err := pool.Submit(work)
+	if err != nil {
+		return nil, err
+	}
+	cancelled := parent.Done()
+	if parentHandle, ok := parent.Value(taskHandleKey{}).(*TaskHandle); ok {
+		cancelled = parentHandle.identity.requested
+	}
+	pool.spawn(func() {
+		select {
+		case <-handle.done:
+		case <-cancelled:
+			handle.Cancel()
+		}
+	}, nil)
+	return handle, nil
+}
+
+// ! Boost raises the priority of a task that is still waiting by delta, so that it is
+// ! dispatched sooner, for instance when a user upgrades a pending job to urgent. A task held
+// ! for its dependencies passes the new priority on to them, see SubmitWithDependencies. It
+// ! reports false, changing nothing, if delta is not positive or the task is no longer
+// ! waiting: running, finished, delayed, or spilled to disk.
+func (handle *TaskHandle) Boost(delta int) bool {
+	pool := handle.pool
+	if pool == nil || delta <= 0 {
+		return false
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	for _, queued := range pool.queue.all() {
+		if queued.handle == handle {
+			pool.queue.raise(queued, queued.priority+delta)
+			return true
+		}
+	}
+	for heldTask := range pool.held {
+		if heldTask.handle == handle {
+			heldTask.priority += delta
+			pool.inheritPriority(heldTask)
+			return true
+		}
+	}
+	return false
+}
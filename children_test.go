@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCancellingParentCascadesToSubtasks(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(3), WithQueueSize(8))
+	var mutex sync.Mutex
+	observed := map[string]bool{} //! Subtasks that saw their context cancelled.
+	children := map[string]*TaskHandle{}
+
+	//! waitForCancel returns a task that blocks until its context is cancelled, optionally
+	//! spawning a subtask of its own first.
+	var waitForCancel func(name string, child string) Task
+	submitChild := func(parent context.Context, name string, grandchild string) {
+		handle, err := pool.SubmitChild(parent, waitForCancel(name, grandchild))
+		if err != nil {
+			t.Errorf("submitting %s: %v", name, err)
+			return
+		}
+		mutex.Lock()
+		children[name] = handle
+		mutex.Unlock()
+	}
+	waitForCancel = func(name string, child string) Task {
+		return TaskFunc(func(ctx context.Context) error {
+			if child != "" {
+				submitChild(ctx, child, "")
+			}
+			<-ctx.Done()
+			mutex.Lock()
+			observed[name] = true
+			mutex.Unlock()
+			return ctx.Err()
+		})
+	}
+
+	submitted := make(chan struct{})
+	parent, err := pool.Submit(TaskFunc(func(ctx context.Context) error {
+		submitChild(ctx, "running child", "grandchild")
+		time.Sleep(10 * time.Millisecond) //! Let the child and grandchild take the other two workers.
+		submitChild(ctx, "queued child", "")
+		close(submitted)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-submitted
+	parent.Cancel()
+
+	mutex.Lock()
+	names := make([]string, 0, len(children))
+	for name := range children {
+		names = append(names, name)
+	}
+	mutex.Unlock()
+	if len(names) != 3 {
+		t.Fatalf("got subtasks %v, want a running child, its grandchild and a queued child", names)
+	}
+	for _, name := range names {
+		mutex.Lock()
+		handle := children[name]
+		mutex.Unlock()
+		select {
+		case <-handle.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not cancelled along with its parent", name)
+		}
+		mutex.Lock()
+		sawCancel := observed[name]
+		mutex.Unlock()
+		//! A child still queued is dropped; one already running sees its context cancelled.
+		if !sawCancel && !handle.Cancelled() {
+			t.Errorf("%s finished with %v without observing the cancellation", name, handle.Err())
+		}
+	}
+}
+
+func TestSubmitChildFollowsPlainContext(t *testing.T) {
+	pool := newTestPool(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	handle, err := pool.SubmitChild(ctx, TaskFunc(func(taskContext context.Context) error {
+		<-taskContext.Done()
+		return taskContext.Err()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case <-handle.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the child of a cancelled context kept running")
+	}
+}
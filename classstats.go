@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ! ClassTag is the tag key that assigns a task to a class for StatsByClass, as in
+// ! SubmitTagged(map[string]string{ClassTag: "image-resize"}, task).
+const ClassTag = "class"
+
+// ! classLatencyBounds are the upper bounds of the latency histogram buckets in ClassStats.
+// ! A last bucket without a bound collects everything slower.
+var classLatencyBounds = [...]time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// ! ClassStats is a snapshot of what tasks of one class have done, see StatsByClass.
+type ClassStats struct {
+	Completed int64 //! Tasks of the class that finished without an error.
+	Failed    int64 //! Tasks of the class whose final attempt returned an error.
+
+	//! Average time from a task starting to run until it finished, retries included.
+	MeanLatency time.Duration
+	//! The same latencies as a histogram, with one bucket per bound in ascending order.
+	Latency []LatencyBucket
+}
+
+// ! LatencyBucket counts the tasks whose latency was at most UpperBound and above the
+// ! previous bucket's bound. The last bucket has an UpperBound of math.MaxInt64.
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// ! classCounters holds the live counters behind one ClassStats.
+type classCounters struct {
+	completed    atomic.Int64
+	failed       atomic.Int64
+	totalLatency atomic.Int64
+	buckets      [len(classLatencyBounds) + 1]atomic.Int64
+}
+
+// ! classStats maps class names to their *classCounters.
+type classStats struct {
+	byClass sync.Map
+}
+
+// ! record counts a finished task of the given class.
+func (stats *classStats) record(class string, latency time.Duration, err error) {
+	counters, ok := stats.byClass.Load(class)
+	if !ok {
+		counters, _ = stats.byClass.LoadOrStore(class, &classCounters{})
+	}
+	classCounters := counters.(*classCounters)
+	if err != nil {
+		classCounters.failed.Add(1)
+	} else {
+		classCounters.completed.Add(1)
+	}
+	classCounters.totalLatency.Add(int64(latency))
+	bucket := 0
+	for bucket < len(classLatencyBounds) && latency > classLatencyBounds[bucket] {
+		bucket++
+	}
+	classCounters.buckets[bucket].Add(1)
+}
+
+// ! StatsByClass breaks completed and failed counts and latency down by task class, the value
+// ! of the task's ClassTag tag. Tasks without that tag are not included. Each update is a few
+// ! atomic increments, so tagging every task is cheap.
+func (pool *Pool) StatsByClass() map[string]ClassStats {
+	snapshot := make(map[string]ClassStats)
+	pool.classStats.byClass.Range(func(class, counters any) bool {
+		classCounters := counters.(*classCounters)
+		stats := ClassStats{
+			Completed: classCounters.completed.Load(),
+			Failed:    classCounters.failed.Load(),
+			Latency:   make([]LatencyBucket, len(classCounters.buckets)),
+		}
+		for bucket := range classCounters.buckets {
+			stats.Latency[bucket] = LatencyBucket{UpperBound: math.MaxInt64, Count: classCounters.buckets[bucket].Load()}
+			if bucket < len(classLatencyBounds) {
+				stats.Latency[bucket].UpperBound = classLatencyBounds[bucket]
+			}
+		}
+		if finished := stats.Completed + stats.Failed; finished > 0 {
+			stats.MeanLatency = time.Duration(classCounters.totalLatency.Load() / finished)
+		}
+		snapshot[class.(string)] = stats
+		return true
+	})
+	return snapshot
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStatsByClassBreaksDownCountsAndLatency(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock))
+	taking := func(latency time.Duration, err error) Task {
+		return TaskFunc(func(context.Context) error {
+			clock.advance(latency)
+			return err
+		})
+	}
+	resize := map[string]string{ClassTag: "resize"}
+	pool.SubmitTagged(resize, taking(5*time.Millisecond, nil))
+	pool.SubmitTagged(resize, taking(50*time.Millisecond, nil))
+	pool.SubmitTagged(resize, taking(20*time.Second, errors.New("corrupt image")))
+	pool.SubmitTagged(map[string]string{ClassTag: "thumbnail"}, taking(time.Millisecond, nil))
+	pool.SubmitTagged(map[string]string{"tenant": "acme"}, taking(time.Millisecond, nil))
+	pool.Wait()
+
+	byClass := pool.StatsByClass()
+	if len(byClass) != 2 {
+		t.Fatalf("classes %v, want resize and thumbnail only", byClass)
+	}
+	stats := byClass["resize"]
+	if stats.Completed != 2 || stats.Failed != 1 {
+		t.Errorf("resize completed %d and failed %d, want 2 and 1", stats.Completed, stats.Failed)
+	}
+	if want := (5*time.Millisecond + 50*time.Millisecond + 20*time.Second) / 3; stats.MeanLatency != want {
+		t.Errorf("resize mean latency %v, want %v", stats.MeanLatency, want)
+	}
+	want := []int64{0, 1, 1, 0, 0, 1}
+	for bucket, latency := range stats.Latency {
+		if latency.Count != want[bucket] {
+			t.Errorf("resize bucket up to %v counted %d, want %d", latency.UpperBound, latency.Count, want[bucket])
+		}
+	}
+	if last := stats.Latency[len(stats.Latency)-1].UpperBound; last != math.MaxInt64 {
+		t.Errorf("the last bucket is bounded by %v, want it open", last)
+	}
+	if thumbnail := byClass["thumbnail"]; thumbnail.Completed != 1 || thumbnail.Latency[0].Count != 1 {
+		t.Errorf("thumbnail stats %+v, want one task in the first bucket", thumbnail)
+	}
+}
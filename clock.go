@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+)
+
+// ! Clock is the source of time for the pool: timestamps, windows and delayed tasks.
+// ! Tests can inject a fake clock with WithClock to control time deterministically.
+// ! Durations measured with it must be monotonic, which the time package guarantees for
+// ! readings from time.Now and for timers, so wall-clock steps (NTP corrections, DST
+// ! changes, manual adjustments) never shorten or stretch a delay.
+type Clock interface {
+	Now() time.Time
+	//! AfterFunc calls f on its own goroutine once d has elapsed, measured on the monotonic clock.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// ! Timer is a pending AfterFunc call. Stop reports whether it prevented the call.
+type Timer interface {
+	Stop() bool
+}
+
+// ! WithClock replaces the real clock, mainly for tests.
+func WithClock(clock Clock) Option {
+	return func(pool *Pool) {
+		pool.clock = clock
+	}
+}
+
+// ! realClock is the default Clock, backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ! WithResultCoalescing gathers finished results into slices of up to maxCount, delivered on
+// ! the ResultBatches channel once full or maxWait after their first result, for workloads
+// ! producing tiny results at a rate where a channel send per result dominates. Unlike
+// ! WithResultBatcher, which still sends every result to its goroutine, workers append to the
+// ! pending slice under a mutex and only a full batch costs a channel send. Close delivers the
+// ! final partial batch before closing the channel. Batches follow the Results policy for a
+// ! reader that falls behind: dropped, counted per result in Stats.ResultsDropped, unless
+// ! WithStrictResultDelivery makes workers wait. Batches may arrive slightly out of order.
+func WithResultCoalescing(maxCount int, maxWait time.Duration) Option {
+	return func(pool *Pool) {
+		pool.coalescer = &resultCoalescer{pool: pool, maxCount: maxCount, maxWait: maxWait}
+	}
+}
+
+// ! ResultBatches returns the channel of WithResultCoalescing, closed once Close has finished,
+// ! or nil without the option.
+func (pool *Pool) ResultBatches() <-chan []Result {
+	if pool.coalescer == nil {
+		return nil
+	}
+	return pool.coalescer.output
+}
+
+// ! resultCoalescer is the sink behind ResultBatches.
+type resultCoalescer struct {
+	pool     *Pool
+	maxCount int
+	maxWait  time.Duration
+	output   chan []Result
+
+	mutex      sync.Mutex
+	pending    []Result
+	generation int //! Identifies the pending batch, so a late timer does not flush its successor.
+	timer      Timer
+	closed     bool
+
+	sending sync.RWMutex //! Held for reading by every send, so close does not close output under one.
+}
+
+// ! startCoalescer sizes the ResultBatches channel and attaches the sink. The channel holds
+// ! about as many results as the Results channel would.
+func (pool *Pool) startCoalescer() {
+	coalescer := pool.coalescer
+	if coalescer == nil {
+		return
+	}
+	coalescer.maxCount = max(coalescer.maxCount, 1)
+	coalescer.output = make(chan []Result, (pool.queueSize+pool.totalWorkers)/coalescer.maxCount+1)
+	pool.addResultSink(coalescer)
+}
+
+func (coalescer *resultCoalescer) add(result Result) {
+	coalescer.mutex.Lock()
+	if coalescer.closed {
+		coalescer.mutex.Unlock()
+		return
+	}
+	if coalescer.pending == nil {
+		coalescer.pending = make([]Result, 0, coalescer.maxCount)
+		generation := coalescer.generation
+		coalescer.timer = coalescer.pool.clock.AfterFunc(coalescer.maxWait, func() { coalescer.expire(generation) })
+	}
+	coalescer.pending = append(coalescer.pending, result)
+	var full []Result
+	if len(coalescer.pending) >= coalescer.maxCount {
+		full = coalescer.take()
+	}
+	coalescer.mutex.Unlock()
+	coalescer.send(full)
+}
+
+// ! expire flushes the pending batch once its maxWait is up, unless it was flushed already.
+func (coalescer *resultCoalescer) expire(generation int) {
+	coalescer.mutex.Lock()
+	var due []Result
+	if generation == coalescer.generation && !coalescer.closed {
+		due = coalescer.take()
+	}
+	coalescer.mutex.Unlock()
+	coalescer.send(due)
+}
+
+// ! take removes the pending batch and starts a new generation. The mutex must be held.
+func (coalescer *resultCoalescer) take() []Result {
+	batch := coalescer.pending
+	coalescer.pending = nil
+	coalescer.generation++
+	if coalescer.timer != nil {
+		coalescer.timer.Stop()
+		coalescer.timer = nil
+	}
+	return batch
+}
+
+// ! send delivers a batch, if there is one, dropping it when the reader is behind unless
+// ! deliveries are strict.
+func (coalescer *resultCoalescer) send(batch []Result) {
+	if len(batch) == 0 {
+		return
+	}
+	coalescer.sending.RLock()
+	defer coalescer.sending.RUnlock()
+	if coalescer.pool.strictResults {
+		coalescer.output <- batch
+		return
+	}
+	select {
+	case coalescer.output <- batch:
+	default:
+		coalescer.pool.counters.resultsDropped.Add(int64(len(batch)))
+	}
+}
+
+func (coalescer *resultCoalescer) close() {
+	coalescer.mutex.Lock()
+	if coalescer.closed {
+		coalescer.mutex.Unlock()
+		return
+	}
+	coalescer.closed = true
+	final := coalescer.take()
+	coalescer.mutex.Unlock()
+	coalescer.send(final)
+
+	coalescer.sending.Lock()
+	defer coalescer.sending.Unlock()
+	close(coalescer.output)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResultCoalescingBatchesAndFlushesOnClose(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2), WithResultCoalescing(4, time.Hour), WithStrictResultDelivery(0))
+	for range 10 {
+		pool.Submit(noopTask)
+	}
+	pool.Wait()
+	pool.Close()
+
+	var sizes []int
+	seen := make(map[int]bool)
+	for batch := range pool.ResultBatches() {
+		sizes = append(sizes, len(batch))
+		for _, result := range batch {
+			seen[result.TaskId] = true
+		}
+	}
+	if len(seen) != 10 {
+		t.Fatalf("got results of %d distinct tasks, want 10", len(seen))
+	}
+	if len(sizes) != 3 || sizes[2] != 2 {
+		t.Fatalf("got batches of %v, want two full ones and the final 2", sizes)
+	}
+}
+
+func TestResultCoalescingFlushesAfterMaxWait(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithResultCoalescing(100, time.Second))
+	pool.Submit(noopTask)
+	pool.Wait()
+	select {
+	case batch := <-pool.ResultBatches():
+		t.Fatalf("batch of %d delivered before maxWait", len(batch))
+	default:
+	}
+	clock.advance(time.Second)
+	select {
+	case batch := <-pool.ResultBatches():
+		if len(batch) != 1 {
+			t.Fatalf("got a batch of %d, want 1", len(batch))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no batch after maxWait")
+	}
+}
+
+// ! BenchmarkResultDelivery compares a reader of tiny results on the Results channel with one
+// ! reading coalesced batches.
+func BenchmarkResultDelivery(b *testing.B) {
+	b.Run("channel", func(b *testing.B) {
+		pool, _ := New(WithWorkers(4), WithStrictResultDelivery(0))
+		results := pool.Results()
+		read := make(chan struct{})
+		go func() {
+			defer close(read)
+			for range results {
+			}
+		}()
+		for b.Loop() {
+			pool.Submit(noopTask)
+		}
+		pool.Close()
+		<-read
+	})
+	b.Run("coalesced", func(b *testing.B) {
+		pool, _ := New(WithWorkers(4), WithStrictResultDelivery(0), WithResultCoalescing(256, time.Millisecond))
+		read := make(chan struct{})
+		go func() {
+			defer close(read)
+			for range pool.ResultBatches() {
+			}
+		}()
+		for b.Loop() {
+			pool.Submit(noopTask)
+		}
+		pool.Close()
+		<-read
+	})
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ! TypedResult is the outcome of one function run by a generic helper such as Broadcast.
+type TypedResult[R any] struct {
+	Value R
+	Err   error
+}
+
+// ! Broadcast runs every function on the same input in parallel across the pool and blocks
+// ! until all of them have returned. results[i] always belongs to fns[i]; a function the pool
+// ! refused to run has the submission error, such as ErrPoolClosed, as its Err.
+func Broadcast[T, R any](pool *Pool, input T, fns ...func(T) (R, error)) []TypedResult[R] {
+	results := make([]TypedResult[R], len(fns))
+	handles := make([]*TaskHandle, len(fns))
+	for index, fn := range fns {
+		result := &results[index] //! Each task writes to its own slot, so no locking is needed.
+		handle, err := pool.Submit(TaskFunc(func(ctx context.Context) error {
+			value, err := fn(input)
+			deliverOutput(ctx, func() { result.Value = value })
+			return err
+		}))
+		if err != nil {
+			result.Err = err
+		}
+		handles[index] = handle
+	}
+	pool.Flush(handles...)
+	collectErrors(results, handles)
+	return results
+}
+
+// ! Fanout runs n copies of the same function in parallel across the pool and blocks until all
+// ! of them have returned, for sampling, load tests or Monte Carlo runs that want n independent
+// ! results. results[i] always belongs to the i-th copy; a copy the pool refused to run has the
+// ! submission error, such as ErrPoolClosed, as its Err.
+func Fanout[R any](pool *Pool, n int, fn func() (R, error)) []TypedResult[R] {
+	results := make([]TypedResult[R], max(n, 0))
+	handles := make([]*TaskHandle, len(results))
+	for index := range results {
+		result := &results[index] //! Each copy writes to its own slot, so no locking is needed.
+		handle, err := pool.Submit(TaskFunc(func(ctx context.Context) error {
+			value, err := fn()
+			deliverOutput(ctx, func() { result.Value = value })
+			return err
+		}))
+		if err != nil {
+			result.Err = err
+		}
+		handles[index] = handle
+	}
+	pool.Flush(handles...)
+	collectErrors(results, handles)
+	return results
+}
+
+// ! FirstN runs every task in parallel across the pool and returns as soon as n of them have
+// ! succeeded, cancelling the others as by their handles' Cancel, for quorum reads such as
+// ! asking five replicas and going ahead once three answered. values are in the order the
+// ! tasks succeeded, and values[i] came from tasks[indexes[i]]. Once fewer than n tasks can
+// ! still succeed, it cancels the rest and returns the values it has with an error wrapping
+// ! ErrQuorumNotReached, joined with every failure, submission errors included, each naming
+// ! its task's index. Tasks should watch ctx, since a cancelled running task otherwise keeps
+// ! its worker until it returns; FirstN does not wait for them.
+func FirstN[R any](pool *Pool, n int, tasks []func(ctx context.Context) (R, error)) (values []R, indexes []int, err error) {
+	type outcome struct {
+		index int
+		value R
+		err   error
+	}
+	if n > len(tasks) {
+		return nil, nil, fmt.Errorf("%w: %d tasks can never give %d successes", ErrQuorumNotReached, len(tasks), n)
+	}
+	if n <= 0 {
+		return nil, nil, nil
+	}
+	outcomes := make(chan outcome, len(tasks)) //! Late finishers never block.
+	handles := make([]*TaskHandle, 0, len(tasks))
+	for index, run := range tasks {
+		var value R
+		handle, err := pool.SubmitCallback(TaskFunc(func(ctx context.Context) error {
+			output, err := run(ctx)
+			deliverOutput(ctx, func() { value = output })
+			return err
+		}), func(err error) { outcomes <- outcome{index: index, value: value, err: err} })
+		if err != nil {
+			outcomes <- outcome{index: index, err: err}
+			continue
+		}
+		handles = append(handles, handle)
+	}
+	defer func() {
+		for _, handle := range handles {
+			handle.Cancel()
+		}
+	}()
+
+	var failures []error
+	for len(values) < n && len(tasks)-len(failures) >= n {
+		finished := <-outcomes
+		if finished.err != nil {
+			failures = append(failures, fmt.Errorf("task %d: %w", finished.index, finished.err))
+			continue
+		}
+		values = append(values, finished.value)
+		indexes = append(indexes, finished.index)
+	}
+	if len(values) < n {
+		summary := fmt.Errorf("%w: %d of %d tasks failed, %d successes needed", ErrQuorumNotReached, len(failures), len(tasks), n)
+		return values, indexes, errors.Join(append([]error{summary}, failures...)...)
+	}
+	return values, indexes, nil
+}
+
+// ! collectErrors sets the Err of every result whose task was accepted to the task's final
+// ! error, so results of tasks that were dropped or abandoned report why instead of a zero
+// ! Value with no error.
+func collectErrors[R any](results []TypedResult[R], handles []*TaskHandle) {
+	for index, handle := range handles {
+		if handle != nil {
+			results[index].Err = handle.Err()
+		}
+	}
+}
+
+// ! SubmitBatch submits every task and blocks until all of them have finished. errs[i] is the
+// ! error of tasks[i], or the submission error, such as ErrPoolClosed, if the pool refused it.
+func (pool *Pool) SubmitBatch(tasks []Task) []error {
+	errs := make([]error, len(tasks))
+	handles := make([]*TaskHandle, len(tasks))
+	for index, work := range tasks {
+		handles[index], errs[index] = pool.Submit(work)
+	}
+	for index, handle := range handles {
+		if handle != nil {
+			<-handle.done
+			errs[index] = handle.err
+		}
+	}
+	return errs
+}
+
+// ! SubmitBatchCtx is SubmitBatch with one deadline for the whole batch, for fan-outs that
+// ! have an end-to-end time budget rather than per-task timeouts. Once ctx ends, the batch's
+// ! tasks still waiting in the queue are dropped, their errors wrapping both ErrTaskCancelled
+// ! and ctx.Err(), such as context.DeadlineExceeded; running ones have their context cancelled
+// ! and are not retried, and report whatever they return; and tasks not yet submitted report
+// ! ctx.Err() as submission errors. errs[i] belongs to tasks[i], so errors.Join(errs...) gives
+// ! the combined error of the batch.
+func (pool *Pool) SubmitBatchCtx(ctx context.Context, tasks []Task) []error {
+	errs := make([]error, len(tasks))
+	handles := make([]*TaskHandle, len(tasks))
+	marker := &batchMarker{}
+	stop := context.AfterFunc(ctx, func() { pool.abandonBatch(marker, ctx.Err()) })
+	defer stop()
+	for index, work := range tasks {
+		handles[index], errs[index] = pool.enqueueContext(ctx, &task{work: work, run: runTask(work), batch: marker})
+	}
+	for index, handle := range handles {
+		if handle != nil {
+			<-handle.done
+			errs[index] = handle.err
+		}
+	}
+	return errs
+}
+
+// ! batchMarker identifies the tasks of one SubmitBatchCtx call.
+type batchMarker struct{}
+
+// ! abandonBatch drops the queued tasks of a batch whose context ended and cancels the contexts
+// ! of its running ones.
+func (pool *Pool) abandonBatch(marker *batchMarker, cause error) {
+	pool.mutex.Lock()
+	dropped := pool.queue.removeMatching(func(queued *task) bool { return queued.batch == marker })
+	if len(dropped) > 0 {
+		pool.spaceAvailable.Broadcast()
+	}
+	for runningTask, cancel := range pool.runningCancels {
+		if runningTask.batch == marker {
+			runningTask.handle.requestCancel()
+			cancel()
+		}
+	}
+	pool.mutex.Unlock()
+
+	for _, droppedTask := range dropped {
+		pool.cancelTaskWith(droppedTask, fmt.Errorf("%w: %w", ErrTaskCancelled, cause))
+	}
+}
+
+// ! TaskSpec describes one task of a mixed batch, see SubmitBatchTasks. Zero fields keep the
+// ! pool's defaults.
+type TaskSpec struct {
+	Task        Task
+	Priority    int               //! Dispatch priority as with SubmitWithPriority; zero submits plain FIFO work.
+	Tags        map[string]string //! Labels as with SubmitTagged; the map is copied.
+	Timeout     time.Duration     //! Per-attempt timeout replacing WithTaskTimeout's; the leak grace period stays the pool's.
+	MaxAttempts int               //! Total attempts replacing WithRetry's.
+}
+
+// ! SubmitBatchTasks submits a batch whose tasks each get their own priority, tags, timeout and
+// ! retry policy, in one call. It returns at once with one handle per spec, in order, to wait
+// ! on with Flush; handles[i] is nil if the pool refused specs[i], and the returned error joins
+// ! every such submission error, each naming the index of its spec.
+func (pool *Pool) SubmitBatchTasks(specs []TaskSpec) ([]*TaskHandle, error) {
+	handles := make([]*TaskHandle, len(specs))
+	var errs []error
+	for index, spec := range specs {
+		handle, err := pool.enqueue(&task{
+			work:        spec.Task,
+			run:         runTask(spec.Task),
+			priority:    spec.Priority,
+			prioritized: spec.Priority != 0,
+			tags:        copyTags(spec.Tags),
+			timeout:     spec.Timeout,
+			maxAttempts: spec.MaxAttempts,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %d: %w", index, err))
+			continue
+		}
+		handles[index] = handle
+	}
+	return handles, errors.Join(errs...)
+}
+
+// ! SubmitBatchAsync is SubmitBatch without the blocking: it returns at once, submits the tasks
+// ! in the background, and delivers the error slice on the returned channel once the whole batch
+// ! has finished, so several batches can be awaited with a select. The channel is buffered, so
+// ! nothing is left blocked if its value is never received.
+func (pool *Pool) SubmitBatchAsync(tasks []Task) <-chan []error {
+	done := make(chan []error, 1)
+	pool.spawn(func() { done <- pool.SubmitBatch(tasks) }, nil)
+	return done
+}
+
+// ! SubmitAll submits every task read from tasks until the channel is closed or ctx ends, then
+// ! waits for the submitted tasks to finish. Each submission goes through SubmitCtx, so the
+// ! pool's admission policy (queue space, rate limit, load shedding) throttles how fast the
+// ! channel is drained. The returned error joins every task error and submission error,
+// ! plus ctx.Err() if ctx ended first; it is nil if everything succeeded. Once the pool
+// ! refuses a task because it is closed, the rest of the channel is left unread.
+func (pool *Pool) SubmitAll(ctx context.Context, tasks <-chan func() error) error {
+	var (
+		mutex    sync.Mutex
+		errs     []error
+		finished sync.WaitGroup
+	)
+	record := func(err error) {
+		mutex.Lock()
+		errs = append(errs, err)
+		mutex.Unlock()
+	}
+
+	for reading := true; reading; {
+		select {
+		case run, ok := <-tasks:
+			if !ok {
+				reading = false
+				break
+			}
+			handle, err := pool.SubmitCtx(ctx, TaskFunc(func(context.Context) error { return run() }))
+			if err != nil {
+				if ctx.Err() == nil {
+					record(err)
+				}
+				reading = !errors.Is(err, ErrPoolClosed) && ctx.Err() == nil
+				break
+			}
+			finished.Add(1)
+			pool.spawn(func() {
+				<-handle.Done()
+				if err := handle.Err(); err != nil {
+					record(err)
+				}
+			}, finished.Done)
+		case <-ctx.Done():
+			reading = false
+		}
+	}
+	finished.Wait()
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ! MapResults applies fn to every value received from in on workers goroutines of its own
+// ! and sends the outputs on the returned channel, for a second stage such as "parse, then
+// ! enrich" after results come out of a pool. Outputs arrive in completion order, not input
+// ! order. The returned channel is closed once in has been closed and every output has been
+// ! delivered, so ranging over it ends the stage cleanly. The caller must keep receiving
+// ! until then, or the stage's goroutines stay blocked. workers below 1 count as 1.
+func MapResults[R, S any](in <-chan R, workers int, fn func(R) S) <-chan S {
+	workers = max(workers, 1)
+	out := make(chan S, workers)
+	var running sync.WaitGroup
+	running.Add(workers)
+	for range workers {
+		go func() {
+			defer running.Done()
+			for value := range in {
+				out <- fn(value)
+			}
+		}()
+	}
+	go func() {
+		running.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// ! Reduce folds every value received from in into an accumulator, starting from initial,
+// ! for map-reduce jobs such as summing sizes or merging histograms. It drains in on a
+// ! goroutine of its own and calls reduce from that goroutine only, so reduce needs no locking.
+// ! The returned function blocks until in has been closed and every value folded, then returns
+// ! the final accumulator; it may be called any number of times from any goroutine.
+func Reduce[R, A any](in <-chan R, initial A, reduce func(A, R) A) func() A {
+	accumulator := initial
+	folded := make(chan struct{})
+	go func() {
+		defer close(folded)
+		for value := range in {
+			accumulator = reduce(accumulator, value)
+		}
+	}()
+	return func() A {
+		<-folded
+		return accumulator
+	}
+}
+
+// ! CollectInto appends every value received from in to *dst, on a goroutine of its own, and
+// ! closes the returned channel once in has been closed and the last value appended. Only then
+// ! may *dst be read: with pool.Results() as in, that is after Close, as in
+// ! done := CollectInto(pool.Results(), &results); pool.Close(); <-done. It replaces the
+// ! append loop behind a mutex that collecting results otherwise takes.
+func CollectInto[R any](in <-chan R, dst *[]R) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for value := range in {
+			*dst = append(*dst, value)
+		}
+	}()
+	return done
+}
+
+// ! CollectIntoMap is CollectInto for a map: every value received from in is stored in dst
+// ! under key(value), a later value replacing an earlier one with the same key. dst must not
+// ! be touched until the returned channel is closed.
+func CollectIntoMap[K comparable, R any](in <-chan R, dst map[K]R, key func(R) K) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for value := range in {
+			dst[key(value)] = value
+		}
+	}()
+	return done
+}
+
+// ! Chain composes fns into a single task body that runs them one after another on the same
+// ! worker, each receiving the output of the previous one; the first receives nil. It stops
+// ! at the first error and returns it, naming the failing step. Submitting a chain instead of
+// ! its steps keeps a sequence of cheap steps on one worker, for locality and to save the
+// ! handoff between workers, while many chains still run in parallel, for example through
+// ! SubmitWait. The final output is discarded; use SubmitChain to keep it.
+func Chain(fns ...func(any) (any, error)) func() error {
+	return func() error {
+		_, err := runChain(nil, fns)
+		return err
+	}
+}
+
+// ! SubmitChain submits the chain of fns, fed with input, as one task, see Chain, and returns a
+// ! Future for the output of the last step as an R. If that output is not an R, the future
+// ! fails with an error saying so. An empty chain passes input through.
+func SubmitChain[R any](pool *Pool, input any, fns ...func(any) (any, error)) (*Future[R], error) {
+	return SubmitFuture(pool, func(context.Context) (R, error) {
+		var zero R
+		output, err := runChain(input, fns)
+		if err != nil || output == nil {
+			return zero, err
+		}
+		typed, ok := output.(R)
+		if !ok {
+			return zero, fmt.Errorf("worker pool: chain produced a %T, not a %v", output, reflect.TypeFor[R]())
+		}
+		return typed, nil
+	})
+}
+
+// ! runChain feeds input through fns in order and returns the last output.
+func runChain(input any, fns []func(any) (any, error)) (any, error) {
+	value := input
+	for step, fn := range fns {
+		output, err := fn(value)
+		if err != nil {
+			return nil, fmt.Errorf("chain step %d: %w", step+1, err)
+		}
+		value = output
+	}
+	return value, nil
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	pool := newTestPool(t)
+	parse := func(input any) (any, error) { return strconv.Atoi(input.(string)) }
+	double := func(input any) (any, error) { return input.(int) * 2, nil }
+
+	future, err := SubmitChain[int](pool, "21", parse, double)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, err := future.Get(); output != 42 || err != nil {
+		t.Errorf("chain returned %d, %v, want 42, nil", output, err)
+	}
+
+	calledAfterError := false
+	failing, _ := SubmitChain[int](pool, "x", parse, func(any) (any, error) {
+		calledAfterError = true
+		return nil, nil
+	})
+	if _, err := failing.Get(); err == nil || !strings.Contains(err.Error(), "chain step 1") || calledAfterError {
+		t.Errorf("failing chain returned %v and ran later steps: %v", err, calledAfterError)
+	}
+
+	mistyped, _ := SubmitChain[string](pool, "21", parse)
+	if _, err := mistyped.Get(); err == nil || !strings.Contains(err.Error(), "not a string") {
+		t.Errorf("mistyped chain returned %v", err)
+	}
+
+	errStep := errors.New("step failed")
+	if err := pool.SubmitWait(Chain(func(any) (any, error) { return nil, errStep })); !errors.Is(err, errStep) {
+		t.Errorf("Chain returned %v, want the step's error", err)
+	}
+}
+
+func TestSubmitBatchCtxBoundsTheWholeBatch(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithRetry(3))
+	var attempts atomic.Int32
+	slow := TaskFunc(func(ctx context.Context) error {
+		attempts.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	errs := pool.SubmitBatchCtx(ctx, []Task{slow, noopTask, noopTask})
+	if !errors.Is(errs[0], context.Canceled) || attempts.Load() != 1 {
+		t.Fatalf("running task: err %v after %d attempts, want one cancelled attempt", errs[0], attempts.Load())
+	}
+	for index, err := range errs[1:] {
+		if !errors.Is(err, ErrTaskCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("queued task %d: err %v, want a deadline-exceeded cancellation", index+1, err)
+		}
+	}
+	if err := errors.Join(errs...); err == nil {
+		t.Fatal("the combined batch error is nil")
+	}
+}
+
+func TestFirstNReturnsAtQuorum(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(5))
+	slowCancelled := make(chan struct{})
+	replica := func(value int, err error, delay time.Duration) func(context.Context) (int, error) {
+		return func(ctx context.Context) (int, error) {
+			time.Sleep(delay)
+			return value, err
+		}
+	}
+	tasks := []func(context.Context) (int, error){
+		replica(10, nil, 0),
+		replica(0, errors.New("replica down"), 0),
+		replica(12, nil, 0),
+		replica(13, nil, 10*time.Millisecond),
+		func(ctx context.Context) (int, error) { <-ctx.Done(); close(slowCancelled); return 0, ctx.Err() },
+	}
+	values, indexes, err := FirstN(pool, 3, tasks)
+	if err != nil || len(values) != 3 {
+		t.Fatalf("FirstN returned %v from %v with %v", values, indexes, err)
+	}
+	for position, index := range indexes {
+		if values[position] != 10+index {
+			t.Errorf("value %d is attributed to task %d", values[position], index)
+		}
+	}
+	returnsWithin(t, time.Second, "the straggler's cancellation", func() { <-slowCancelled })
+
+	_, _, err = FirstN(pool, 2, tasks[:2])
+	if !errors.Is(err, ErrQuorumNotReached) || !strings.Contains(err.Error(), "task 1: replica down") {
+		t.Fatalf("FirstN without a quorum returned %v", err)
+	}
+}
+
+func TestCollectIntoFillsTheDestination(t *testing.T) {
+	pool, err := New(WithWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []Result
+	collected := CollectInto(pool.Results(), &results)
+	typed, err := NewTyped(2, func(ctx context.Context, input int) (int, error) { return input * input, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	squares := map[int]int{}
+	squared := CollectIntoMap(typed.Successes(), squares, func(square int) int { return square })
+	for input := range 5 {
+		pool.Submit(noopTask)
+		typed.Submit(input)
+	}
+	pool.Close()
+	typed.Pool().Wait()
+	typed.Close()
+	<-collected
+	<-squared
+	if len(results) != 5 || len(squares) != 5 || squares[16] != 16 {
+		t.Fatalf("collected %d results and squares %v", len(results), squares)
+	}
+}
+
+func TestBroadcastKeepsResultsInFunctionOrder(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(3))
+	var started sync.WaitGroup
+	started.Add(3)
+	together := func(derive func(string) (int, error)) func(string) (int, error) {
+		return func(record string) (int, error) {
+			started.Done()
+			started.Wait() //! Only returns if all three run at once.
+			return derive(record)
+		}
+	}
+	errNoDigits := errors.New("no digits")
+	results := Broadcast(pool, "order-42",
+		together(func(record string) (int, error) { return len(record), nil }),
+		together(func(record string) (int, error) { return 0, errNoDigits }),
+		together(func(record string) (int, error) { return strings.Count(record, "-"), nil }))
+
+	if len(results) != 3 || results[0].Value != 8 || results[2].Value != 1 {
+		t.Fatalf("results %+v, want 8 and 1 around the failure", results)
+	}
+	if results[0].Err != nil || !errors.Is(results[1].Err, errNoDigits) || results[2].Err != nil {
+		t.Errorf("errors %v, %v and %v, want only the second to fail", results[0].Err, results[1].Err, results[2].Err)
+	}
+}
+
+func TestBroadcastOnAClosedPoolReportsEachRefusal(t *testing.T) {
+	pool := newTestPool(t)
+	pool.Close()
+	identity := func(input int) (int, error) { return input, nil }
+	for index, result := range Broadcast(pool, 1, identity, identity) {
+		if !errors.Is(result.Err, ErrPoolClosed) {
+			t.Errorf("function %d ended with %v, want ErrPoolClosed", index, result.Err)
+		}
+	}
+}
+
+func TestSubmitAllJoinsEveryTaskError(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	tasks := make(chan func() error, 5)
+	var ran atomic.Int32
+	for _, err := range []error{nil, errFirst, nil, errSecond, nil} {
+		tasks <- func() error { ran.Add(1); return err }
+	}
+	close(tasks)
+	err := pool.SubmitAll(context.Background(), tasks)
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("SubmitAll returned %v, want both task errors", err)
+	}
+	if got := ran.Load(); got != 5 {
+		t.Errorf("%d tasks ran, want all 5", got)
+	}
+}
+
+func TestSubmitAllStopsReading(t *testing.T) {
+	t.Run("closed pool", func(t *testing.T) {
+		pool := newTestPool(t)
+		pool.Close()
+		tasks := make(chan func() error, 3)
+		for range 3 {
+			tasks <- func() error { return nil }
+		}
+		if err := pool.SubmitAll(context.Background(), tasks); !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("SubmitAll returned %v, want ErrPoolClosed", err)
+		}
+		if len(tasks) != 2 {
+			t.Errorf("%d tasks left in the channel, want the two after the refused one", len(tasks))
+		}
+	})
+	t.Run("ended context", func(t *testing.T) {
+		pool := newTestPool(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		var err error
+		returnsWithin(t, time.Second, "SubmitAll on a channel that is never closed", func() {
+			err = pool.SubmitAll(ctx, make(chan func() error))
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("SubmitAll returned %v, want the context's error", err)
+		}
+	})
+}
+
+func TestSubmitAllWatchersCountAsPoolGoroutines(t *testing.T) {
+	pool, err := New(WithWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	tasks := make(chan func() error, 3)
+	for range 3 {
+		tasks <- func() error { <-release; return nil }
+	}
+	close(tasks)
+	done := make(chan error)
+	go func() { done <- pool.SubmitAll(context.Background(), tasks) }()
+
+	returnsWithin(t, time.Second, "a watcher per submitted task", func() {
+		for pool.GoroutineCount() != 2+3 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	pool.Close()
+	if count := pool.GoroutineCount(); count != 0 {
+		t.Errorf("GoroutineCount = %d once the pool has wound down, want 0", count)
+	}
+}
+
+func TestMapResultsRunsAStageOverThePoolsResults(t *testing.T) {
+	pool, err := New(WithWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := pool.Results()
+	for range 6 {
+		pool.Submit(noopTask)
+	}
+	var enriching sync.WaitGroup
+	enriching.Add(3)
+	enriched := MapResults(results, 3, func(result Result) int {
+		if result.TaskId <= 3 {
+			enriching.Done()
+			enriching.Wait() //! Only returns if the stage runs three at once.
+		}
+		return result.TaskId * 10
+	})
+	pool.Close() //! Closes results, which ends the stage.
+
+	seen := map[int]bool{}
+	returnsWithin(t, time.Second, "the stage to drain and close", func() {
+		for value := range enriched {
+			seen[value] = true
+		}
+	})
+	if len(seen) != 6 || !seen[10] || !seen[60] {
+		t.Errorf("the stage produced %v, want every task id times 10", seen)
+	}
+}
+
+func TestMapResultsRunsAtLeastOneWorker(t *testing.T) {
+	in := make(chan int, 2)
+	in <- 1
+	in <- 2
+	close(in)
+	var sum int
+	returnsWithin(t, time.Second, "a stage with zero workers", func() {
+		for value := range MapResults(in, 0, func(value int) int { return -value }) {
+			sum += value
+		}
+	})
+	if sum != -3 {
+		t.Errorf("the stage summed to %d, want -3", sum)
+	}
+}
+
+func TestSubmitBatchTasksAppliesEachSpec(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithTaskTimeout(0, time.Second)) //! Only the grace period, for the spec's timeout.
+	release := occupy(t, pool)
+	var order []string
+	var mutex sync.Mutex
+	recording := func(name string, run func(ctx context.Context) error) Task {
+		return TaskFunc(func(ctx context.Context) error {
+			mutex.Lock()
+			order = append(order, name)
+			mutex.Unlock()
+			return run(ctx)
+		})
+	}
+	var attempts atomic.Int32
+	handles, err := pool.SubmitBatchTasks([]TaskSpec{
+		{Task: recording("plain", func(context.Context) error { return nil })},
+		{Task: recording("urgent", func(context.Context) error { return nil }), Priority: 5, Tags: map[string]string{"tenant": "acme"}},
+		{Task: recording("flaky", func(context.Context) error {
+			if attempts.Add(1) < 3 {
+				return errors.New("transient")
+			}
+			return nil
+		}), MaxAttempts: 3},
+		{Task: recording("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), Timeout: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := map[int]TaskInfo{}
+	for _, info := range pool.PendingTasks() {
+		pending[info.Id] = info
+	}
+	if urgent := pending[handles[1].Id()]; urgent.Priority != 5 || urgent.Tags["tenant"] != "acme" {
+		t.Errorf("the urgent spec is queued as %+v, want priority 5 tagged tenant=acme", urgent)
+	}
+	close(release)
+	returnsWithin(t, time.Second, "the batch", func() { pool.Flush(handles...) })
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(order) == 0 || order[0] != "urgent" {
+		t.Errorf("ran %v, want the prioritized spec first", order)
+	}
+	if handles[2].Err() != nil || attempts.Load() != 3 {
+		t.Errorf("the flaky spec ended with %v after %d attempts, want success on the third", handles[2].Err(), attempts.Load())
+	}
+	if !errors.Is(handles[3].Err(), context.DeadlineExceeded) {
+		t.Errorf("the slow spec ended with %v, want its own timeout", handles[3].Err())
+	}
+}
+
+func TestSubmitBatchTasksNamesEachRefusal(t *testing.T) {
+	pool := newTestPool(t)
+	pool.Close()
+	handles, err := pool.SubmitBatchTasks([]TaskSpec{{Task: noopTask}, {Task: noopTask}})
+	if handles[0] != nil || handles[1] != nil {
+		t.Errorf("a closed pool returned handles %v, want none", handles)
+	}
+	if !errors.Is(err, ErrPoolClosed) || !strings.Contains(err.Error(), "task 0:") || !strings.Contains(err.Error(), "task 1:") {
+		t.Errorf("SubmitBatchTasks returned %v, want ErrPoolClosed for each spec by index", err)
+	}
+}
+
+func TestReduceFoldsEveryValueOnceTheInputCloses(t *testing.T) {
+	pool, err := New(WithWorkers(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := pool.Results()
+	sizes := map[int]int{} //! Unsynchronized on purpose: reduce runs on a single goroutine.
+	total := Reduce(results, 0, func(sum int, result Result) int {
+		sizes[result.TaskId]++
+		return sum + result.TaskId
+	})
+	for range 10 {
+		pool.Submit(noopTask)
+	}
+
+	folded := make(chan int)
+	go func() { folded <- total() }()
+	select {
+	case sum := <-folded:
+		t.Fatalf("Reduce returned %d before its input closed", sum)
+	case <-time.After(50 * time.Millisecond):
+	}
+	pool.Close()
+	if sum := <-folded; sum != 55 {
+		t.Errorf("Reduce summed the task ids to %d, want 55", sum)
+	}
+	if sum := total(); sum != 55 || len(sizes) != 10 {
+		t.Errorf("a second call returned %d after folding %d tasks, want 55 from all 10", sum, len(sizes))
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ! Config describes a pool in a form that can be loaded from a JSON or YAML file. Zero
+// ! values mean "use the default". NewFromConfig turns it into a pool; the functional
+// ! options remain the way to configure pools from code.
+type Config struct {
+	Workers          int              `json:"workers,omitempty" yaml:"workers,omitempty"`
+	QueueSize        int              `json:"queue_size,omitempty" yaml:"queue_size,omitempty"`
+	RateLimit        *RateLimitConfig `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	Retry            *RetryConfig     `json:"retry,omitempty" yaml:"retry,omitempty"`
+	TaskTimeout      Duration         `json:"task_timeout,omitempty" yaml:"task_timeout,omitempty"`
+	LeakGracePeriod  Duration         `json:"leak_grace_period,omitempty" yaml:"leak_grace_period,omitempty"`
+	MaxLeakedWorkers int              `json:"max_leaked_workers,omitempty" yaml:"max_leaked_workers,omitempty"`
+}
+
+// ! RateLimitConfig is the configuration form of WithRateLimit.
+type RateLimitConfig struct {
+	TasksPerSecond float64 `json:"tasks_per_second" yaml:"tasks_per_second"`
+	Burst          int     `json:"burst" yaml:"burst"`
+}
+
+// ! RetryConfig is the configuration form of WithRetry, WithRetryBackoff and WithRetryBudget.
+type RetryConfig struct {
+	MaxAttempts    int      `json:"max_attempts" yaml:"max_attempts"`
+	InitialBackoff Duration `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"`
+	MaxBackoff     Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+	BudgetRetries  int      `json:"budget_retries,omitempty" yaml:"budget_retries,omitempty"`
+	BudgetWindow   Duration `json:"budget_window,omitempty" yaml:"budget_window,omitempty"`
+}
+
+// ! Duration is a time.Duration written as a string such as "1.5s" in configuration files.
+// ! A plain number is read as nanoseconds.
+type Duration time.Duration
+
+// ! MarshalJSON writes the duration in time.Duration's string form.
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(duration).String())
+}
+
+// ! UnmarshalJSON accepts either a duration string or a number of nanoseconds.
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var nanoseconds int64
+		if err := json.Unmarshal(data, &nanoseconds); err != nil {
+			return fmt.Errorf("duration must be a string such as \"1.5s\" or a number of nanoseconds, got %s", data)
+		}
+		*duration = Duration(nanoseconds)
+		return nil
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*duration = Duration(parsed)
+	return nil
+}
+
+// ! UnmarshalText lets YAML decoders and flag packages read a duration string.
+func (duration *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*duration = Duration(parsed)
+	return nil
+}
+
+// ! NewFromConfig validates the configuration and creates a pool from it, followed by any
+// ! extra options. Every problem found is reported at once, joined in an error wrapping
+// ! ErrInvalidConfig.
+func NewFromConfig(config Config, options ...Option) (*Pool, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return New(append(config.options(), options...)...)
+}
+
+// ! validate checks the configuration for values and combinations that make no sense.
+func (config Config) validate() error {
+	var problems []error
+	invalid := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
+	}
+	if config.Workers < 0 {
+		invalid("workers must not be negative, got %d", config.Workers)
+	}
+	if config.QueueSize < 0 {
+		invalid("queue_size must not be negative, got %d", config.QueueSize)
+	}
+	if config.TaskTimeout < 0 || config.LeakGracePeriod < 0 {
+		invalid("task_timeout and leak_grace_period must not be negative")
+	}
+	if config.LeakGracePeriod > 0 && config.TaskTimeout == 0 {
+		invalid("leak_grace_period needs a task_timeout")
+	}
+	if config.MaxLeakedWorkers < 0 {
+		invalid("max_leaked_workers must not be negative, got %d", config.MaxLeakedWorkers)
+	}
+	if limit := config.RateLimit; limit != nil {
+		if limit.TasksPerSecond <= 0 {
+			invalid("rate_limit.tasks_per_second must be positive, got %v", limit.TasksPerSecond)
+		}
+		if limit.Burst < 1 {
+			invalid("rate_limit.burst must be at least 1, got %d", limit.Burst)
+		}
+	}
+	if retry := config.Retry; retry != nil {
+		if retry.MaxAttempts < 1 {
+			invalid("retry.max_attempts must be at least 1, got %d", retry.MaxAttempts)
+		}
+		if retry.InitialBackoff < 0 || retry.MaxBackoff < 0 {
+			invalid("retry backoffs must not be negative")
+		}
+		if retry.InitialBackoff > 0 && retry.MaxBackoff < retry.InitialBackoff {
+			invalid("retry.max_backoff (%v) must be at least retry.initial_backoff (%v)",
+				time.Duration(retry.MaxBackoff), time.Duration(retry.InitialBackoff))
+		}
+		if retry.BudgetRetries < 0 {
+			invalid("retry.budget_retries must not be negative, got %d", retry.BudgetRetries)
+		}
+		if retry.BudgetRetries > 0 && retry.BudgetWindow <= 0 {
+			invalid("retry.budget_retries needs a positive retry.budget_window")
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// ! options translates the configuration into the equivalent functional options.
+func (config Config) options() []Option {
+	var options []Option
+	if config.Workers > 0 {
+		options = append(options, WithWorkers(config.Workers))
+	}
+	if config.QueueSize > 0 {
+		options = append(options, WithQueueSize(config.QueueSize))
+	}
+	if limit := config.RateLimit; limit != nil {
+		options = append(options, WithRateLimit(limit.TasksPerSecond, limit.Burst))
+	}
+	if retry := config.Retry; retry != nil {
+		options = append(options, WithRetry(retry.MaxAttempts))
+		if retry.InitialBackoff > 0 {
+			options = append(options, WithRetryBackoff(time.Duration(retry.InitialBackoff), time.Duration(retry.MaxBackoff)))
+		}
+		if retry.BudgetRetries > 0 {
+			options = append(options, WithRetryBudget(retry.BudgetRetries, time.Duration(retry.BudgetWindow)))
+		}
+	}
+	if config.TaskTimeout > 0 {
+		options = append(options, WithTaskTimeout(time.Duration(config.TaskTimeout), time.Duration(config.LeakGracePeriod)))
+	}
+	if config.MaxLeakedWorkers > 0 {
+		options = append(options, WithMaxLeakedWorkers(config.MaxLeakedWorkers))
+	}
+	return options
+}
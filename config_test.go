@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDurationReadsStringsAndNanoseconds(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		`"1.5s"`:     1500 * time.Millisecond,
+		`"250ms"`:    250 * time.Millisecond,
+		`2000000000`: 2 * time.Second,
+	} {
+		var duration Duration
+		if err := json.Unmarshal([]byte(input), &duration); err != nil {
+			t.Errorf("reading %s: %v", input, err)
+			continue
+		}
+		if time.Duration(duration) != want {
+			t.Errorf("read %s as %v, want %v", input, time.Duration(duration), want)
+		}
+	}
+	for _, input := range []string{`"soon"`, `true`, `1.5`} {
+		var duration Duration
+		if err := json.Unmarshal([]byte(input), &duration); err == nil {
+			t.Errorf("read %s as %v, want an error", input, time.Duration(duration))
+		}
+	}
+
+	written, err := json.Marshal(Duration(90 * time.Second))
+	if err != nil || string(written) != `"1m30s"` {
+		t.Errorf("wrote %s, %v, want \"1m30s\"", written, err)
+	}
+}
+
+func TestNewFromConfigReportsEveryProblem(t *testing.T) {
+	config := Config{
+		Workers:         -1,
+		LeakGracePeriod: Duration(time.Second),
+		RateLimit:       &RateLimitConfig{TasksPerSecond: 10},
+		Retry:           &RetryConfig{MaxAttempts: 3, InitialBackoff: Duration(time.Second), MaxBackoff: Duration(time.Millisecond)},
+	}
+	pool, err := NewFromConfig(config)
+	if pool != nil || !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewFromConfig returned %v, %v, want ErrInvalidConfig", pool, err)
+	}
+	for _, want := range []string{"workers", "leak_grace_period needs a task_timeout", "rate_limit.burst", "retry.max_backoff"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 4 {
+		t.Errorf("error %q is not the four problems joined", err)
+	}
+}
+
+func TestNewFromConfigAppliesEachSetting(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{
+		"workers": 3,
+		"queue_size": 40,
+		"rate_limit": {"tasks_per_second": 50, "burst": 5},
+		"retry": {"max_attempts": 4, "initial_backoff": "10ms", "max_backoff": "1s", "budget_retries": 20, "budget_window": "1m"},
+		"task_timeout": "2s",
+		"leak_grace_period": 500000000,
+		"max_leaked_workers": 2
+	}`), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := NewFromConfig(config, WithQueueSize(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if pool.totalWorkers != 3 || pool.maxLeakedWorkers != 2 {
+		t.Errorf("workers %d and max leaked %d, want 3 and 2", pool.totalWorkers, pool.maxLeakedWorkers)
+	}
+	if pool.queueSize != 60 {
+		t.Errorf("queue size %d, want the extra option's 60 to override the config's", pool.queueSize)
+	}
+	if limiter := pool.rateLimiter; limiter == nil || limiter.rate != 50 || limiter.burst != 5 {
+		t.Errorf("rate limiter %+v, want 50 per second with a burst of 5", limiter)
+	}
+	if pool.maxAttempts != 4 || pool.retryBackoff != 10*time.Millisecond || pool.maxRetryBackoff != time.Second {
+		t.Errorf("retry %d attempts backing off %v to %v, want 4 from 10ms to 1s",
+			pool.maxAttempts, pool.retryBackoff, pool.maxRetryBackoff)
+	}
+	if budget := pool.retryBudget; budget == nil || budget.limit != 20 || budget.window != time.Minute {
+		t.Errorf("retry budget %+v, want 20 retries a minute", budget)
+	}
+	if pool.taskTimeout != 2*time.Second || pool.leakGracePeriod != 500*time.Millisecond {
+		t.Errorf("task timeout %v with grace %v, want 2s and 500ms", pool.taskTimeout, pool.leakGracePeriod)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ! cpuBudgetWindow is the sliding window WithCPUTimeBudget measures consumption over.
+const cpuBudgetWindow = time.Second
+
+// ! WithCPUTimeBudget caps the pool's CPU footprint on a shared host: once the tasks that
+// ! finished within the last second consumed budget or more, new submissions are refused with
+// ! a *RejectionError wrapping ErrCPUBudgetExceeded until enough of that time has left the
+// ! window. A budget of 500ms thus keeps the pool at about half a core on average, whatever
+// ! its worker count. Consumption is estimated from each task's execution time, as reported in
+// ! Result.ExecDuration and summed up in Stats.CPUTime, since Go offers no per-goroutine CPU
+// ! clock. The estimate counts time a task spends blocked, sleeping or waiting out a retry
+// ! backoff as if it were computing, and misses work a task hands to goroutines of its own, and
+// ! a task's time is only counted when it finishes, so long tasks hit the budget late. It is
+// ! therefore a reasonable cap for CPU-bound tasks and a conservative one for I/O-bound ones.
+func WithCPUTimeBudget(budget time.Duration) Option {
+	return func(pool *Pool) {
+		pool.cpuBudget = &cpuBudget{budget: budget}
+	}
+}
+
+// ! cpuBudget tracks the estimated CPU time of the tasks that finished within the window.
+type cpuBudget struct {
+	mutex  sync.Mutex
+	budget time.Duration
+	spent  []cpuSpend    //! Tasks that finished inside the window, oldest first.
+	total  time.Duration //! Sum of spent.
+}
+
+// ! cpuSpend is the time one task consumed, counted when it finished.
+type cpuSpend struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// ! record counts a finished task's execution time. It is a no-op without a budget.
+func (budget *cpuBudget) record(at time.Time, duration time.Duration) {
+	if budget == nil {
+		return
+	}
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+	budget.spent = append(budget.spent, cpuSpend{at: at, duration: duration})
+	budget.total += duration
+	budget.expire(at)
+}
+
+// ! exhausted reports whether the window's consumption has reached the budget.
+func (budget *cpuBudget) exhausted(now time.Time) bool {
+	if budget == nil {
+		return false
+	}
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+	budget.expire(now)
+	return budget.total >= budget.budget
+}
+
+// ! expire forgets tasks that finished before the window. The budget mutex must be held.
+func (budget *cpuBudget) expire(now time.Time) {
+	dropped := 0
+	for dropped < len(budget.spent) && now.Sub(budget.spent[dropped].at) >= cpuBudgetWindow {
+		budget.total -= budget.spent[dropped].duration
+		dropped++
+	}
+	budget.spent = budget.spent[dropped:]
+}
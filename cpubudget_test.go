@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCPUTimeBudgetRefusesUntilTheWindowMoves(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithCPUTimeBudget(500*time.Millisecond))
+	busy := TaskFunc(func(context.Context) error {
+		clock.stepWall(300 * time.Millisecond) //! Stands in for 300ms of computing.
+		return nil
+	})
+
+	for range 2 {
+		if _, err := pool.Submit(busy); err != nil {
+			t.Fatalf("Submit within the budget: %v", err)
+		}
+		pool.Wait()
+	}
+	_, err := pool.Submit(busy)
+	assertRejected(t, err, RejectedCPUBudget, ErrCPUBudgetExceeded)
+	if cpu := pool.Stats().CPUTime; cpu != 600*time.Millisecond {
+		t.Fatalf("Stats().CPUTime = %v, want 600ms", cpu)
+	}
+
+	clock.stepWall(time.Second)
+	if _, err := pool.Submit(busy); err != nil {
+		t.Fatalf("Submit once the window moved on: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+// ! DeliverySemantics says how often a submitted task may run when things go wrong.
+type DeliverySemantics int
+
+const (
+	//! AtLeastOnce favours getting every task done, for idempotent tasks. A failed or panicking
+	//! attempt is retried as configured with WithRetry even though it may already have had
+	//! side effects, and with WithDiskSpill a task's file is only deleted once the task has
+	//! finished, so a task that was running when the process died runs again on restart.
+	//! Replay submits failed tasks again. A task reaches the dead-letter callback of
+	//! WithMaxLifetimeAttempts once it fails with its lifetime attempts used up, or is denied
+	//! a retry by WithRetryBudget. This is the default.
+	AtLeastOnce DeliverySemantics = iota
+	//! AtMostOnce favours never running a task twice, for tasks that are not idempotent.
+	//! Failed attempts are never retried, whatever WithRetry says, and with WithDiskSpill a
+	//! task's file is deleted as soon as the task is taken off disk, so a task that was
+	//! running when the process died is lost instead of repeated. Replay refuses every
+	//! result, and the retry budget is never consulted, so a failed task only reaches the
+	//! dead-letter callback when WithMaxLifetimeAttempts's cap is 1.
+	AtMostOnce
+)
+
+// ! WithDeliverySemantics picks between at-least-once and at-most-once execution. Under
+// ! either, a leaked task (see WithTaskTimeout) is never retried, since its first attempt
+// ! may still be running, and tasks cancelled or shed before they started never run at all.
+func WithDeliverySemantics(semantics DeliverySemantics) Option {
+	return func(pool *Pool) {
+		pool.delivery = semantics
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+// ! SubmitWithDependencies is like SubmitWithPriority, but the task is held back until every
+// ! task in dependencies has finished, whatever their outcome, and only then queued. It goes
+// ! through the same admission as Submit, so the admission controller, rate limit, load
+// ! shedding and drains apply when it is submitted, and a synchronous pool runs it inline once
+// ! its dependencies are done. To keep a low-priority dependency from stalling an urgent task
+// ! (priority inversion), each dependency that has not started yet inherits the task's
+// ! priority if that is higher, transitively through dependencies of its own and through the
+// ! tasks ahead of it for its SubmitExclusive key. Dependencies already running cannot be sped
+// ! up. The task counts as outstanding from the start, so Wait waits for it; Close cancels it
+// ! if it is still held, as it does with delayed tasks. Nil handles are ignored.
+func (pool *Pool) SubmitWithDependencies(priority int, dependencies []*TaskHandle, work Task) (*TaskHandle, error) {
+	dependent := &task{work: work, run: runTask(work), priority: priority, prioritized: true}
+	for _, dependency := range dependencies {
+		if dependency != nil {
+			dependent.dependencies = append(dependent.dependencies, dependency)
+		}
+	}
+	return pool.enqueue(dependent)
+}
+
+// ! holdForDependencies keeps an admitted task back until its dependencies have finished,
+// ! reporting whether it did. The pool mutex must be held.
+func (pool *Pool) holdForDependencies(dependent *task) bool {
+	if len(dependent.dependencies) == 0 {
+		return false
+	}
+	pool.held[dependent] = struct{}{}
+	pool.inheritPriority(dependent)
+	pool.spawn(func() { pool.awaitDependencies(dependent) }, nil)
+	return true
+}
+
+// ! inheritPriority raises every dependency of a task that has not started yet to the task's
+// ! priority. The pool mutex must be held.
+func (pool *Pool) inheritPriority(dependent *task) {
+	for _, dependency := range dependent.dependencies {
+		for _, queued := range pool.queue.all() {
+			if queued.handle == dependency && queued.priority < dependent.priority {
+				pool.queue.raise(queued, dependent.priority)
+			}
+		}
+		for heldTask := range pool.held {
+			if heldTask.handle == dependency && heldTask.priority < dependent.priority {
+				heldTask.priority = dependent.priority
+				pool.inheritPriority(heldTask)
+			}
+		}
+		for _, line := range pool.exclusiveLines {
+			for position, waiting := range line.waiting {
+				if waiting.handle == dependency {
+					pool.inheritKeyPriority(line, position+1, dependent.priority)
+				}
+			}
+		}
+	}
+}
+
+// ! inheritKeyPriority raises the first count tasks waiting in a SubmitExclusive key's line,
+// ! and its active task unless that is already running, to priority if that is higher. The
+// ! waiting ones are queued with it once their turn comes. The pool mutex must be held.
+func (pool *Pool) inheritKeyPriority(line *exclusiveLine, count int, priority int) {
+	for _, ahead := range line.waiting[:count] {
+		ahead.priority = max(ahead.priority, priority)
+	}
+	active := line.active
+	if active == nil || active.priority >= priority {
+		return
+	}
+	if _, running := pool.runningSince[active]; running {
+		return
+	}
+	for _, queued := range pool.queue.all() {
+		if queued == active {
+			pool.queue.raise(active, priority)
+			return
+		}
+	}
+	active.priority = priority //! Not queued yet, such as while a Drain holds it back.
+}
+
+// ! awaitDependencies queues a held task once all of its dependencies have finished, waiting
+// ! for queue space like a delayed task does. It gives up once the pool is torn down.
+func (pool *Pool) awaitDependencies(dependent *task) {
+	if !pool.waitForDependencies(dependent) {
+		return
+	}
+	pool.mutex.Lock()
+	if _, stillHeld := pool.held[dependent]; !stillHeld {
+		pool.mutex.Unlock() //! Close got to it first.
+		return
+	}
+	delete(pool.held, dependent)
+	for !pool.closed && pool.queue.len() >= pool.queueSize {
+		pool.spaceAvailable.Wait()
+	}
+	if pool.closed {
+		pool.mutex.Unlock()
+		pool.cancelTask(dependent)
+		return
+	}
+	pool.push(dependent)
+	pool.mutex.Unlock()
+	pool.announceEnqueued(dependent)
+}
+
+// ! waitForDependencies blocks until every dependency of a task has finished, and reports
+// ! false if the pool was torn down first.
+func (pool *Pool) waitForDependencies(dependent *task) bool {
+	for _, dependency := range dependent.dependencies {
+		select {
+		case <-dependency.done:
+		case <-pool.poolContext.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// ! cancelHeld cancels every task still waiting for its dependencies.
+func (pool *Pool) cancelHeld() {
+	pool.mutex.Lock()
+	held := pool.held
+	pool.held = make(map[*task]struct{})
+	pool.mutex.Unlock()
+
+	for heldTask := range held {
+		pool.cancelTask(heldTask)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! runOrder records the order tasks ran in by name.
+type runOrder struct {
+	mutex sync.Mutex
+	ran   []string
+}
+
+func (order *runOrder) record(name string) func() {
+	return func() {
+		order.mutex.Lock()
+		defer order.mutex.Unlock()
+		order.ran = append(order.ran, name)
+	}
+}
+
+func (order *runOrder) task(name string) Task {
+	record := order.record(name)
+	return TaskFunc(func(context.Context) error { record(); return nil })
+}
+
+func (order *runOrder) names() []string {
+	order.mutex.Lock()
+	defer order.mutex.Unlock()
+	return slices.Clone(order.ran)
+}
+
+func TestDependentRunsOnceItsDependenciesFinish(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	var order runOrder
+	release := make(chan struct{})
+	record := order.record("dependency")
+	dependency, _ := pool.SubmitFunc(func() { <-release; record() })
+	dependent, err := pool.SubmitWithDependencies(0, []*TaskHandle{dependency, nil}, order.task("dependent"))
+	if err != nil {
+		t.Fatalf("SubmitWithDependencies: %v", err)
+	}
+	select {
+	case <-dependent.Done():
+		t.Fatalf("the dependent finished before its dependency")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	pool.Wait()
+	if ran := order.names(); !slices.Equal(ran, []string{"dependency", "dependent"}) {
+		t.Fatalf("ran %v", ran)
+	}
+}
+
+func TestQueuedDependencyInheritsPriority(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := blockSource(t, pool)
+	var order runOrder
+	dependency, _ := pool.SubmitWithPriority(0, order.task("dependency"))
+	pool.SubmitWithPriority(5, order.task("unrelated"))
+	pool.SubmitWithDependencies(10, []*TaskHandle{dependency}, order.task("dependent"))
+	close(release)
+	pool.Wait()
+	if ran := order.names(); ran[0] != "dependency" {
+		t.Fatalf("ran %v, want the dependency first", ran)
+	}
+}
+
+func TestKeyLineOfADependencyInheritsPriority(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := blockSource(t, pool)
+	var order runOrder
+	pool.SubmitExclusive("alice", order.record("ahead"))
+	dependency, _ := pool.SubmitExclusive("alice", order.record("dependency"))
+	pool.SubmitWithPriority(5, order.task("unrelated"))
+	pool.SubmitWithDependencies(10, []*TaskHandle{dependency}, order.task("dependent"))
+	close(release)
+	pool.Wait()
+	if ran := order.names(); !slices.Equal(ran[:2], []string{"ahead", "dependency"}) {
+		t.Fatalf("ran %v, want the key's line first", ran)
+	}
+}
+
+func TestSubmitWithDependenciesGoesThroughAdmission(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithAdmissionController(func(info TaskInfo) (bool, string) {
+		return info.Priority < 10, "too urgent"
+	}))
+	_, err := pool.SubmitWithDependencies(10, nil, noopTask)
+	assertRejected(t, err, RejectedByController, ErrAdmissionDenied)
+
+	release := blockSource(t, pool)
+	drained := startDrain(pool, pool.DrainAndReject)
+	_, err = pool.SubmitWithDependencies(0, nil, noopTask)
+	assertRejected(t, err, RejectedDraining, ErrPoolDraining)
+	close(release)
+	<-drained
+}
+
+func TestDependentHeldByDrainStillWaitsForDependencies(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	other := newTestPool(t, WithWorkers(1))
+	dependencyRelease := blockSource(t, other)
+	dependency, _ := other.Submit(noopTask) //! Stuck behind other's blocker.
+	release := blockSource(t, pool)
+	drained := startDrain(pool, pool.Drain)
+	dependent, _ := pool.SubmitWithDependencies(0, []*TaskHandle{dependency}, noopTask)
+	close(release)
+	<-drained
+	select {
+	case <-dependent.Done():
+		t.Fatalf("the dependent ran once the drain completed, before its dependency")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(dependencyRelease)
+	returnsWithin(t, time.Second, "the dependent", func() { <-dependent.Done() })
+}
+
+func TestSynchronousPoolRunsDependentsInline(t *testing.T) {
+	pool := newTestPool(t, WithSynchronous())
+	dependency, _ := pool.Submit(noopTask)
+	dependent, err := pool.SubmitWithDependencies(0, []*TaskHandle{dependency}, noopTask)
+	if err != nil {
+		t.Fatalf("SubmitWithDependencies: %v", err)
+	}
+	select {
+	case <-dependent.Done():
+	default:
+		t.Fatalf("the dependent had not run when SubmitWithDependencies returned")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// ! Drain blocks until every task submitted before the call has finished, as a barrier between
+// ! phases of work, and leaves the pool open. Tasks submitted while it drains are accepted as
+// ! usual, and count against the queue size, but are held back and queued only once the drain
+// ! completes, in the order they arrived, so nothing of the next phase starts before the
+// ! previous one is done. Use DrainAndReject to refuse them instead. Delayed tasks and tasks
+// ! waiting for dependencies count as submitted before, as they do for Wait. Overlapping drains
+// ! release the held tasks together when the last of them completes; Close cancels them.
+func (pool *Pool) Drain() {
+	pool.drain(false)
+}
+
+// ! DrainAndReject is like Drain, but while it drains every submission is refused with a
+// ! *RejectionError wrapping ErrPoolDraining, including submitters already waiting for queue
+// ! space, instead of being held back. Submissions are accepted again once it returns.
+func (pool *Pool) DrainAndReject() {
+	pool.drain(true)
+}
+
+// ! drain implements Drain and DrainAndReject.
+func (pool *Pool) drain(reject bool) {
+	pool.mutex.Lock()
+	pool.drains++
+	if reject {
+		pool.rejectingDrains++
+		pool.spaceAvailable.Broadcast()
+	}
+	pool.mutex.Unlock()
+
+	pool.waitFor(context.Background(), func() bool { return pool.outstanding == len(pool.drainHeld) })
+
+	pool.mutex.Lock()
+	pool.drains--
+	if reject {
+		pool.rejectingDrains--
+	}
+	if pool.drains == 0 {
+		for _, heldTask := range pool.drainHeld {
+			if !pool.holdForDependencies(heldTask) {
+				pool.push(heldTask)
+			}
+		}
+		pool.drainHeld = nil
+	}
+	pool.mutex.Unlock()
+	pool.rethrowPanic()
+}
+
+// ! holdForDrain keeps an admitted task back while a Drain is in progress, reporting whether
+// ! it did. The pool mutex must be held.
+func (pool *Pool) holdForDrain(newTask *task) bool {
+	if pool.drains == 0 {
+		return false
+	}
+	newTask.queueIndex = -1
+	newTask.enqueuedAt = pool.clock.Now()
+	pool.drainHeld = append(pool.drainHeld, newTask)
+	return true
+}
+
+// ! cancelDrainHeld cancels the tasks a Drain was holding back when the pool closes.
+func (pool *Pool) cancelDrainHeld() {
+	pool.mutex.Lock()
+	held := pool.drainHeld
+	pool.drainHeld = nil
+	pool.mutex.Unlock()
+
+	for _, heldTask := range held {
+		pool.cancelTask(heldTask)
+	}
+}
+
+// ! DrainTo hands this pool's backlog over to other, for blue-green swaps or rotating to a
+// ! freshly configured pool without losing queued work. It stops this pool from accepting
+// ! tasks, moves every queued task to other in dispatch order, keeping its handle, priority,
+// ! tags and queue wait, and then closes this pool, waiting for the tasks that were already
+// ! running to finish. Tasks spilled to disk are read back and moved too, leaving the spill
+// ! directory as they go. Delayed tasks that are not due, tasks waiting for dependencies, tasks
+// ! held back by a Drain and SubmitExclusive tasks waiting behind their key's active one stay
+// ! behind and are cancelled as by Close; the exclusive ones because their key may still be
+// ! running here. Each moved task is admitted by other as if submitted to it: other gives it a
+// ! fresh id, which its handle's Id then reports, and applies its own admission controller,
+// ! rate limit, load shedding, drains and key lines, blocking while its queue is full exactly
+// ! like Submit would, or spilling if other spills. A task other refuses is cancelled with an
+// ! error matching both ErrTaskCancelled and the refusal, and DrainTo returns an error wrapping
+// ! the first refusal, such as one matching ErrPoolClosed if other is closed meanwhile. other
+// ! must be a different, concurrently running pool.
+func (pool *Pool) DrainTo(other *Pool) error {
+	pool.mutex.Lock()
+	pool.closed = true
+	moved := pool.queue.removeAll()
+	for pool.diskSpill.len() > 0 {
+		unspilled := pool.diskSpill.read()
+		pool.forgetSpilled(unspilled)
+		unspilled.spillPath = ""
+		moved = append(moved, unspilled)
+	}
+	for _, movedTask := range moved {
+		pool.statuses.forget(movedTask.id)
+		delete(pool.progressTasks, movedTask.id)
+	}
+	pool.outstanding -= len(moved)
+	pool.updateOverload()
+	pool.taskAvailable.Broadcast()
+	pool.spaceAvailable.Broadcast()
+	pool.broadcastStateChange()
+	pool.mutex.Unlock()
+
+	var refused int
+	var firstRefusal error
+	for _, movedTask := range moved {
+		err := other.adoptWaiting(movedTask)
+		if err == nil {
+			continue
+		}
+		refused++
+		if firstRefusal == nil {
+			firstRefusal = err
+		}
+		pool.mutex.Lock()
+		pool.outstanding++
+		pool.mutex.Unlock()
+		pool.cancelTaskWith(movedTask, fmt.Errorf("%w: %w", ErrTaskCancelled, err))
+	}
+	pool.Close()
+	if refused > 0 {
+		return fmt.Errorf("%d of %d tasks could not be handed over and were cancelled: %w", refused, len(moved), firstRefusal)
+	}
+	return nil
+}
+
+// ! adoptWaiting admits a task handed over by another pool as Submit would, keeping its handle.
+// ! The task leaves the other pool's key line, if it has one, to join this pool's.
+func (pool *Pool) adoptWaiting(adopted *task) error {
+	adopted.exclusive = nil
+	adopted.handle.exclusive = nil
+	adopted.noWait = false //! Waits for room like Submit, even if it came from TrySubmit.
+	_, err := pool.enqueue(adopted)
+	return err
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ! drainsInProgress returns how many Drain calls are under way.
+func drainsInProgress(pool *Pool) int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.drains
+}
+
+// ! startDrain runs drain on its own goroutine once blocker is running and returns a channel
+// ! closed when it returns.
+func startDrain(pool *Pool, drain func()) <-chan struct{} {
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		drain()
+	}()
+	for drainsInProgress(pool) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return drained
+}
+
+func TestDrainHoldsNewSubmissionsUntilDone(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var firstPhaseDone atomic.Bool
+	pool.SubmitFunc(func() { close(started); <-release; firstPhaseDone.Store(true) })
+	<-started
+	drained := startDrain(pool, pool.Drain)
+
+	var ranEarly atomic.Bool
+	secondPhase, err := pool.SubmitFunc(func() { ranEarly.Store(!firstPhaseDone.Load()) })
+	if err != nil {
+		t.Fatalf("Submit during Drain: %v", err)
+	}
+	select {
+	case <-drained:
+		t.Fatal("Drain returned while a task submitted before it was running")
+	case <-secondPhase.Done():
+		t.Fatal("a task submitted during Drain ran before the drain completed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-drained
+	<-secondPhase.Done()
+	if ranEarly.Load() {
+		t.Fatal("the second phase started before the first finished")
+	}
+}
+
+func TestDrainAndRejectRefusesUntilDone(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	drained := startDrain(pool, pool.DrainAndReject)
+
+	_, err := pool.SubmitFunc(func() {})
+	assertRejected(t, err, RejectedDraining, ErrPoolDraining)
+	close(release)
+	<-drained
+	if _, err := pool.SubmitFunc(func() {}); err != nil {
+		t.Fatalf("Submit after DrainAndReject: %v", err)
+	}
+}
+
+func TestCloseCancelsTasksHeldByDrain(t *testing.T) {
+	pool, err := New(WithWorkers(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	drained := startDrain(pool, pool.Drain)
+	held, _ := pool.SubmitFunc(func() {})
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		pool.Close()
+	}()
+	<-held.Done()
+	close(release)
+	<-closed
+	<-drained
+	if !held.Cancelled() {
+		t.Fatal("Close ran the task Drain was holding back")
+	}
+}
+
+// ! isClosed reports whether the pool has stopped accepting tasks.
+func (pool *Pool) isClosed() bool {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.closed
+}
+
+// ! blockSource occupies the single worker of pool until the returned release is closed.
+func blockSource(t *testing.T, pool *Pool) chan struct{} {
+	t.Helper()
+	started, release := make(chan struct{}), make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	return release
+}
+
+func TestDrainToGivesMovedTasksFreshIds(t *testing.T) {
+	source := newTestPool(t, WithWorkers(1))
+	target := newTestPool(t, WithWorkers(1))
+	release := blockSource(t, source)
+	var moved []*TaskHandle
+	for range 3 {
+		handle, _ := source.SubmitFunc(func() {})
+		moved = append(moved, handle)
+	}
+	ids := make(map[int]bool)
+	for range 3 {
+		own, _ := target.SubmitFunc(func() {})
+		ids[own.Id()] = true
+	}
+	target.Wait()
+
+	drained := make(chan error, 1)
+	go func() { drained <- source.DrainTo(target) }()
+	for _, handle := range moved {
+		returnsWithin(t, time.Second, "a moved task", func() { <-handle.Done() })
+		if ids[handle.Id()] {
+			t.Fatalf("a moved task has id %d, which the target already gave out", handle.Id())
+		}
+		ids[handle.Id()] = true
+		if status := target.Status(handle.Id()); status != TaskCompleted {
+			t.Fatalf("target.Status(%d) = %v, want completed", handle.Id(), status)
+		}
+	}
+	close(release)
+	if err := <-drained; err != nil {
+		t.Fatalf("DrainTo: %v", err)
+	}
+}
+
+func TestDrainToAppliesTheTargetsAdmission(t *testing.T) {
+	source := newTestPool(t, WithWorkers(1))
+	target := newTestPool(t, WithWorkers(1), WithAdmissionController(func(info TaskInfo) (bool, string) {
+		return info.Tags["tier"] != "free", "free tier paused"
+	}))
+	release := blockSource(t, source)
+	paid, _ := source.SubmitTagged(map[string]string{"tier": "paid"}, noopTask)
+	free, _ := source.SubmitTagged(map[string]string{"tier": "free"}, noopTask)
+
+	drained := make(chan error, 1)
+	go func() { drained <- source.DrainTo(target) }()
+	returnsWithin(t, time.Second, "the refused task", func() { <-free.Done() })
+	if err := free.Err(); !errors.Is(err, ErrTaskCancelled) || !errors.Is(err, ErrAdmissionDenied) {
+		t.Fatalf("the refused task reported %v, want ErrTaskCancelled and ErrAdmissionDenied", err)
+	}
+	close(release)
+	if err := <-drained; !errors.Is(err, ErrAdmissionDenied) {
+		t.Fatalf("DrainTo: got %v, want an error wrapping ErrAdmissionDenied", err)
+	}
+	<-paid.Done()
+	if paid.Err() != nil {
+		t.Fatalf("the admitted task reported %v", paid.Err())
+	}
+}
+
+func TestDrainToCancelsExclusiveTasksWaitingBehindARunningOne(t *testing.T) {
+	source := newTestPool(t, WithWorkers(1))
+	target := newTestPool(t, WithWorkers(1))
+	started, release := make(chan struct{}), make(chan struct{})
+	source.SubmitExclusive("alice", func() { close(started); <-release })
+	<-started
+	waiting, _ := source.SubmitExclusive("alice", func() {})
+
+	drained := make(chan error, 1)
+	go func() { drained <- source.DrainTo(target) }()
+	for !source.isClosed() {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if err := <-drained; err != nil {
+		t.Fatalf("DrainTo: %v", err)
+	}
+	if !errors.Is(waiting.Err(), ErrTaskCancelled) {
+		t.Fatalf("the waiting exclusive task reported %v, want ErrTaskCancelled", waiting.Err())
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"container/heap"
+	"time"
+)
+
+// ! SubmitWithDeadline is like Submit for SLA-bound work that must finish by an absolute time.
+// ! The deadline is seen by the Scheduler in the task's Info, which NewEDFScheduler orders by,
+// ! and with WithDropMissedDeadlines a task still waiting when it passes is dropped instead of
+// ! run. It does not limit how long the task may run once started; see SubmitWithTimeouts for that.
+func (pool *Pool) SubmitWithDeadline(deadline time.Time, work Task) (*TaskHandle, error) {
+	return pool.enqueue(&task{work: work, run: runTask(work), deadline: deadline})
+}
+
+// ! WithDropMissedDeadlines drops a task submitted with SubmitWithDeadline when a worker picks
+// ! it up after its deadline, since its result would come too late to matter. It is reported
+// ! through its handle as Expired with ErrDeadlineExceeded, and counted in Stats.Expired.
+func WithDropMissedDeadlines() Option {
+	return func(pool *Pool) {
+		pool.dropMissedDeadlines = true
+	}
+}
+
+// ! missedDeadline reports whether a task is to be dropped under WithDropMissedDeadlines.
+func (pool *Pool) missedDeadline(queuedTask *task) bool {
+	return pool.dropMissedDeadlines && !queuedTask.deadline.IsZero() && pool.clock.Now().After(queuedTask.deadline)
+}
+
+// ! NewEDFScheduler returns an earliest-deadline-first Scheduler for WithScheduler: the task
+// ! with the nearest deadline, see SubmitWithDeadline, is always dispatched next, whatever its
+// ! priority. Tasks without a deadline come after all those with one, in submission order, as
+// ! do ties.
+func NewEDFScheduler() Scheduler {
+	return &edfScheduler{}
+}
+
+type edfScheduler []*QueuedTask
+
+func (scheduler *edfScheduler) Enqueue(queued *QueuedTask) { heap.Push(scheduler, queued) }
+
+func (scheduler *edfScheduler) Dequeue() (*QueuedTask, bool) {
+	if len(*scheduler) == 0 {
+		return nil, false
+	}
+	return heap.Pop(scheduler).(*QueuedTask), true
+}
+
+func (scheduler edfScheduler) Len() int { return len(scheduler) }
+
+func (scheduler edfScheduler) Less(i, j int) bool {
+	a, b := scheduler[i].info, scheduler[j].info
+	if !a.Deadline.Equal(b.Deadline) {
+		switch {
+		case a.Deadline.IsZero():
+			return false
+		case b.Deadline.IsZero():
+			return true
+		default:
+			return a.Deadline.Before(b.Deadline)
+		}
+	}
+	return a.Id < b.Id
+}
+
+func (scheduler edfScheduler) Swap(i, j int) { scheduler[i], scheduler[j] = scheduler[j], scheduler[i] }
+
+func (scheduler *edfScheduler) Push(value any) { *scheduler = append(*scheduler, value.(*QueuedTask)) }
+
+func (scheduler *edfScheduler) Pop() any {
+	old := *scheduler
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*scheduler = old[:len(old)-1]
+	return last
+}
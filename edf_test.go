@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEDFSchedulerDispatchesNearestDeadlineFirst(t *testing.T) {
+	now := time.Now()
+	ran := runInSchedulerOrder(t, NewEDFScheduler(), func(pool *Pool, record func(int) Task) {
+		pool.Submit(record(1)) //! No deadline, so last.
+		pool.SubmitWithDeadline(now.Add(3*time.Hour), record(2))
+		pool.SubmitWithDeadline(now.Add(time.Hour), record(3))
+		pool.SubmitWithPriority(9, record(4)) //! Priority does not count.
+		pool.SubmitWithDeadline(now.Add(2*time.Hour), record(5))
+	})
+	if want := []int{3, 5, 2, 1, 4}; !slices.Equal(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestDropMissedDeadlines(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithDropMissedDeadlines())
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	late, _ := pool.SubmitWithDeadline(clock.Now().Add(time.Minute), noopTask)
+	onTime, _ := pool.SubmitWithDeadline(clock.Now().Add(time.Hour), noopTask)
+	clock.stepWall(2 * time.Minute)
+	close(release)
+	pool.Wait()
+
+	if !late.Expired() || !errors.Is(late.Err(), ErrDeadlineExceeded) {
+		t.Fatalf("late task: expired %v, err %v", late.Expired(), late.Err())
+	}
+	if onTime.Expired() || onTime.Err() != nil {
+		t.Fatalf("task within its deadline: expired %v, err %v", onTime.Expired(), onTime.Err())
+	}
+	if got := pool.Stats().Expired; got != 1 {
+		t.Fatalf("Stats.Expired = %d, want 1", got)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+)
+
+var (
+	//! ErrPoolClosed is returned when a task is submitted after Close has been called.
+	ErrPoolClosed = errors.New("worker pool: pool is closed")
+	//! ErrTaskLeaked is reported for a task that ignored the cancellation of its timed-out context and was abandoned.
+	ErrTaskLeaked = errors.New("worker pool: task ignored its timeout and was abandoned")
+	//! ErrTaskCancelled is reported for a task dropped before it could run to the end, see CancelQueued and Shutdown.
+	ErrTaskCancelled = errors.New("worker pool: task cancelled before it ran")
+	//! ErrCPUBudgetExceeded is returned for submissions refused while WithCPUTimeBudget's budget is used up.
+	ErrCPUBudgetExceeded = errors.New("worker pool: cpu time budget exceeded")
+	//! ErrNotReplayable is returned by Replay for failed results that carry no task to submit again, and under AtMostOnce.
+	ErrNotReplayable = errors.New("worker pool: result carries no task to replay")
+	//! ErrAdmissionDenied is wrapped, with the reason given, when WithAdmissionController refuses a task.
+	ErrAdmissionDenied = errors.New("worker pool: admission denied")
+	//! ErrPoolDraining is returned for submissions refused while DrainAndReject is in progress.
+	ErrPoolDraining = errors.New("worker pool: pool is draining")
+	//! ErrTaskReplaced is reported for a queued task that a later SubmitReplace for its slot replaced.
+	ErrTaskReplaced = errors.New("worker pool: task replaced by a newer one for its slot")
+	//! ErrTaskSaved is reported for a queued task that SaveQueue wrote to disk instead of running.
+	ErrTaskSaved = errors.New("worker pool: task saved to disk for a later process")
+	//! ErrTaskShed is returned for a low-priority task dropped because the pool is overloaded, see WithLoadShedding.
+	ErrTaskShed = errors.New("worker pool: task shed under overload")
+	//! ErrAttemptsExhausted is reported for a resubmitted task that already used up WithMaxLifetimeAttempts's cap.
+	ErrAttemptsExhausted = errors.New("worker pool: task used up its lifetime attempts")
+	//! ErrTaskExpired is reported for a task that waited in the queue longer than the queue TTL, see WithQueueTTL.
+	ErrTaskExpired = errors.New("worker pool: task expired in the queue")
+	//! ErrInvalidReplay is returned by New when the recording given to WithReplay cannot be parsed.
+	ErrInvalidReplay = errors.New("worker pool: invalid dispatch recording")
+	//! ErrDeadlineExceeded is reported for a task dropped for being picked up after its deadline, see WithDropMissedDeadlines.
+	ErrDeadlineExceeded = errors.New("worker pool: task missed its deadline before it ran")
+	//! ErrPoolNameTaken is returned by New when WithName names a pool that is already registered.
+	ErrPoolNameTaken = errors.New("worker pool: pool name already registered")
+	//! ErrNoWorkers is returned by New when fewer than one worker is configured for a pool that is not synchronous.
+	ErrNoWorkers = errors.New("worker pool: no workers configured")
+	//! ErrTooManyWorkers is returned by New when the number of workers exceeds the sanity limit, see WithMaxWorkerSanityLimit.
+	ErrTooManyWorkers = errors.New("worker pool: too many workers")
+	//! ErrResultTimeout is returned by Future.GetTimeout when the task has not finished in time; the task itself keeps running.
+	ErrResultTimeout = errors.New("worker pool: timed out waiting for the result")
+	//! ErrWorkerInit is returned by New under WithFailFastInit when a worker's init failed.
+	ErrWorkerInit = errors.New("worker pool: worker init failed")
+	//! ErrNotEnoughWorkers is returned by WaitReady when too few workers are left to ever reach the requested number.
+	ErrNotEnoughWorkers = errors.New("worker pool: not enough workers can become ready")
+	//! ErrBandsExceedWorkers is returned by New when WithPriorityBands reserves more workers than the pool has.
+	ErrBandsExceedWorkers = errors.New("worker pool: priority bands reserve more workers than the pool has")
+	//! ErrNoShards is returned by NewShardedPool when fewer than one shard is requested.
+	ErrNoShards = errors.New("worker pool: sharded pool needs at least one shard")
+	//! ErrStandbyDiskSpill is returned by NewStandbyPool when its options include WithDiskSpill.
+	ErrStandbyDiskSpill = errors.New("worker pool: a standby pool cannot spill to disk")
+	//! ErrNoStandby is returned by StandbyPool.Promote while no warm standby is available.
+	ErrNoStandby = errors.New("worker pool: no standby pool is ready")
+	//! ErrNoCapableWorker is returned by SubmitRequiring when no worker advertises the required capability.
+	ErrNoCapableWorker = errors.New("worker pool: no worker has the required capability")
+	//! ErrCostExceedsBudget is returned by SubmitWeighted for a task costing more than the whole concurrency budget.
+	ErrCostExceedsBudget = errors.New("worker pool: task cost exceeds the concurrency budget")
+	//! ErrInvalidConfig is wrapped by every error NewFromConfig reports about the configuration itself.
+	ErrInvalidConfig = errors.New("worker pool: invalid configuration")
+	//! ErrBucketOverflow is returned by Submit when the leaky bucket is full, see WithLeakyBucket.
+	ErrBucketOverflow = errors.New("worker pool: leaky bucket is full")
+	//! ErrQueueFull is returned by TrySubmit when the queue has no space.
+	ErrQueueFull = errors.New("worker pool: queue is full")
+	//! ErrRateLimited is returned by TrySubmit when no rate-limit token is available, see WithRateLimit.
+	ErrRateLimited = errors.New("worker pool: rate limited")
+	//! ErrQuorumNotReached is returned by FirstN when too many tasks failed for the required number to succeed.
+	ErrQuorumNotReached = errors.New("worker pool: not enough tasks succeeded")
+	//! ErrKeyQuarantined is returned for tasks of a key WithPerKeyCircuitBreaker has quarantined after repeated failures.
+	ErrKeyQuarantined = errors.New("worker pool: key quarantined after repeated failures")
+	//! ErrCircuitOpen is returned for submissions refused while the pool's circuit breaker is open, see WithCircuitBreaker.
+	ErrCircuitOpen = errors.New("worker pool: circuit breaker is open")
+	//! ErrMemoryLimit is returned for submissions refused while the heap is over the limit, see WithMemoryLimit.
+	ErrMemoryLimit = errors.New("worker pool: memory limit reached")
+)
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// ! drainRateInterval is how often the completion rate behind EstimatedDrainTime is sampled.
+const drainRateInterval = time.Second
+
+// ! drainRateSmoothing is the weight of the latest interval in the moving average, so the
+// ! estimate follows a change in throughput within about five seconds without jumping around
+// ! on every burst.
+const drainRateSmoothing = 0.2
+
+// ! EstimatedDrainTime estimates how long the current backlog will take to clear, for a
+// ! "your jobs will be done in about N minutes" display: the queued tasks, spilled ones
+// ! included, divided by an exponentially weighted moving average of how many tasks finished
+// ! per second recently. It returns zero when there is no backlog, and also during the pool's
+// ! first second of work, since there is no throughput to extrapolate from yet; while nothing
+// ! finishes, the average decays and the estimate keeps growing. Running tasks, scheduled ones and tasks held back by an
+// ! admission policy are not counted, and the estimate assumes queued tasks take about as long
+// ! as the recent ones did.
+func (pool *Pool) EstimatedDrainTime() time.Duration {
+	pool.mutex.Lock()
+	depth := pool.queue.len() + pool.diskSpill.len()
+	pool.mutex.Unlock()
+	rate := pool.drainRate.perSecond(pool.clock.Now())
+	if depth == 0 || rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(depth) / rate * float64(time.Second))
+}
+
+// ! drainRate is a moving average of the pool's completion rate, sampled per interval and
+// ! brought up to date lazily, on each completion and query, so it needs no goroutine.
+type drainRate struct {
+	mutex       sync.Mutex
+	started     bool
+	bucketStart time.Time //! Start of the interval being counted.
+	bucketCount int       //! Tasks finished in it so far.
+	rate        float64   //! Tasks per second, as of bucketStart.
+}
+
+// ! record counts a task that finished at now.
+func (drain *drainRate) record(now time.Time) {
+	drain.mutex.Lock()
+	defer drain.mutex.Unlock()
+	drain.advance(now)
+	drain.bucketCount++
+}
+
+// ! perSecond returns the smoothed completion rate as of now.
+func (drain *drainRate) perSecond(now time.Time) float64 {
+	drain.mutex.Lock()
+	defer drain.mutex.Unlock()
+	drain.advance(now)
+	return drain.rate
+}
+
+// ! advance folds the intervals that ended by now into the average, the empty ones as zero.
+// ! The drainRate mutex must be held.
+func (drain *drainRate) advance(now time.Time) {
+	if !drain.started {
+		drain.started, drain.bucketStart = true, now
+		return
+	}
+	intervals := int(now.Sub(drain.bucketStart) / drainRateInterval)
+	if intervals <= 0 {
+		return
+	}
+	sample := float64(drain.bucketCount) / drainRateInterval.Seconds()
+	drain.rate = drainRateSmoothing*sample + (1-drainRateSmoothing)*drain.rate
+	drain.rate *= math.Pow(1-drainRateSmoothing, float64(intervals-1))
+	drain.bucketStart = drain.bucketStart.Add(time.Duration(intervals) * drainRateInterval)
+	drain.bucketCount = 0
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEstimatedDrainTime(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock))
+	if eta := pool.EstimatedDrainTime(); eta != 0 {
+		t.Fatalf("a fresh pool estimated %v", eta)
+	}
+	for range 10 {
+		pool.Submit(noopTask)
+	}
+	pool.Wait()
+	clock.advance(drainRateInterval) //! 10 tasks in the first second average to 2 per second.
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pool.Submit(TaskFunc(func(context.Context) error { close(started); <-release; return nil }))
+	<-started
+	for range 4 {
+		pool.Submit(noopTask)
+	}
+	if eta := pool.EstimatedDrainTime(); eta != 2*time.Second {
+		t.Errorf("4 queued tasks at 2 per second estimated %v, want 2s", eta)
+	}
+	clock.advance(time.Minute) //! Nothing finished for a minute.
+	if eta := pool.EstimatedDrainTime(); eta < time.Hour {
+		t.Errorf("a stalled pool still estimated %v", eta)
+	}
+	close(release)
+}
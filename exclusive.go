@@ -0,0 +1,130 @@
+package main
+
+// ! SubmitExclusive is like SubmitFunc, but at most one task per key runs at any time across
+// ! the whole pool: while a task for the key is queued or running, later ones for the same key
+// ! wait behind it, in submission order, and each is queued only once its predecessor has
+// ! finished. Tasks for different keys interleave freely on any worker, so per-account
+// ! operations serialize without a global lock or pinning accounts to workers. Waiting tasks
+// ! count as outstanding but not against the queue size, like tasks held for dependencies, and
+// ! Close cancels them. Synchronous pools run every task inline without this guarantee.
+func (pool *Pool) SubmitExclusive(key string, run func()) (*TaskHandle, error) {
+	return pool.enqueue(&task{run: runFunc(run), exclusiveKey: key})
+}
+
+// ! ExclusiveDepth returns how many tasks submitted with SubmitExclusive for key have not
+// ! finished: the one queued or running, plus those waiting behind it.
+func (pool *Pool) ExclusiveDepth(key string) int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	line := pool.exclusiveLines[key]
+	if line == nil {
+		return 0
+	}
+	return 1 + len(line.waiting)
+}
+
+// ! KeyQueueDepths returns, for every SubmitExclusive key with tasks waiting behind its active
+// ! one, how many wait, to spot a hot key whose slow task backs up the rest. Keys with nothing
+// ! waiting are left out.
+func (pool *Pool) KeyQueueDepths() map[string]int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	depths := make(map[string]int)
+	for key, line := range pool.exclusiveLines {
+		if len(line.waiting) > 0 {
+			depths[key] = len(line.waiting)
+		}
+	}
+	return depths
+}
+
+// ! CancelKeyQueue cancels every task waiting behind the active task of a SubmitExclusive key,
+// ! as CancelQueued would, and returns how many it cancelled. The active task is left alone,
+// ! whether it is already running or still queued, so the line keeps its order for later
+// ! submissions.
+func (pool *Pool) CancelKeyQueue(key string) int {
+	pool.mutex.Lock()
+	var waiting []*task
+	if line := pool.exclusiveLines[key]; line != nil {
+		waiting = line.waiting
+		line.waiting = nil
+	}
+	pool.mutex.Unlock()
+
+	for _, waitingTask := range waiting {
+		pool.cancelTask(waitingTask)
+	}
+	return len(waiting)
+}
+
+// ! exclusiveLine serializes the tasks of one SubmitExclusive key. The pool mutex guards it.
+type exclusiveLine struct {
+	pool    *Pool
+	key     string
+	active  *task   //! The task queued or running for the key; only it advances the line.
+	waiting []*task //! Tasks behind the active one, oldest first.
+}
+
+// ! holdExclusive attaches a task to its key's line and, if another task for the key is
+// ! active, keeps it waiting there, reporting whether it did. The pool mutex must be held.
+func (pool *Pool) holdExclusive(newTask *task) bool {
+	if newTask.exclusiveKey == "" {
+		return false
+	}
+	line := pool.exclusiveLines[newTask.exclusiveKey]
+	if line == nil {
+		if pool.exclusiveLines == nil {
+			pool.exclusiveLines = make(map[string]*exclusiveLine)
+		}
+		newTask.exclusive = &exclusiveLine{pool: pool, key: newTask.exclusiveKey, active: newTask}
+		pool.exclusiveLines[newTask.exclusiveKey] = newTask.exclusive
+		return false
+	}
+	newTask.exclusive = line
+	newTask.queueIndex = -1
+	line.waiting = append(line.waiting, newTask)
+	return true
+}
+
+// ! release queues the next waiting task of the line once the active one has finished, or
+// ! retires the line when nothing waits. It is a no-op for tasks without a key, for waiting
+// ! tasks that were cancelled, which never held the key, and once the pool is closed, since
+// ! Close cancels whatever still waits.
+func (line *exclusiveLine) release(finished *TaskHandle) {
+	if line == nil {
+		return
+	}
+	pool := line.pool
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if pool.closed || line.active == nil || line.active.handle != finished {
+		return
+	}
+	if len(line.waiting) == 0 {
+		line.active = nil
+		if pool.exclusiveLines[line.key] == line {
+			delete(pool.exclusiveLines, line.key)
+		}
+		return
+	}
+	next := line.waiting[0]
+	line.waiting[0] = nil
+	line.waiting = line.waiting[1:]
+	line.active = next
+	pool.push(next)
+}
+
+// ! cancelExclusiveWaiting cancels every task still waiting behind another for its key.
+func (pool *Pool) cancelExclusiveWaiting() {
+	pool.mutex.Lock()
+	var waiting []*task
+	for _, line := range pool.exclusiveLines {
+		waiting = append(waiting, line.waiting...)
+		line.waiting = nil
+	}
+	pool.mutex.Unlock()
+
+	for _, waitingTask := range waiting {
+		pool.cancelTask(waitingTask)
+	}
+}
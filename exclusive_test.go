@@ -0,0 +1,156 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitExclusiveSerializesPerKey(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(4), WithQueueSize(50))
+	var mutex sync.Mutex
+	order := map[string][]int{}
+	running := map[string]*atomic.Int32{"alice": {}, "bob": {}}
+	var overlapped atomic.Bool
+	for index := range 10 {
+		for _, account := range []string{"alice", "bob"} {
+			pool.SubmitExclusive(account, func() {
+				if running[account].Add(1) > 1 {
+					overlapped.Store(true)
+				}
+				time.Sleep(time.Millisecond)
+				mutex.Lock()
+				order[account] = append(order[account], index)
+				mutex.Unlock()
+				running[account].Add(-1)
+			})
+		}
+	}
+	pool.Wait()
+
+	if overlapped.Load() {
+		t.Fatal("two tasks for the same key ran at once")
+	}
+	want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	for account, got := range order {
+		if !slices.Equal(got, want) {
+			t.Fatalf("%s's tasks ran in order %v", account, got)
+		}
+	}
+	if depth := pool.ExclusiveDepth("alice"); depth != 0 {
+		t.Fatalf("ExclusiveDepth after Wait = %d", depth)
+	}
+}
+
+func TestExclusiveDepthAndClose(t *testing.T) {
+	pool, err := New(WithWorkers(2))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitExclusive("alice", func() { close(started); <-release })
+	<-started
+	var waiting []*TaskHandle
+	for range 3 {
+		handle, _ := pool.SubmitExclusive("alice", func() {})
+		waiting = append(waiting, handle)
+	}
+	if depth := pool.ExclusiveDepth("alice"); depth != 4 {
+		t.Fatalf("ExclusiveDepth = %d, want the running task and 3 waiting", depth)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		pool.Close()
+	}()
+	for _, handle := range waiting {
+		<-handle.Done()
+		if !handle.Cancelled() {
+			t.Fatalf("waiting task %d ran after Close", handle.Id())
+		}
+	}
+	close(release)
+	<-closed
+}
+
+func TestCancelKeyQueueKeepsActiveTask(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	active, _ := pool.SubmitExclusive("alice", func() { close(started); <-release })
+	<-started
+	var waiting []*TaskHandle
+	for range 3 {
+		handle, _ := pool.SubmitExclusive("alice", func() {})
+		waiting = append(waiting, handle)
+	}
+	pool.SubmitExclusive("bob", func() {})
+	pool.SubmitExclusive("bob", func() {}) //! May still wait behind the first, depending on timing.
+
+	if depth := pool.KeyQueueDepths()["alice"]; depth != 3 {
+		t.Fatalf("KeyQueueDepths()[alice] = %d, want 3", depth)
+	}
+	if dropped := pool.CancelKeyQueue("alice"); dropped != 3 {
+		t.Fatalf("CancelKeyQueue dropped %d tasks, want 3", dropped)
+	}
+	for _, handle := range waiting {
+		<-handle.Done()
+		if !handle.Cancelled() {
+			t.Fatalf("waiting task %d was not cancelled", handle.Id())
+		}
+	}
+	if _, ok := pool.KeyQueueDepths()["alice"]; ok {
+		t.Fatalf("alice still listed after CancelKeyQueue: %v", pool.KeyQueueDepths())
+	}
+	close(release)
+	<-active.Done()
+	if err := active.Err(); err != nil {
+		t.Fatalf("active task: %v", err)
+	}
+	pool.Wait()
+	if depth := pool.ExclusiveDepth("alice"); depth != 0 {
+		t.Fatalf("ExclusiveDepth after Wait = %d", depth)
+	}
+}
+
+func TestCancelKeyQueueKeepsKeyHeldByRunningTask(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var running atomic.Int32
+	first, _ := pool.SubmitExclusive("alice", func() {
+		running.Add(1)
+		close(started)
+		<-release
+		running.Add(-1)
+	})
+	<-started
+	for range 2 {
+		pool.SubmitExclusive("alice", func() {})
+	}
+	pool.CancelKeyQueue("alice")
+
+	var overlapped atomic.Bool
+	second, _ := pool.SubmitExclusive("alice", func() {
+		if running.Load() != 0 {
+			overlapped.Store(true)
+		}
+	})
+	if depth := pool.ExclusiveDepth("alice"); depth != 2 {
+		t.Fatalf("ExclusiveDepth = %d after resubmitting, want the running task and one waiting", depth)
+	}
+	close(release)
+	<-first.Done()
+	<-second.Done()
+	if overlapped.Load() {
+		t.Fatalf("second alice task ran while the first was still running")
+	}
+	pool.Wait()
+	if depth := pool.ExclusiveDepth("alice"); depth != 0 {
+		t.Fatalf("ExclusiveDepth after Wait = %d", depth)
+	}
+}
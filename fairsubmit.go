@@ -0,0 +1,51 @@
+package main
+
+import "container/list"
+
+// ! WithFairSubmit admits blocked submitters in arrival order. Without it, whichever waiting
+// ! Submit happens to wake first when a slot frees takes it, so under heavy contention some
+// ! producers can be starved indefinitely while others sail through. With it every submission
+// ! takes its place in a line and is only admitted from the head of it; a newcomer finding the
+// ! line non-empty waits behind it even if there is space, and TrySubmit fails with ErrQueueFull
+// ! rather than jump the line. The cost is a list operation per submission and, because the
+// ! head of the line must be the one to wake, a broadcast to every blocked submitter instead of
+// ! a single signal whenever a slot frees, which grows with the number of blocked goroutines.
+func WithFairSubmit() Option {
+	return func(pool *Pool) {
+		pool.submitLine = list.New()
+	}
+}
+
+// ! joinSubmitLine puts a submitter at the end of the line, see WithFairSubmit. It returns nil
+// ! without the option. The pool mutex must be held.
+func (pool *Pool) joinSubmitLine() *list.Element {
+	if pool.submitLine == nil {
+		return nil
+	}
+	return pool.submitLine.PushBack(struct{}{})
+}
+
+// ! atHeadOfLine reports whether the submitter holding place may be admitted. The pool mutex must be held.
+func (pool *Pool) atHeadOfLine(place *list.Element) bool {
+	return place == nil || pool.submitLine.Front() == place
+}
+
+// ! leaveSubmitLine removes a submitter from the line, admitted or not, and wakes the
+// ! others so the new head can check for space. The pool mutex must be held.
+func (pool *Pool) leaveSubmitLine(place *list.Element) {
+	if place == nil {
+		return
+	}
+	pool.submitLine.Remove(place)
+	pool.spaceAvailable.Broadcast()
+}
+
+// ! signalSpace wakes a submitter waiting for queue space, or all of them under WithFairSubmit,
+// ! since only the head of the line may take the space. The pool mutex must be held.
+func (pool *Pool) signalSpace() {
+	if pool.submitLine != nil {
+		pool.spaceAvailable.Broadcast()
+		return
+	}
+	pool.spaceAvailable.Signal()
+}
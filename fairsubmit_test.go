@@ -0,0 +1,47 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! submittersInLine returns the length of the WithFairSubmit line.
+func submittersInLine(pool *Pool) int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.submitLine.Len()
+}
+
+func TestFairSubmitAdmitsInArrivalOrder(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(1), WithFairSubmit())
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	pool.SubmitFunc(func() {}) //! Fills the queue.
+
+	var mutex sync.Mutex
+	var order []int
+	var submitters sync.WaitGroup
+	for index := range 8 {
+		submitters.Go(func() {
+			pool.SubmitFunc(func() {
+				mutex.Lock()
+				order = append(order, index)
+				mutex.Unlock()
+			})
+		})
+		for submittersInLine(pool) != index+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(release)
+	submitters.Wait()
+	pool.Wait()
+
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !slices.Equal(order, want) {
+		t.Fatalf("tasks ran in order %v, want arrival order %v", order, want)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ! Future is the pending output of a function submitted with SubmitFuture.
+type Future[R any] struct {
+	handle *TaskHandle
+	value  R //! Written through deliverOutput before the handle is done, so safe to read afterwards.
+}
+
+// ! SubmitFuture submits fn like Submit and returns a Future for its output. Submission
+// ! errors, such as ErrPoolClosed, are returned straight away.
+func SubmitFuture[R any](pool *Pool, fn func(ctx context.Context) (R, error)) (*Future[R], error) {
+	future := &Future[R]{}
+	handle, err := pool.Submit(TaskFunc(func(ctx context.Context) error {
+		value, err := fn(ctx)
+		deliverOutput(ctx, func() { future.value = value })
+		return err
+	}))
+	if err != nil {
+		return nil, err
+	}
+	future.handle = handle
+	return future, nil
+}
+
+// ! Handle returns the handle of the underlying task.
+func (future *Future[R]) Handle() *TaskHandle {
+	return future.handle
+}
+
+// ! Get blocks until the task has finished and returns its output and error.
+func (future *Future[R]) Get() (R, error) {
+	<-future.handle.done
+	return future.value, future.handle.err
+}
+
+// ! GetTimeout is like Get, but gives up waiting after timeout and returns ErrResultTimeout.
+// ! Giving up only concerns this call: the task is not cancelled, keeps running, and its
+// ! output can still be collected later with Get or another GetTimeout. To stop the task
+// ! itself, bound its execution with WithTaskTimeout or its context instead.
+func (future *Future[R]) GetTimeout(timeout time.Duration) (R, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-future.handle.done:
+		return future.value, future.handle.err
+	case <-timer.C:
+		var zero R
+		return zero, ErrResultTimeout
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFutureIgnoresOutputOfAbandonedAttempt(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithTaskTimeout(10*time.Millisecond, 10*time.Millisecond))
+	//! This attempt ignores its context and only returns long after the worker has moved on.
+	future, err := SubmitFuture(pool, func(context.Context) (int, error) {
+		time.Sleep(60 * time.Millisecond)
+		return 42, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := future.Get(); value != 0 || !errors.Is(err, ErrTaskLeaked) {
+		t.Fatalf("Get returned %d, %v, want 0, ErrTaskLeaked", value, err)
+	}
+	time.Sleep(100 * time.Millisecond) //! The leaked attempt returns meanwhile.
+	if value, _ := future.Get(); value != 0 {
+		t.Fatalf("the abandoned attempt overwrote the output with %d", value)
+	}
+}
+
+func TestFuture(t *testing.T) {
+	pool := newTestPool(t)
+	future, err := SubmitFuture(pool, func(context.Context) (string, error) { return "done", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := future.Get(); value != "done" || err != nil {
+		t.Fatalf("Get returned %q, %v", value, err)
+	}
+}
+
+func TestFanoutReportsAbandonedCopies(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2), WithTaskTimeout(10*time.Millisecond, 10*time.Millisecond))
+	var calls atomic.Int32
+	results := Fanout(pool, 2, func() (int, error) {
+		if calls.Add(1) == 1 {
+			time.Sleep(60 * time.Millisecond)
+		}
+		return 7, nil
+	})
+	leaked := 0
+	for _, result := range results {
+		if errors.Is(result.Err, ErrTaskLeaked) {
+			leaked++
+			if result.Value != 0 {
+				t.Errorf("an abandoned copy reported the value %d", result.Value)
+			}
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if leaked != 1 {
+		t.Fatalf("got results %v, want exactly one abandoned copy", results)
+	}
+}
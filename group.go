@@ -0,0 +1,95 @@
+package main
+
+import "context"
+
+// ! GroupTag is the tag key that puts a task into a named group, as in
+// ! SubmitTagged(map[string]string{GroupTag: "tenant-42"}, task), so that WaitGroup can wait
+// ! for the group and report its first error.
+const GroupTag = "group"
+
+// ! WithGroupFailFast gives the group errgroup semantics within the shared pool: as soon as one
+// ! of its tasks returns an error, the group's tasks still waiting in the queue are cancelled,
+// ! while tasks of other groups, and the group's tasks already running, carry on. Use it once
+// ! per group, for instance to abort only the failing tenant of a multi-tenant batch.
+func WithGroupFailFast(groupId string) Option {
+	return func(pool *Pool) {
+		if pool.failFastGroups == nil {
+			pool.failFastGroups = make(map[string]bool)
+		}
+		pool.failFastGroups[groupId] = true
+	}
+}
+
+// ! taskGroup tracks the tasks of one group. The pool mutex guards it.
+type taskGroup struct {
+	pool        *Pool
+	id          string
+	outstanding int   //! Tasks of the group that have not finished.
+	err         error //! First error a task of the group returned.
+}
+
+// ! WaitGroup blocks until every task of the group submitted so far has finished, and returns
+// ! the first error one of them returned, or nil. Tasks dropped without running, such as those
+// ! a fail-fast group cancelled, do not count as errors. A group that finished is started over,
+// ! with no error, by the next task submitted to it. A group nothing was submitted to returns nil.
+func (pool *Pool) WaitGroup(groupId string) error {
+	var err error
+	pool.waitFor(context.Background(), func() bool {
+		group := pool.groups[groupId]
+		if group == nil {
+			return true
+		}
+		err = group.err
+		return group.outstanding == 0
+	})
+	return err
+}
+
+// ! joinGroup adds a newly registered task to the group its tags name, if any. The pool
+// ! mutex must be held.
+func (pool *Pool) joinGroup(newTask *task) {
+	groupId, ok := newTask.tags[GroupTag]
+	if !ok {
+		return
+	}
+	group := pool.groups[groupId]
+	if group == nil {
+		if pool.groups == nil {
+			pool.groups = make(map[string]*taskGroup)
+		}
+		group = &taskGroup{pool: pool, id: groupId}
+		pool.groups[groupId] = group
+	}
+	if group.outstanding == 0 {
+		group.err = nil
+	}
+	group.outstanding++
+	newTask.handle.group = group
+}
+
+// ! finished accounts for a task of the group having left the pool, and cancels the rest of
+// ! a fail-fast group's queued tasks on its first error. It is a no-op for tasks in no group.
+func (group *taskGroup) finished(outcome taskOutcome, err error) {
+	if group == nil {
+		return
+	}
+	pool := group.pool
+	pool.mutex.Lock()
+	group.outstanding--
+	var dropped []*task
+	if outcome == outcomeRan && err != nil && group.err == nil {
+		group.err = err
+		if pool.failFastGroups[group.id] {
+			dropped = pool.queue.removeMatching(func(queued *task) bool { return queued.handle.group == group })
+			if len(dropped) > 0 {
+				pool.spaceAvailable.Broadcast()
+			}
+		}
+	}
+	pool.broadcastStateChange()
+	pool.mutex.Unlock()
+
+	for _, droppedTask := range dropped {
+		pool.cancelTask(droppedTask)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGroupFailFastCancelsOnlyItsOwnQueuedTasks(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(20), WithGroupFailFast("tenant-a"))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+
+	failure := errors.New("tenant-a broke")
+	tenantA := map[string]string{GroupTag: "tenant-a"}
+	tenantB := map[string]string{GroupTag: "tenant-b"}
+	var ranA, ranB atomic.Int32
+	pool.SubmitTagged(tenantA, TaskFunc(func(context.Context) error { return failure }))
+	var remainingA []*TaskHandle
+	for range 3 {
+		handle, _ := pool.SubmitTagged(tenantA, TaskFunc(func(context.Context) error { ranA.Add(1); return nil }))
+		remainingA = append(remainingA, handle)
+		pool.SubmitTagged(tenantB, TaskFunc(func(context.Context) error { ranB.Add(1); return nil }))
+	}
+	close(release)
+
+	if err := pool.WaitGroup("tenant-a"); !errors.Is(err, failure) {
+		t.Fatalf("WaitGroup(tenant-a) = %v, want its first error", err)
+	}
+	if err := pool.WaitGroup("tenant-b"); err != nil {
+		t.Fatalf("WaitGroup(tenant-b) = %v, want nil", err)
+	}
+	if ranA.Load() != 0 || ranB.Load() != 3 {
+		t.Fatalf("%d tenant-a and %d tenant-b tasks ran, want 0 and 3", ranA.Load(), ranB.Load())
+	}
+	for _, handle := range remainingA {
+		if !handle.Cancelled() {
+			t.Fatalf("tenant-a task %d was not cancelled", handle.Id())
+		}
+	}
+}
+
+func TestGroupStartsOverOnceFinished(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	group := map[string]string{GroupTag: "batch"}
+	pool.SubmitTagged(group, TaskFunc(func(context.Context) error { return errors.New("first batch") }))
+	if err := pool.WaitGroup("batch"); err == nil {
+		t.Fatal("WaitGroup lost the first batch's error")
+	}
+	pool.SubmitTagged(group, noopTask)
+	if err := pool.WaitGroup("batch"); err != nil {
+		t.Fatalf("second batch reports %v, want nil", err)
+	}
+	if err := pool.WaitGroup("never-used"); err != nil {
+		t.Fatalf("unknown group reports %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ! taskOutcome records how a task left the pool.
+type taskOutcome int32
+
+const (
+	outcomePending   taskOutcome = iota //! Still queued or running.
+	outcomeRan                          //! Ran to completion, successfully or not.
+	outcomeCancelled                    //! Dropped from the queue, see CancelQueued.
+	outcomeSkipped                      //! Dropped at dispatch by its guard.
+	outcomeShed                         //! Dropped by load shedding.
+	outcomeExpired                      //! Dropped at dispatch for having queued too long.
+)
+
+// ! TaskHandle identifies a submitted task and lets the submitter wait for it to finish.
+type TaskHandle struct {
+	id       atomic.Int64  //! Reassigned when DrainTo hands the task over to another pool.
+	done     chan struct{} //! Closed once the task has finished running or has been dropped.
+	err      error         //! Written before done is closed, so it is safe to read afterwards.
+	outcome  atomic.Int32
+	progress atomic.Uint64 //! Bits of the float64 percentage last reported, see SubmitWithProgress.
+
+	pool      *Pool           //! The pool that issued the handle, nil for sub-pool handles.
+	identity  *taskIdentity   //! Shared with resubmissions of the task, see taskIdentity.
+	callback  func(err error) //! See SubmitCallback.
+	exclusive *exclusiveLine  //! The SubmitExclusive key's line; nil for other tasks.
+	group     *taskGroup      //! The group the task was submitted to, see GroupTag; nil for none.
+}
+
+func newTaskHandle(id int) *TaskHandle {
+	handle := &TaskHandle{done: make(chan struct{}), identity: newTaskIdentity()}
+	handle.id.Store(int64(id))
+	return handle
+}
+
+// ! Id returns the sequential id the pool assigned to the task, starting at 1. A task handed
+// ! over by DrainTo takes a new id from the pool it moved to.
+func (handle *TaskHandle) Id() int {
+	return int(handle.id.Load())
+}
+
+// ! Done returns a channel that is closed once the task has finished running or has been dropped.
+func (handle *TaskHandle) Done() <-chan struct{} {
+	return handle.done
+}
+
+// ! Err returns the error the task returned, or the error saying why it never ran to the end,
+// ! such as ErrTaskCancelled. It is only meaningful once Done is closed.
+func (handle *TaskHandle) Err() error {
+	select {
+	case <-handle.done:
+		return handle.err
+	default:
+		return nil
+	}
+}
+
+// ! Cancelled reports whether the task was dropped from the queue without running, or given up
+// ! on by Shutdown while waiting to retry. Its Err is then ErrTaskCancelled.
+func (handle *TaskHandle) Cancelled() bool {
+	return taskOutcome(handle.outcome.Load()) == outcomeCancelled
+}
+
+// ! Skipped reports whether the task was dropped at dispatch because its guard returned false.
+func (handle *TaskHandle) Skipped() bool {
+	return taskOutcome(handle.outcome.Load()) == outcomeSkipped
+}
+
+// ! Shed reports whether the task was dropped by load shedding, see WithLoadShedding.
+func (handle *TaskHandle) Shed() bool {
+	return taskOutcome(handle.outcome.Load()) == outcomeShed
+}
+
+// ! Expired reports whether the task was dropped at dispatch for having waited in the queue
+// ! longer than the queue TTL, see WithQueueTTL, when its Err is ErrTaskExpired, or after its
+// ! deadline under WithDropMissedDeadlines, when its Err is ErrDeadlineExceeded.
+func (handle *TaskHandle) Expired() bool {
+	return taskOutcome(handle.outcome.Load()) == outcomeExpired
+}
+
+// ! finish records how the task ended and releases everyone waiting on the handle.
+func (handle *TaskHandle) finish(outcome taskOutcome, err error) {
+	handle.err = err
+	handle.outcome.Store(int32(outcome))
+	if handle.pool != nil {
+		handle.pool.statuses.finish(handle.Id(), statusOf(outcome, err))
+	}
+	close(handle.done)
+	handle.group.finished(outcome, err)
+	handle.exclusive.release(handle)
+	if handle.callback != nil {
+		handle.pool.callbacks.dispatch(func() { handle.callback(err) })
+	}
+	if outcome != outcomeRan && handle.pool != nil {
+		handle.pool.orderedWindow.skip(handle.Id(), handle.pool.deliverResult) //! No Result will follow.
+	}
+}
+
+// ! Flush blocks until exactly the given tasks have completed, regardless of what else
+// ! is queued or running in the pool. Nil handles are ignored.
+func (pool *Pool) Flush(handles ...*TaskHandle) {
+	for _, handle := range handles {
+		if handle == nil {
+			continue
+		}
+		<-handle.done
+	}
+}
+
+// ! waitError is the error a blocking submit form reports for a finished task. A task the pool
+// ! gave up on because it was closing also matches ErrPoolClosed, so callers need not tell a
+// ! Shutdown that cancelled their task apart from one that refused it.
+func (pool *Pool) waitError(handle *TaskHandle) error {
+	if !handle.Cancelled() {
+		return handle.err
+	}
+	pool.mutex.Lock()
+	closed := pool.closed
+	pool.mutex.Unlock()
+	if closed {
+		return fmt.Errorf("%w: %w", ErrPoolClosed, handle.err)
+	}
+	return handle.err
+}
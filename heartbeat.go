@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ! WithHeartbeatTimeout watches tasks submitted with SubmitWithHeartbeat: when one has gone
+// ! timeout without calling its heartbeat, onMissed is called with the task and how long it has
+// ! been silent, once per silence, since the task may be stuck in a loop that ignores its
+// ! context. Unlike a limit on execution time, a legitimately long task that keeps heartbeating,
+// ! say once per chunk, is never flagged. The pool does not stop the task; onMissed may, for
+// ! instance with its handle's Cancel. It runs on a timer goroutine and must not block for long.
+func WithHeartbeatTimeout(timeout time.Duration, onMissed func(info TaskInfo, silentFor time.Duration)) Option {
+	return func(pool *Pool) {
+		pool.heartbeatTimeout = timeout
+		pool.onMissedHeartbeat = onMissed
+	}
+}
+
+// ! SubmitWithHeartbeat is like Submit for long tasks that prove they are alive: run receives a
+// ! heartbeat function to call, from any goroutine, whenever it makes progress, which resets the
+// ! task's watchdog, see WithHeartbeatTimeout. The watchdog starts when the task does, and
+// ! without the option the heartbeat does nothing.
+func (pool *Pool) SubmitWithHeartbeat(run func(ctx context.Context, heartbeat func()) error) (*TaskHandle, error) {
+	watchedTask := &task{}
+	watchedTask.run = func(ctx context.Context, _ *WorkerScratch) error {
+		watch := pool.watchHeartbeat(watchedTask)
+		defer watch.stop()
+		return run(ctx, watch.beat)
+	}
+	return pool.enqueue(watchedTask)
+}
+
+// ! heartbeatWatch is the watchdog of one running task.
+type heartbeatWatch struct {
+	pool     *Pool
+	watched  *task
+	mutex    sync.Mutex
+	lastBeat time.Time
+	reported bool //! onMissed was called for the current silence.
+	stopped  bool
+	timer    Timer
+}
+
+// ! watchHeartbeat starts the watchdog for a task that is starting, or returns nil without
+// ! WithHeartbeatTimeout.
+func (pool *Pool) watchHeartbeat(watched *task) *heartbeatWatch {
+	if pool.heartbeatTimeout <= 0 || pool.onMissedHeartbeat == nil {
+		return nil
+	}
+	watch := &heartbeatWatch{pool: pool, watched: watched, lastBeat: pool.clock.Now()}
+	watch.mutex.Lock()
+	watch.timer = pool.clock.AfterFunc(pool.heartbeatTimeout, watch.check)
+	watch.mutex.Unlock()
+	return watch
+}
+
+// ! beat records a sign of life.
+func (watch *heartbeatWatch) beat() {
+	if watch == nil {
+		return
+	}
+	watch.mutex.Lock()
+	watch.lastBeat = watch.pool.clock.Now()
+	watch.reported = false
+	watch.mutex.Unlock()
+}
+
+// ! check reports a silence that reached the timeout and re-arms the timer.
+func (watch *heartbeatWatch) check() {
+	pool := watch.pool
+	watch.mutex.Lock()
+	if watch.stopped {
+		watch.mutex.Unlock()
+		return
+	}
+	silentFor := pool.clock.Now().Sub(watch.lastBeat)
+	missed := silentFor >= pool.heartbeatTimeout && !watch.reported
+	if missed {
+		watch.reported = true
+	}
+	next := pool.heartbeatTimeout
+	if silentFor < pool.heartbeatTimeout {
+		next -= silentFor
+	}
+	watch.timer = pool.clock.AfterFunc(next, watch.check)
+	watch.mutex.Unlock()
+
+	if missed {
+		pool.mutex.Lock()
+		info := infoOf(watch.watched)
+		pool.mutex.Unlock()
+		pool.onMissedHeartbeat(info, silentFor)
+	}
+}
+
+// ! stop ends the watch once the task has returned.
+func (watch *heartbeatWatch) stop() {
+	if watch == nil {
+		return
+	}
+	watch.mutex.Lock()
+	watch.stopped = true
+	watch.timer.Stop()
+	watch.mutex.Unlock()
+}
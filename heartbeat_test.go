@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatTimeoutReportsOnlySilence(t *testing.T) {
+	clock := newFakeClock()
+	var mutex sync.Mutex
+	var missed []time.Duration
+	var missedId int
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock),
+		WithHeartbeatTimeout(10*time.Second, func(info TaskInfo, silentFor time.Duration) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			missed = append(missed, silentFor)
+			missedId = info.Id
+		}))
+	beats := make(chan func())
+	release := make(chan struct{})
+	handle, err := pool.SubmitWithHeartbeat(func(ctx context.Context, heartbeat func()) error {
+		beats <- heartbeat
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SubmitWithHeartbeat: %v", err)
+	}
+	heartbeat := <-beats
+
+	clock.advance(6 * time.Second)
+	heartbeat()
+	clock.advance(6 * time.Second) //! 12s running, but only 6s silent.
+	mutex.Lock()
+	if len(missed) != 0 {
+		t.Fatalf("a heartbeating task was reported: %v", missed)
+	}
+	mutex.Unlock()
+
+	clock.advance(4 * time.Second)  //! Silent for the full timeout.
+	clock.advance(20 * time.Second) //! Still the same silence.
+	close(release)
+	pool.Wait()
+	clock.advance(time.Minute) //! The watch stopped with the task.
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(missed) != 1 || missed[0] != 10*time.Second || missedId != handle.Id() {
+		t.Fatalf("got reports %v for task %d, want one of 10s for task %d", missed, missedId, handle.Id())
+	}
+}
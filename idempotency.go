@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ! IdempotencyKeyTag is the tag key that gives a task an idempotency key, as in
+// ! SubmitTagged(map[string]string{IdempotencyKeyTag: orderId}, task), see WithIdempotencyStore.
+const IdempotencyKeyTag = "idempotency_key"
+
+// ! IdempotencyStore remembers which idempotency keys have been processed successfully, see
+// ! WithIdempotencyStore. NewMemoryIdempotencyStore is the in-process implementation; one
+// ! backed by Redis or a database lets the guarantee span processes and restarts. Both methods
+// ! are called from worker goroutines, concurrently, with the pool's context.
+type IdempotencyStore interface {
+	//! Processed reports whether a task with key has already succeeded.
+	Processed(ctx context.Context, key string) (bool, error)
+	//! MarkProcessed records that a task with key has succeeded.
+	MarkProcessed(ctx context.Context, key string) error
+}
+
+// ! WithIdempotencyStore makes at-least-once processing safe against duplicate side effects:
+// ! before a worker runs a task tagged with IdempotencyKeyTag it asks store whether the key has
+// ! already been processed, and if so skips the task, whose handle reports Skipped with a nil
+// ! error, the outcome the first run recorded; after a successful run it marks the key. Only
+// ! successes are recorded, so failed tasks can still be retried or replayed. If store fails,
+// ! the task fails with that error rather than risk a duplicate run; failing to mark a key is
+// ! logged, as the task itself succeeded. Duplicates that run at the same moment are not caught.
+func WithIdempotencyStore(store IdempotencyStore) Option {
+	return func(pool *Pool) {
+		pool.idempotencyStore = store
+	}
+}
+
+// ! alreadyProcessed checks the store for a task about to run. It reports whether the task is
+// ! a duplicate, or the store's error.
+func (pool *Pool) alreadyProcessed(candidate *task) (bool, error) {
+	key, ok := candidate.tags[IdempotencyKeyTag]
+	if pool.idempotencyStore == nil || !ok {
+		return false, nil
+	}
+	processed, err := pool.idempotencyStore.Processed(pool.poolContext, key)
+	if err != nil {
+		return false, fmt.Errorf("worker pool: checking idempotency key %q: %w", key, err)
+	}
+	return processed, nil
+}
+
+// ! markProcessed records a task's key in the store after it succeeded.
+func (pool *Pool) markProcessed(succeeded *task) {
+	key, ok := succeeded.tags[IdempotencyKeyTag]
+	if pool.idempotencyStore == nil || !ok {
+		return
+	}
+	if err := pool.idempotencyStore.MarkProcessed(pool.poolContext, key); err != nil {
+		pool.logger.Printf("task %d succeeded but its idempotency key %q could not be recorded: %v", succeeded.id, key, err)
+	}
+}
+
+// ! memoryIdempotencyStore is the IdempotencyStore NewMemoryIdempotencyStore returns.
+type memoryIdempotencyStore struct {
+	processed sync.Map //! Keys that have succeeded.
+}
+
+// ! NewMemoryIdempotencyStore returns an IdempotencyStore that keeps processed keys in memory,
+// ! for the life of the process, without ever forgetting any.
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{}
+}
+
+func (store *memoryIdempotencyStore) Processed(_ context.Context, key string) (bool, error) {
+	_, ok := store.processed.Load(key)
+	return ok, nil
+}
+
+func (store *memoryIdempotencyStore) MarkProcessed(_ context.Context, key string) error {
+	store.processed.Store(key, struct{}{})
+	return nil
+}
+
+// ! finishUnrun finishes a task the idempotency check stopped from running: a duplicate is
+// ! skipped, and a task whose check failed fails with the store's error, with a Result of no
+// ! attempts so that result sinks still hear of the failure.
+func (pool *Pool) finishUnrun(stopped *task, slot *workerSlot, err error) {
+	if err == nil {
+		pool.counters.skipped.Add(1)
+		stopped.handle.finish(outcomeSkipped, nil)
+		return
+	}
+	pool.counters.failed.Add(1)
+	stopped.handle.finish(outcomeRan, err)
+	now := pool.clock.Now()
+	pool.publishResult(Result{TaskId: stopped.id, Err: err, WorkerId: slot.id, EnqueuedAt: stopped.enqueuedAt, StartedAt: now, FinishedAt: now})
+}
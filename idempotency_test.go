@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+)
+
+// ! brokenStore is an IdempotencyStore whose backend is down.
+type brokenStore struct{}
+
+func (brokenStore) Processed(context.Context, string) (bool, error) {
+	return false, errors.New("redis unreachable")
+}
+func (brokenStore) MarkProcessed(context.Context, string) error { return nil }
+
+func TestIdempotencyStoreSkipsProcessedKeys(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithIdempotencyStore(NewMemoryIdempotencyStore()))
+	var charged atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	charge := TaskFunc(func(context.Context) error {
+		if fail.Swap(false) {
+			return errors.New("gateway timeout")
+		}
+		charged.Add(1)
+		return nil
+	})
+	order := map[string]string{IdempotencyKeyTag: "order-7"}
+
+	failed, _ := pool.SubmitTagged(order, charge)
+	retried, _ := pool.SubmitTagged(order, charge)
+	duplicate, _ := pool.SubmitTagged(order, charge)
+	untagged, _ := pool.Submit(charge)
+	pool.Wait()
+
+	if failed.Err() == nil || retried.Err() != nil || retried.Skipped() {
+		t.Fatalf("a failure must not mark the key: failed %v, retried %v", failed.Err(), retried.Err())
+	}
+	if !duplicate.Skipped() || duplicate.Err() != nil {
+		t.Fatalf("the duplicate ran: skipped %v, err %v", duplicate.Skipped(), duplicate.Err())
+	}
+	if untagged.Skipped() || charged.Load() != 2 {
+		t.Fatalf("charged %d times, want the retry and the untagged task", charged.Load())
+	}
+}
+
+func TestIdempotencyStoreErrorFailsTheTask(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithIdempotencyStore(brokenStore{}), WithLogger(log.New(io.Discard, "", 0)))
+	var ran atomic.Bool
+	handle, _ := pool.SubmitTagged(map[string]string{IdempotencyKeyTag: "order-8"},
+		TaskFunc(func(context.Context) error { ran.Store(true); return nil }))
+	pool.Wait()
+	if ran.Load() || handle.Err() == nil {
+		t.Fatalf("with the store down the task ran %v and reported %v", ran.Load(), handle.Err())
+	}
+}
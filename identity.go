@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ! taskIdentity is the part of a task that outlives one instance of it: its cancellation flag
+// ! and how often it has run. Retries run under the same handle, and a resubmission such as
+// ! Replay passes the identity on to the new instance, so a Cancel issued before the task came
+// ! back still stops it when a worker picks it up, the original handle's Cancel reaches it, and
+// ! WithMaxLifetimeAttempts counts every attempt it ever made.
+type taskIdentity struct {
+	requested chan struct{} //! Closed once Cancel or CancelByTag reached the task, see SubmitChild.
+	once      sync.Once
+	attempts  atomic.Int64 //! Attempts made by every instance of the task.
+}
+
+func newTaskIdentity() *taskIdentity {
+	return &taskIdentity{requested: make(chan struct{})}
+}
+
+// ! cancel sets the cancellation flag. It is idempotent.
+func (identity *taskIdentity) cancel() {
+	identity.once.Do(func() { close(identity.requested) })
+}
+
+// ! cancelled reports whether the cancellation flag is set.
+func (identity *taskIdentity) cancelled() bool {
+	select {
+	case <-identity.requested:
+		return true
+	default:
+		return false
+	}
+}
+
+// ! dispatchCancelled finishes a task whose identity was cancelled while it was on its way
+// ! back to a worker, as by a retry or Replay, and reports whether it did.
+func (pool *Pool) dispatchCancelled(currentTask *task) bool {
+	if !currentTask.handle.identity.cancelled() {
+		return false
+	}
+	pool.counters.cancelled.Add(1)
+	pool.recordUnprocessed(currentTask)
+	if currentTask.onCancel != nil {
+		currentTask.onCancel()
+	}
+	currentTask.handle.finish(outcomeCancelled, ErrTaskCancelled)
+	pool.forgetSpilled(currentTask)
+	return true
+}
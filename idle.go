@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ! WaitIdle blocks until the pool has nothing queued and nothing running, then returns nil,
+// ! without closing the pool. It returns ctx.Err() if the context ends first. Being idle is a
+// ! moment in time: a task submitted right after the pool became idle, even before WaitIdle
+// ! returns, does not keep it waiting, so callers that keep submitting concurrently only
+// ! learn that the pool caught up at some point.
+func (pool *Pool) WaitIdle(ctx context.Context) error {
+	return pool.waitFor(ctx, pool.isIdle)
+}
+
+// ! WaitTimeout is Wait with a limit: it blocks until every task submitted so far has
+// ! completed or timeout has passed, and reports whether the pool drained in time. Giving up
+// ! leaves the tasks running and the pool open.
+func (pool *Pool) WaitTimeout(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if pool.waitFor(ctx, pool.isIdle) != nil {
+		return false
+	}
+	pool.rethrowPanic()
+	return true
+}
+
+// ! WaitBelow blocks until fewer than depth tasks are waiting, in the queue or spilled to disk,
+// ! and returns nil, or returns ctx.Err() if the context ends first. Unlike the all-or-nothing
+// ! saturation of a full queue, it lets a producer apply hysteresis, such as pausing a bulk
+// ! load until the backlog is under 100 and resuming only then. Waiters are woken when a worker
+// ! takes a task off the queue, not by polling, and each re-checks its own threshold.
+func (pool *Pool) WaitBelow(ctx context.Context, depth int) error {
+	pool.mutex.Lock()
+	pool.depthWaiters++
+	pool.mutex.Unlock()
+	defer func() {
+		pool.mutex.Lock()
+		pool.depthWaiters--
+		pool.mutex.Unlock()
+	}()
+	return pool.waitFor(ctx, func() bool { return pool.queue.len()+pool.diskSpill.len() < depth })
+}
+
+// ! isIdle reports whether no submitted task is queued or running. The pool mutex must be held.
+func (pool *Pool) isIdle() bool {
+	return pool.outstanding == 0
+}
+
+// ! waitFor blocks until condition holds or ctx ends. The condition is evaluated with the pool
+// ! mutex held, and re-evaluated every time broadcastStateChange is called. Any number of
+// ! goroutines may wait at once: a change closes a channel they all share rather than handing
+// ! out a signal one of them could consume, so Wait, WaitIdle, WaitTimeout and WaitReady
+// ! callers all wake up and each re-checks its own condition.
+func (pool *Pool) waitFor(ctx context.Context, condition func() bool) error {
+	pool.mutex.Lock()
+	for !condition() {
+		if pool.stateChanged == nil {
+			pool.stateChanged = make(chan struct{})
+		}
+		changed := pool.stateChanged
+		pool.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		pool.mutex.Lock()
+	}
+	pool.mutex.Unlock()
+	return nil
+}
+
+// ! broadcastStateChange wakes every goroutine blocked in waitFor. The pool mutex must be held.
+// ! The channel is only allocated while someone is waiting, so this is free otherwise.
+func (pool *Pool) broadcastStateChange() {
+	if pool.stateChanged != nil {
+		close(pool.stateChanged)
+		pool.stateChanged = nil
+	}
+}
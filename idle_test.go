@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentWaitersAllReturn(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2), WithQueueSize(20))
+	release := make(chan struct{})
+	for range 10 {
+		pool.SubmitFunc(func() { <-release })
+	}
+
+	const waitersPerKind = 20
+	var waiters sync.WaitGroup
+	returned := make(chan string, 3*waitersPerKind)
+	for range waitersPerKind {
+		waiters.Go(func() {
+			pool.Wait()
+			returned <- "Wait"
+		})
+		waiters.Go(func() {
+			if err := pool.WaitIdle(context.Background()); err != nil {
+				t.Errorf("WaitIdle: %v", err)
+			}
+			returned <- "WaitIdle"
+		})
+		waiters.Go(func() {
+			if !pool.WaitTimeout(10 * time.Second) {
+				t.Errorf("WaitTimeout gave up before the pool drained")
+			}
+			returned <- "WaitTimeout"
+		})
+	}
+	time.Sleep(20 * time.Millisecond)
+	if len(returned) != 0 {
+		t.Fatalf("a waiter returned while tasks were still blocked")
+	}
+	close(release)
+	returnsWithin(t, time.Second, "the waiters", waiters.Wait)
+	if len(returned) != 3*waitersPerKind {
+		t.Fatalf("%d of %d waiters returned", len(returned), 3*waitersPerKind)
+	}
+}
+
+func TestWaitTimeoutGivesUp(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := make(chan struct{})
+	defer close(release)
+	pool.SubmitFunc(func() { <-release })
+	if pool.WaitTimeout(10 * time.Millisecond) {
+		t.Fatal("WaitTimeout reported a drained pool while a task was blocked")
+	}
+}
+
+func TestWaitBelowWakesAsTheQueueShrinks(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(10))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	steps := make(chan struct{})
+	for range 6 {
+		pool.SubmitFunc(func() { <-steps })
+	}
+
+	below := make(chan error, 1)
+	go func() { below <- pool.WaitBelow(context.Background(), 3) }()
+	close(release)
+	steps <- struct{}{}
+	steps <- struct{}{}
+	select {
+	case err := <-below:
+		t.Fatalf("WaitBelow returned %v with at least 3 tasks still queued", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(steps)
+	if err := <-below; err != nil {
+		t.Fatalf("WaitBelow: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.WaitBelow(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitBelow(0) = %v, want the deadline", err)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// ! TaskInfo describes a task for debugging and introspection.
+type TaskInfo struct {
+	Id         int
+	Priority   int
+	Tags       map[string]string //! Shared with the pool; treat as read-only.
+	EnqueuedAt time.Time
+	Deadline   time.Time //! See SubmitWithDeadline; zero for tasks without one.
+	//! How long the task ran, retries and backoffs included; only set by SlowestTasks.
+	ExecDuration time.Duration
+}
+
+// ! infoOf describes a task. The pool mutex must be held, since a queued or held task's priority
+// ! can still be raised, see TaskHandle.Boost and SubmitWithDependencies.
+func infoOf(described *task) TaskInfo {
+	return TaskInfo{
+		Id:         described.id,
+		Priority:   described.priority,
+		Tags:       described.tags,
+		EnqueuedAt: described.enqueuedAt,
+		Deadline:   described.deadline,
+	}
+}
+
+// ! PendingTasks returns a snapshot of the tasks that are queued but have not started, in
+// ! the order priorities alone would dispatch them, without removing anything. The queue is
+// ! only locked long enough to copy it, so dispatch is not held up while the snapshot is sorted.
+// ! Delayed tasks that are not due yet are not included.
+func (pool *Pool) PendingTasks() []TaskInfo {
+	pool.mutex.Lock()
+	queued := pool.queue.all()
+	infos := make([]TaskInfo, len(queued))
+	for index, queuedTask := range queued {
+		infos[index] = infoOf(queuedTask)
+	}
+	pool.mutex.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Priority != infos[j].Priority {
+			return infos[i].Priority > infos[j].Priority
+		}
+		return infos[i].Id < infos[j].Id
+	})
+	return infos
+}
+
+// ! OldestRunningTaskAge returns how long the longest-running task a worker is executing has
+// ! been at it, retries included, or zero if no task is running. It is a cheap pull-based
+// ! signal for alerting on stuck work. Leaked tasks are not included, since their workers have
+// ! moved on; Stats reports them as LeakedRunning.
+func (pool *Pool) OldestRunningTaskAge() time.Duration {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	var oldest time.Time
+	for _, startedAt := range pool.runningSince {
+		if oldest.IsZero() || startedAt.Before(oldest) {
+			oldest = startedAt
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return pool.clock.Now().Sub(oldest)
+}
+
+// ! Utilization returns the fraction of live workers that are running a task right now, from 0
+// ! to 1, for graphing how saturated the pool is. Sustained values near 1 mean the pool needs
+// ! more workers, values near 0 that it has more than it uses. Workers waiting for work, for
+// ! a leaky-bucket slot or in their init hook count as idle. Synchronous pools always report 0.
+func (pool *Pool) Utilization() float64 {
+	pool.mutex.Lock()
+	workers := pool.activeWorkers
+	pool.mutex.Unlock()
+	if workers <= 0 || pool.synchronous {
+		return 0
+	}
+	return min(float64(pool.busyWorkers.Load())/float64(workers), 1)
+}
+
+// ! markRunning records that a task started running at startedAt, or, with a zero time,
+// ! that it stopped.
+func (pool *Pool) markRunning(runningTask *task, startedAt time.Time) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if startedAt.IsZero() {
+		delete(pool.runningSince, runningTask)
+		return
+	}
+	pool.runningSince[runningTask] = startedAt
+	pool.statuses.set(runningTask.id, TaskRunning)
+}
+
+// ! copyTags returns a private copy of tags, or nil if there are none.
+func copyTags(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
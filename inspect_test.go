@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPendingTasksWhileBoosting(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(5))
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	pool.SubmitFunc(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	var handles []*TaskHandle
+	for range 5 {
+		handle, _ := pool.SubmitWithPriority(1, noopTask)
+		handles = append(handles, handle)
+	}
+
+	var boosters sync.WaitGroup
+	boosters.Go(func() {
+		for range 100 {
+			handles[4].Boost(1)
+		}
+	})
+	for range 100 {
+		pool.PendingTasks()
+	}
+	boosters.Wait()
+
+	pending := pool.PendingTasks()
+	if len(pending) != 5 || pending[0].Id != handles[4].Id() || pending[0].Priority != 101 {
+		t.Fatalf("PendingTasks returned %v, want the boosted task first at priority 101", pending)
+	}
+}
+
+func TestOldestRunningTaskAgeFollowsTheLongestRunningTask(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(2), WithClock(clock))
+	if age := pool.OldestRunningTaskAge(); age != 0 {
+		t.Fatalf("age %v with nothing running, want 0", age)
+	}
+	started := make(chan struct{})
+	runUntil := func(release <-chan struct{}) Task {
+		return TaskFunc(func(context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+	}
+	releaseFirst, releaseSecond := make(chan struct{}), make(chan struct{})
+	first, _ := pool.Submit(runUntil(releaseFirst))
+	<-started
+	clock.advance(10 * time.Second)
+	second, _ := pool.Submit(runUntil(releaseSecond))
+	<-started
+	clock.advance(5 * time.Second)
+	if age := pool.OldestRunningTaskAge(); age != 15*time.Second {
+		t.Errorf("age %v, want the first task's 15s", age)
+	}
+
+	close(releaseFirst)
+	<-first.Done()
+	returnsWithin(t, time.Second, "the age to follow the second task", func() {
+		for pool.OldestRunningTaskAge() != 5*time.Second {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	close(releaseSecond)
+	<-second.Done()
+	returnsWithin(t, time.Second, "the age to drop to zero", func() {
+		for pool.OldestRunningTaskAge() != 0 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
+
+func TestOldestRunningTaskAgeLeavesOutLeakedTasks(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithTaskTimeout(time.Millisecond, time.Minute),
+		WithLogger(log.New(io.Discard, "", 0)))
+	stuck := make(chan struct{})
+	defer close(stuck)
+	leaked, _ := pool.Submit(stuckTask(stuck))
+	abandonAfterGrace(t, pool, clock, 1)
+	<-leaked.Done()
+	returnsWithin(t, time.Second, "the leaked task to leave the running ones", func() {
+		for pool.OldestRunningTaskAge() != 0 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
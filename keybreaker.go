@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// ! BreakerKeyTag is the tag key that puts a task under WithPerKeyCircuitBreaker, as in
+// ! SubmitTagged(map[string]string{BreakerKeyTag: accountId}, task). Tasks of SubmitExclusive
+// ! are keyed by their exclusive key without it.
+const BreakerKeyTag = "breaker_key"
+
+// ! WithPerKeyCircuitBreaker isolates keys that keep failing: once threshold tasks of the same
+// ! key have failed within cooldown, the key is quarantined for cooldown, during which its
+// ! submissions are refused with a *RejectionError wrapping ErrKeyQuarantined, and its tasks
+// ! that were already queued fail with ErrKeyQuarantined when a worker picks them up instead of
+// ! running. Tasks of other keys, and unkeyed tasks, carry on as usual. When the quarantine
+// ! ends, the key starts over with a clean slate. A task's key is its SubmitExclusive key, or
+// ! else its BreakerKeyTag tag; a retried task counts once, by its final error.
+func WithPerKeyCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(pool *Pool) {
+		pool.keyBreaker = &keyBreaker{
+			threshold:   max(threshold, 1),
+			cooldown:    cooldown,
+			failures:    map[string][]time.Time{},
+			quarantined: map[string]time.Time{},
+		}
+	}
+}
+
+// ! WithCircuitBreaker protects a struggling downstream by opening the pool's circuit once
+// ! threshold tasks have failed within cooldown: for cooldown, every submission is refused with
+// ! a *RejectionError wrapping ErrCircuitOpen, so callers fail fast instead of piling up work
+// ! that is likely to fail too. Tasks already accepted still run. When the circuit closes the
+// ! count starts over. It covers all tasks alike; see WithPerKeyCircuitBreaker to isolate keys.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(pool *Pool) {
+		pool.circuitBreaker = &keyBreaker{
+			threshold:   max(threshold, 1),
+			cooldown:    cooldown,
+			failures:    map[string][]time.Time{},
+			quarantined: map[string]time.Time{},
+		}
+	}
+}
+
+// ! circuitKey is the single key WithCircuitBreaker tracks every task under.
+const circuitKey = "pool"
+
+// ! checkCircuit returns the rejection for a submission while the pool's circuit is open.
+func (pool *Pool) checkCircuit() error {
+	if until, ok := pool.circuitBreaker.quarantinedUntil(circuitKey, pool.clock.Now()); ok {
+		return pool.rejectUnlocked(RejectedCircuitOpen, fmt.Errorf("%w until %s", ErrCircuitOpen, until.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+// ! QuarantinedKeys returns the keys WithPerKeyCircuitBreaker currently quarantines, each with
+// ! the time its quarantine ends. It returns nil without the option.
+func (pool *Pool) QuarantinedKeys() map[string]time.Time {
+	return pool.keyBreaker.snapshot(pool.clock.Now())
+}
+
+// ! breakerKey returns the key a task is tracked under by the per-key circuit breaker.
+func breakerKey(keyed *task) string {
+	if keyed.exclusiveKey != "" {
+		return keyed.exclusiveKey
+	}
+	return keyed.tags[BreakerKeyTag]
+}
+
+// ! checkQuarantine returns the rejection for a submission whose key is quarantined.
+func (pool *Pool) checkQuarantine(newTask *task) error {
+	key := breakerKey(newTask)
+	if until, ok := pool.keyBreaker.quarantinedUntil(key, pool.clock.Now()); ok {
+		return pool.rejectUnlocked(RejectedKeyQuarantined, quarantineError(key, until))
+	}
+	return nil
+}
+
+// ! refuseQuarantined fails a task picked up while its key is quarantined, and reports whether
+// ! it did.
+func (pool *Pool) refuseQuarantined(currentTask *task, slot *workerSlot) bool {
+	key := breakerKey(currentTask)
+	until, ok := pool.keyBreaker.quarantinedUntil(key, pool.clock.Now())
+	if ok {
+		pool.finishUnrun(currentTask, slot, quarantineError(key, until))
+	}
+	return ok
+}
+
+func quarantineError(key string, until time.Time) error {
+	return fmt.Errorf("%w: key %q until %s", ErrKeyQuarantined, key, until.Format(time.RFC3339))
+}
+
+// ! keyBreaker tracks recent failures per key and the keys they put in quarantine. The pool-wide
+// ! breaker of WithCircuitBreaker is one with the single key circuitKey.
+type keyBreaker struct {
+	mutex       sync.Mutex
+	threshold   int
+	cooldown    time.Duration
+	failures    map[string][]time.Time //! Failures within the last cooldown, oldest first.
+	quarantined map[string]time.Time   //! When each quarantine ends.
+}
+
+// ! record counts a keyed task's final outcome. It is a no-op for unkeyed tasks or without a breaker.
+func (breaker *keyBreaker) record(key string, now time.Time, failed bool) {
+	if breaker == nil || key == "" || !failed {
+		return
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	recent := breaker.failures[key]
+	for len(recent) > 0 && now.Sub(recent[0]) >= breaker.cooldown {
+		recent = recent[1:]
+	}
+	recent = append(recent, now)
+	if len(recent) < breaker.threshold {
+		breaker.failures[key] = recent
+		return
+	}
+	delete(breaker.failures, key)
+	breaker.quarantined[key] = now.Add(breaker.cooldown)
+}
+
+// ! quarantinedUntil reports whether key is quarantined at now, and until when.
+func (breaker *keyBreaker) quarantinedUntil(key string, now time.Time) (time.Time, bool) {
+	if breaker == nil || key == "" {
+		return time.Time{}, false
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	until, ok := breaker.quarantined[key]
+	if ok && !now.Before(until) {
+		delete(breaker.quarantined, key)
+		return time.Time{}, false
+	}
+	return until, ok
+}
+
+func (breaker *keyBreaker) snapshot(now time.Time) map[string]time.Time {
+	if breaker == nil {
+		return nil
+	}
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	maps.DeleteFunc(breaker.quarantined, func(_ string, until time.Time) bool { return !now.Before(until) })
+	return maps.Clone(breaker.quarantined)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPerKeyCircuitBreakerQuarantinesOnlyTheFailingKey(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithPerKeyCircuitBreaker(2, time.Minute))
+	failing := TaskFunc(func(context.Context) error { return errors.New("account locked") })
+	badAccount := map[string]string{BreakerKeyTag: "account-7"}
+	pool.SubmitTagged(badAccount, failing)
+	pool.SubmitTagged(badAccount, failing)
+	pool.Wait()
+
+	_, err := pool.SubmitTagged(badAccount, noopTask)
+	assertRejected(t, err, RejectedKeyQuarantined, ErrKeyQuarantined)
+	good, err := pool.SubmitExclusive("account-8", func() {})
+	if err != nil {
+		t.Fatalf("a healthy key was refused: %v", err)
+	}
+	if <-good.Done(); good.Err() != nil {
+		t.Fatalf("a healthy key's task failed: %v", good.Err())
+	}
+	if quarantined := pool.QuarantinedKeys(); len(quarantined) != 1 || !quarantined["account-7"].Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("QuarantinedKeys returned %v, want account-7 for a minute", quarantined)
+	}
+
+	clock.advance(time.Minute)
+	if _, err := pool.SubmitTagged(badAccount, noopTask); err != nil {
+		t.Fatalf("the key was still refused after the cooldown: %v", err)
+	}
+	if quarantined := pool.QuarantinedKeys(); len(quarantined) != 0 {
+		t.Fatalf("QuarantinedKeys returned %v after the cooldown", quarantined)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ! maxSubmitStackDepth bounds the submission stacks WithLatencyProfile records.
+const maxSubmitStackDepth = 32
+
+// ! WithLatencyProfile keeps the execution time of the last n tasks that finished running,
+// ! along with the stack that submitted each, for LatencySamples and WriteLatencyProfile. It
+// ! costs a stack capture per submission, so it is meant for profiling sessions rather than to
+// ! be left on. Tasks run inline by a synchronous pool are not recorded.
+func WithLatencyProfile(n int) Option {
+	return func(pool *Pool) {
+		pool.latencyProfile = &latencyProfile{limit: n}
+	}
+}
+
+// ! LatencySample is one task's execution time, as recorded by WithLatencyProfile.
+type LatencySample struct {
+	TaskId       int
+	Class        string //! The task's ClassTag, if it has one.
+	ExecDuration time.Duration
+	FinishedAt   time.Time
+	SubmitStack  []uintptr //! Return addresses of the submitting goroutine, for runtime.CallersFrames.
+}
+
+// ! LatencySamples returns the retained samples, oldest first, for feeding into a metrics or
+// ! profiling tool of your own. It returns nil without WithLatencyProfile.
+func (pool *Pool) LatencySamples() []LatencySample {
+	return pool.latencyProfile.snapshot()
+}
+
+// ! WriteLatencyProfile writes the retained samples as a pprof contention profile, the text
+// ! format the runtime uses for its mutex profile at debug=1, in which each submission site's
+// ! delay is the total execution time of the tasks it submitted. Serve it next to the standard
+// ! endpoints, or save it and inspect it with `go tool pprof <binary> <file>`, where the binary
+// ! symbolizes the stacks; the comment lines also name each frame for human readers. Without
+// ! WithLatencyProfile the profile is empty.
+func (pool *Pool) WriteLatencyProfile(w io.Writer) error {
+	type site struct {
+		stack []uintptr
+		total time.Duration
+		count int
+	}
+	sites := map[string]*site{}
+	for _, sample := range pool.LatencySamples() {
+		key := fmt.Sprint(sample.SubmitStack)
+		if sites[key] == nil {
+			sites[key] = &site{stack: sample.SubmitStack}
+		}
+		sites[key].total += sample.ExecDuration
+		sites[key].count++
+	}
+	bySite := make([]*site, 0, len(sites))
+	for _, entry := range sites {
+		bySite = append(bySite, entry)
+	}
+	slices.SortFunc(bySite, func(a, b *site) int { return cmp.Compare(b.total, a.total) })
+
+	buffered := bufio.NewWriter(w)
+	fmt.Fprintf(buffered, "--- contention:\ncycles/second=%d\nsampling period=1\n", time.Second.Nanoseconds())
+	for _, entry := range bySite {
+		var addresses strings.Builder
+		for _, pc := range entry.stack {
+			fmt.Fprintf(&addresses, " %#x", pc)
+		}
+		fmt.Fprintf(buffered, "%d %d @%s\n", entry.total.Nanoseconds(), entry.count, addresses.String())
+		frames := runtime.CallersFrames(entry.stack)
+		for {
+			frame, more := frames.Next()
+			fmt.Fprintf(buffered, "#\t%#x\t%s+%#x\t%s:%d\n", frame.PC, frame.Function, frame.PC-frame.Entry, frame.File, frame.Line)
+			if !more {
+				break
+			}
+		}
+		fmt.Fprintln(buffered)
+	}
+	return buffered.Flush()
+}
+
+// ! latencyProfile is a ring buffer of the last finished tasks' latency samples.
+type latencyProfile struct {
+	mutex   sync.Mutex
+	limit   int
+	samples []LatencySample
+	next    int //! Where the next sample goes once the buffer is full.
+}
+
+// ! submitStack returns the stack of the goroutine submitting a task, or nil without a profile.
+func (profile *latencyProfile) submitStack() []uintptr {
+	if profile == nil || profile.limit <= 0 {
+		return nil
+	}
+	stack := make([]uintptr, maxSubmitStackDepth)
+	return stack[:runtime.Callers(3, stack)] //! Skips runtime.Callers, submitStack and enqueueContext.
+}
+
+// ! record adds the sample of a task that finished running. It is safe on a nil profile.
+func (profile *latencyProfile) record(finishedTask *task, duration time.Duration, finishedAt time.Time) {
+	if profile == nil || profile.limit <= 0 {
+		return
+	}
+	sample := LatencySample{
+		TaskId:       finishedTask.id,
+		Class:        finishedTask.tags[ClassTag],
+		ExecDuration: duration,
+		FinishedAt:   finishedAt,
+		SubmitStack:  finishedTask.submitStack,
+	}
+	profile.mutex.Lock()
+	defer profile.mutex.Unlock()
+	if len(profile.samples) < profile.limit {
+		profile.samples = append(profile.samples, sample)
+		return
+	}
+	profile.samples[profile.next] = sample
+	profile.next = (profile.next + 1) % profile.limit
+}
+
+func (profile *latencyProfile) snapshot() []LatencySample {
+	if profile == nil {
+		return nil
+	}
+	profile.mutex.Lock()
+	defer profile.mutex.Unlock()
+	return append(slices.Clone(profile.samples[profile.next:]), profile.samples[:profile.next]...)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func submitSlowForProfile(pool *Pool) {
+	pool.Submit(TaskFunc(func(context.Context) error { time.Sleep(5 * time.Millisecond); return nil }))
+}
+
+func TestLatencyProfileAttributesSubmitSites(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithLatencyProfile(2))
+	for range 3 {
+		submitSlowForProfile(pool)
+	}
+	pool.Submit(noopTask)
+	pool.Wait()
+
+	samples := pool.LatencySamples()
+	if len(samples) != 2 || samples[0].TaskId != 3 || samples[1].TaskId != 4 {
+		t.Fatalf("got %d samples %+v, want the last 2 tasks, oldest first", len(samples), samples)
+	}
+	if samples[0].ExecDuration < 5*time.Millisecond || len(samples[0].SubmitStack) == 0 {
+		t.Fatalf("sample %+v lacks its duration or stack", samples[0])
+	}
+
+	var profile strings.Builder
+	if err := pool.WriteLatencyProfile(&profile); err != nil {
+		t.Fatalf("WriteLatencyProfile: %v", err)
+	}
+	text := profile.String()
+	if !strings.HasPrefix(text, "--- contention:\n") || !strings.Contains(text, "submitSlowForProfile") {
+		t.Errorf("the profile does not name the slow submission site:\n%s", text)
+	}
+}
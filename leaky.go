@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ! WithLeakyBucket smooths dispatch to a strictly even rate, for downstreams that cannot
+// ! take the bursts a token bucket (see WithRateLimit) lets through. The queue acts as the
+// ! bucket: workers take tasks from it no faster than one every 1/tasksPerSecond seconds,
+// ! whatever the backlog, and while capacity tasks are already waiting, Submit drops new ones
+// ! with ErrBucketOverflow instead of blocking. Stats counts dropped tasks and tasks whose
+// ! dispatch had to wait for their slot.
+func WithLeakyBucket(tasksPerSecond float64, capacity int) Option {
+	return func(pool *Pool) {
+		pool.leakyBucket = &leakyBucket{
+			interval: time.Duration(float64(time.Second) / tasksPerSecond),
+			capacity: capacity,
+		}
+	}
+}
+
+// ! leakyBucket hands out evenly spaced dispatch slots.
+type leakyBucket struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	capacity int
+	nextSlot time.Time //! Earliest time the next dispatch may happen.
+}
+
+// ! overflowing reports whether the bucket is full, counting the drop if it is. The pool
+// ! mutex must be held.
+func (pool *Pool) overflowing() bool {
+	if pool.leakyBucket == nil || pool.queue.len() < pool.leakyBucket.capacity {
+		return false
+	}
+	pool.counters.bucketDropped.Add(1)
+	return true
+}
+
+// ! awaitDispatchSlot blocks until the leaky bucket lets the worker run its next task. It
+// ! returns early once the pool is being torn down.
+func (pool *Pool) awaitDispatchSlot() {
+	bucket := pool.leakyBucket
+	if bucket == nil {
+		return
+	}
+	bucket.mutex.Lock()
+	now := pool.clock.Now()
+	slot := bucket.nextSlot
+	if slot.Before(now) {
+		slot = now
+	}
+	bucket.nextSlot = slot.Add(bucket.interval)
+	bucket.mutex.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return
+	}
+	pool.counters.bucketDelayed.Add(1)
+	elapsed := make(chan struct{})
+	timer := pool.clock.AfterFunc(wait, func() { close(elapsed) })
+	select {
+	case <-elapsed:
+	case <-pool.poolContext.Done():
+		timer.Stop()
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeakyBucketSpacesDispatchesAndDropsOverflow(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithLeakyBucket(10, 3))
+	release := occupy(t, pool) //! Takes the first slot at once.
+	var handles []*TaskHandle
+	for range 3 {
+		handle, err := pool.Submit(noopTask)
+		if err != nil {
+			t.Fatalf("a task was dropped with room in the bucket: %v", err)
+		}
+		handles = append(handles, handle)
+	}
+	_, err := pool.Submit(noopTask)
+	assertRejected(t, err, RejectedBucketOverflow, ErrBucketOverflow)
+
+	close(release)
+	for index, handle := range handles {
+		returnsWithin(t, time.Second, "the worker to wait for its slot", func() {
+			for clock.pending() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		})
+		if finished(handle) {
+			t.Fatalf("task %d ran before its slot", index)
+		}
+		clock.advance(100 * time.Millisecond)
+		returnsWithin(t, time.Second, "a task once its slot came", func() { <-handle.Done() })
+	}
+	if stats := pool.Stats(); stats.BucketDropped != 1 || stats.BucketDelayed != 3 {
+		t.Errorf("BucketDropped %d and BucketDelayed %d, want 1 and 3", stats.BucketDropped, stats.BucketDelayed)
+	}
+}
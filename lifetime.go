@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ! WithMaxLifetimeAttempts caps how often a task may run over its whole life: its retries and
+// ! those of every resubmission that keeps its identity, such as Replay, so an operator
+// ! replaying a permanently broken task again and again cannot keep it looping forever. Once
+// ! the cap is reached the task is not retried, and a resubmission arriving with no attempts
+// ! left fails at dispatch without running, with an error wrapping ErrAttemptsExhausted.
+// ! Either way deadLetter, unless nil, is called with the task and its final error, on the
+// ! worker, so the task can be parked where someone will look at it; it is also called for a
+// ! task denied a retry by WithRetryBudget. An n of zero or less sets no cap, keeping just the
+// ! callback; the per-submission limit of WithRetry keeps applying as well.
+func WithMaxLifetimeAttempts(n int, deadLetter func(info TaskInfo, err error)) Option {
+	return func(pool *Pool) {
+		pool.maxLifetimeAttempts = n
+		pool.deadLetter = deadLetter
+	}
+}
+
+// ! lifetimeExhausted reports whether the task has used up WithMaxLifetimeAttempts's cap.
+func (pool *Pool) lifetimeExhausted(candidate *task) bool {
+	return pool.maxLifetimeAttempts > 0 && candidate.handle.identity.attempts.Load() >= int64(pool.maxLifetimeAttempts)
+}
+
+// ! refuseExhausted fails a task that arrived at dispatch with no lifetime attempts left, and
+// ! reports whether it did.
+func (pool *Pool) refuseExhausted(currentTask *task, slot *workerSlot) bool {
+	if !pool.lifetimeExhausted(currentTask) {
+		return false
+	}
+	err := fmt.Errorf("%w: task %d already ran %d times", ErrAttemptsExhausted, currentTask.id, currentTask.handle.identity.attempts.Load())
+	pool.sendToDeadLetter(currentTask, err)
+	pool.finishUnrun(currentTask, slot, err)
+	return true
+}
+
+// ! sendToDeadLetter hands a task that will never run again to the dead-letter callback.
+func (pool *Pool) sendToDeadLetter(deadTask *task, err error) {
+	if pool.deadLetter == nil {
+		return
+	}
+	pool.mutex.Lock()
+	info := infoOf(deadTask)
+	pool.mutex.Unlock()
+	pool.deadLetter(info, err)
+}
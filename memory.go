@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// ! memorySampleInterval is how often WithMemoryPressureScaling reads the heap size.
+const memorySampleInterval = 100 * time.Millisecond
+
+// ! WithMemoryPressureScaling is a safety valve against running out of memory under bursts of
+// ! allocation-heavy tasks: every 100ms the pool reads the heap size with runtime.ReadMemStats,
+// ! and while the bytes of allocated heap objects exceed heapThreshold, it shrinks its
+// ! concurrency to a single running task, so the garbage collector can catch up. Full
+// ! concurrency returns at the first sample below the threshold. Tasks already running when the
+// ! threshold is crossed are not interrupted, and each transition is logged.
+// !
+// ! ReadMemStats stops the world for the duration of the read, typically some tens of
+// ! microseconds, longer with many goroutines; at ten reads a second that is well under a
+// ! millisecond of pause per second, but it is paid by the whole program, not just the pool.
+func WithMemoryPressureScaling(heapThreshold uint64) Option {
+	return func(pool *Pool) {
+		pressure := pool.memorySampler()
+		pressure.scaling = true
+		pressure.threshold = heapThreshold
+	}
+}
+
+// ! WithMemoryLimit refuses submissions while the heap is over heapLimit bytes, with a
+// ! *RejectionError wrapping ErrMemoryLimit, so that callers back off instead of queueing more
+// ! work than the process can hold. The heap is sampled as for WithMemoryPressureScaling, with
+// ! the same cost, and the two combine: a threshold below the limit first slows the pool down,
+// ! and the limit then stops it from taking more. Tasks already accepted still run.
+func WithMemoryLimit(heapLimit uint64) Option {
+	return func(pool *Pool) {
+		pool.memorySampler().limit = heapLimit
+	}
+}
+
+// ! memorySampler returns the state shared by the memory options, creating it for the first.
+func (pool *Pool) memorySampler() *memoryPressure {
+	if pool.memoryPressure == nil {
+		pool.memoryPressure = &memoryPressure{readHeap: readHeapAlloc}
+	}
+	return pool.memoryPressure
+}
+
+// ! memoryPressure is the state of WithMemoryPressureScaling and WithMemoryLimit, guarded by
+// ! the pool mutex.
+type memoryPressure struct {
+	scaling   bool //! WithMemoryPressureScaling is set.
+	threshold uint64
+	limit     uint64        //! See WithMemoryLimit; zero for none.
+	readHeap  func() uint64 //! Current heap size, replaced in tests.
+	throttled bool          //! The last sample exceeded the threshold.
+	overLimit bool          //! The last sample exceeded the limit.
+	running   int           //! Tasks handed to workers and not yet finished.
+	stopped   bool
+	timer     Timer
+}
+
+// ! readHeapAlloc returns the bytes of allocated heap objects.
+func readHeapAlloc() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// ! startMemorySampler schedules the first heap sample, if WithMemoryPressureScaling is set.
+func (pool *Pool) startMemorySampler() {
+	if pool.memoryPressure == nil {
+		return
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pool.memoryPressure.timer = pool.clock.AfterFunc(memorySampleInterval, pool.sampleMemory)
+}
+
+// ! sampleMemory reads the heap size, switches throttling on or off, and schedules the next
+// ! sample.
+func (pool *Pool) sampleMemory() {
+	pressure := pool.memoryPressure
+	heap := pressure.readHeap()
+	pool.mutex.Lock()
+	if pressure.stopped {
+		pool.mutex.Unlock()
+		return
+	}
+	wasThrottled, wasOverLimit := pressure.throttled, pressure.overLimit
+	pressure.throttled = pressure.scaling && heap > pressure.threshold
+	pressure.overLimit = pressure.limit > 0 && heap > pressure.limit
+	if wasThrottled && !pressure.throttled {
+		pool.taskAvailable.Broadcast()
+	}
+	pressure.timer = pool.clock.AfterFunc(memorySampleInterval, pool.sampleMemory)
+	pool.mutex.Unlock()
+
+	switch {
+	case pressure.throttled && !wasThrottled:
+		pool.logger.Printf("heap at %d bytes exceeds %d; running one task at a time until it shrinks", heap, pressure.threshold)
+	case wasThrottled && !pressure.throttled:
+		pool.logger.Printf("heap back at %d bytes; resuming full concurrency", heap)
+	}
+	switch {
+	case pressure.overLimit && !wasOverLimit:
+		pool.logger.Printf("heap at %d bytes exceeds the limit of %d; refusing submissions until it shrinks", heap, pressure.limit)
+	case wasOverLimit && !pressure.overLimit:
+		pool.logger.Printf("heap back at %d bytes; accepting submissions again", heap)
+	}
+}
+
+// ! memoryLimitError returns the error for a submission refused under WithMemoryLimit, or nil.
+// ! The pool mutex must be held.
+func (pool *Pool) memoryLimitError() error {
+	pressure := pool.memoryPressure
+	if pressure == nil || !pressure.overLimit {
+		return nil
+	}
+	return pool.reject(RejectedMemoryLimit, fmt.Errorf("%w: heap over %d bytes", ErrMemoryLimit, pressure.limit))
+}
+
+// ! stopMemorySampler stops sampling for good. Throttling ends with it, so a closing pool drains
+// ! at full speed.
+func (pool *Pool) stopMemorySampler() {
+	pressure := pool.memoryPressure
+	if pressure == nil {
+		return
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pressure.stopped = true
+	pressure.throttled = false
+	pressure.overLimit = false
+	if pressure.timer != nil {
+		pressure.timer.Stop()
+	}
+}
+
+// ! memoryThrottled reports whether a worker must wait before taking a task because of memory
+// ! pressure. The pool mutex must be held.
+func (pool *Pool) memoryThrottled() bool {
+	pressure := pool.memoryPressure
+	return pressure != nil && pressure.throttled && pressure.running > 0 && !pool.closed
+}
+
+// ! enterMemoryGate counts a task handed to a worker. The pool mutex must be held.
+func (pool *Pool) enterMemoryGate() {
+	if pool.memoryPressure != nil {
+		pool.memoryPressure.running++
+	}
+}
+
+// ! leaveMemoryGate counts a finished task and lets a throttled worker take the next one.
+func (pool *Pool) leaveMemoryGate() {
+	if pool.memoryPressure == nil {
+		return
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pool.memoryPressure.running--
+	if pool.memoryPressure.throttled {
+		pool.taskAvailable.Broadcast()
+	}
+}
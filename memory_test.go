@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryPressureScalingRunsOneTaskAtATime(t *testing.T) {
+	clock := newFakeClock()
+	var heap atomic.Uint64
+	heap.Store(2 << 20)
+	pool := newTestPool(t, WithWorkers(4), WithClock(clock), WithLogger(log.New(io.Discard, "", 0)),
+		WithMemoryPressureScaling(1<<20),
+		func(pool *Pool) { pool.memoryPressure.readHeap = heap.Load })
+	clock.advance(memorySampleInterval)
+
+	var running, peak atomic.Int64
+	started := make(chan struct{}, 8)
+	release := make(chan struct{})
+	for range 8 {
+		if _, err := pool.Submit(TaskFunc(func(context.Context) error {
+			storeMax(&peak, running.Add(1))
+			started <- struct{}{}
+			<-release
+			running.Add(-1)
+			return nil
+		})); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	<-started
+	time.Sleep(20 * time.Millisecond)
+	if got := running.Load(); got != 1 {
+		t.Fatalf("%d tasks run under memory pressure, want 1", got)
+	}
+
+	heap.Store(1 << 19)
+	clock.advance(memorySampleInterval)
+	for range 3 {
+		<-started //! Three more workers join the first task.
+	}
+	close(release)
+	pool.Wait()
+	if got := peak.Load(); got != 4 {
+		t.Fatalf("peak concurrency after the heap shrank is %d, want 4", got)
+	}
+}
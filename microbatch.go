@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ! WithMicroBatch lets a worker run up to maxSize tasks of a MicroBatch in one call of its
+// ! handler: a worker that picks up such a task takes the batch's other queued tasks along and,
+// ! while it has fewer than maxSize, waits up to maxWait for more to arrive before calling the
+// ! handler. This amortizes per-call overhead, such as a network round trip per task, for the
+// ! tasks that opt in, while every other task keeps running on its own. Without the option, or
+// ! with a maxSize of 1 or less, each MicroBatch task is handled on its own.
+func WithMicroBatch(maxSize int, maxWait time.Duration) Option {
+	return func(pool *Pool) {
+		pool.microBatchSize = maxSize
+		pool.microBatchWait = maxWait
+	}
+}
+
+// ! MicroBatch submits items to a pool for a handler that processes several at once, see
+// ! WithMicroBatch. Create it with NewMicroBatch.
+type MicroBatch[T any] struct {
+	pool    *Pool
+	handler func(items []T) []error
+}
+
+// ! NewMicroBatch creates a batch submitter for pool. handler receives the items of one batch
+// ! in submission order and returns their errors, errs[i] belonging to items[i]; a shorter
+// ! slice, or nil, reports success for the items it does not cover.
+func NewMicroBatch[T any](pool *Pool, handler func(items []T) []error) *MicroBatch[T] {
+	return &MicroBatch[T]{pool: pool, handler: handler}
+}
+
+// ! Submit queues item like Submit queues a task, and returns a handle that finishes with
+// ! the error the handler reported for it. Each item gets its own Result, all carrying the
+// ! worker, start and finish times of the batch it ran in.
+func (batch *MicroBatch[T]) Submit(item T) (*TaskHandle, error) {
+	itemTask := &task{batchKey: batch, batchItem: item}
+	itemTask.run = func(ctx context.Context, scratch *WorkerScratch) error {
+		return batch.run(itemTask, scratch)
+	}
+	return batch.pool.enqueue(itemTask)
+}
+
+// ! run handles first together with the batch's other queued tasks and completes those,
+// ! returning the error for first, which the worker completes as usual.
+func (batch *MicroBatch[T]) run(first *task, scratch *WorkerScratch) error {
+	pool := batch.pool
+	siblings := pool.collectBatch(first)
+	items := make([]T, 0, len(siblings)+1)
+	items = append(items, first.batchItem.(T))
+	for _, sibling := range siblings {
+		items = append(items, sibling.batchItem.(T))
+	}
+
+	startedAt := pool.clock.Now()
+	errs := batch.handler(items)
+	finishedAt := pool.clock.Now()
+	errorOf := func(index int) error {
+		if index < len(errs) {
+			return errs[index]
+		}
+		return nil
+	}
+	for index, sibling := range siblings {
+		pool.completeSibling(sibling, errorOf(index+1), scratch.workerId, startedAt, finishedAt)
+	}
+	return errorOf(0)
+}
+
+// ! collectBatch takes queued tasks of first's batch off the queue, up to the batch size,
+// ! waiting up to the batch wait while there are fewer. They are returned in submission order.
+func (pool *Pool) collectBatch(first *task) []*task {
+	limit := pool.microBatchSize - 1
+	if limit <= 0 {
+		return nil
+	}
+	var collected []*task
+	expired := false
+	pool.mutex.Lock()
+	timer := pool.clock.AfterFunc(pool.microBatchWait, func() {
+		pool.mutex.Lock()
+		expired = true
+		pool.batchArrived.Broadcast()
+		pool.mutex.Unlock()
+	})
+	pool.batchCollectors++
+	for {
+		taken := 0
+		more := pool.queue.removeMatching(func(queued *task) bool {
+			if taken < limit-len(collected) && queued.batchKey == first.batchKey {
+				taken++
+				return true
+			}
+			return false
+		})
+		if len(more) > 0 {
+			collected = append(collected, more...)
+			pool.refillFromSpill()
+			pool.updateOverload()
+			pool.spaceAvailable.Broadcast()
+		}
+		if len(collected) >= limit || expired || pool.closed {
+			break
+		}
+		pool.batchArrived.Wait()
+	}
+	pool.batchCollectors--
+	pool.mutex.Unlock()
+	timer.Stop()
+
+	sort.Slice(collected, func(i, j int) bool { return collected[i].id < collected[j].id })
+	for _, sibling := range collected {
+		pool.announceDequeued(sibling)
+	}
+	return collected
+}
+
+// ! completeSibling finishes a task that ran as part of another task's batch.
+func (pool *Pool) completeSibling(sibling *task, err error, workerId int, startedAt, finishedAt time.Time) {
+	defer pool.finishTask()
+	defer pool.forgetProgress(sibling)
+	if err != nil {
+		pool.counters.failed.Add(1)
+	} else {
+		pool.counters.completed.Add(1)
+	}
+	pool.drainRate.record(finishedAt)
+	sibling.handle.finish(outcomeRan, err)
+	finished := Result{
+		TaskId:       sibling.id,
+		Err:          err,
+		WorkerId:     workerId,
+		Attempts:     1,
+		EnqueuedAt:   sibling.enqueuedAt,
+		StartedAt:    startedAt,
+		FinishedAt:   finishedAt,
+		QueueWait:    startedAt.Sub(sibling.enqueuedAt),
+		ExecDuration: finishedAt.Sub(startedAt),
+		TraceId:      sibling.traceId,
+		SpanId:       sibling.spanId,
+	}
+	pool.publishResult(finished)
+}
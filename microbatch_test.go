@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMicroBatchGroupsQueuedItems(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(20), WithMicroBatch(4, 20*time.Millisecond))
+	var mutex sync.Mutex
+	var sizes []int
+	batch := NewMicroBatch(pool, func(items []int) []error {
+		mutex.Lock()
+		sizes = append(sizes, len(items))
+		mutex.Unlock()
+		errs := make([]error, len(items))
+		for index, item := range items {
+			if item%3 == 0 {
+				errs[index] = fmt.Errorf("item %d", item)
+			}
+		}
+		return errs
+	})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	handles := make([]*TaskHandle, 10)
+	for item := range 10 {
+		handles[item], _ = batch.Submit(item)
+	}
+	close(release)
+	pool.Wait()
+
+	if want := []int{4, 4, 2}; !slices.Equal(sizes, want) {
+		t.Fatalf("batch sizes %v, want %v", sizes, want)
+	}
+	for item, handle := range handles {
+		if failed := handle.Err() != nil; failed != (item%3 == 0) {
+			t.Fatalf("item %d finished with %v", item, handle.Err())
+		}
+	}
+	if stats := pool.Stats(); stats.Completed+stats.Failed != 11 {
+		t.Fatalf("stats count %d finished tasks, want 11", stats.Completed+stats.Failed)
+	}
+}
+
+func TestMicroBatchWithoutOptionRunsItemsAlone(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	batch := NewMicroBatch(pool, func(items []string) []error {
+		if len(items) != 1 {
+			return []error{errors.New("batched without WithMicroBatch")}
+		}
+		return nil
+	})
+	var handles []*TaskHandle
+	for range 5 {
+		handle, _ := batch.Submit("item")
+		handles = append(handles, handle)
+	}
+	pool.Wait()
+	for _, handle := range handles {
+		if handle.Err() != nil {
+			t.Fatal(handle.Err())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// ! WithObjectPool hands each task a recycled object, such as a *bytes.Buffer, that the task
+// ! fetches with PooledObject. newObject creates an object when none is free, and reset is
+// ! called on the object once the task has finished, before it is offered to the next task.
+// ! At most one idle object per worker is kept, so the pool never holds more objects than it
+// ! can use at once. Unlike WorkerScratch, the object belongs to the task rather than to the
+// ! worker, so it works with every submit form that passes a context.
+func WithObjectPool(newObject func() any, reset func(any)) Option {
+	return func(pool *Pool) {
+		pool.objectPool = &objectPool{newObject: newObject, reset: reset}
+	}
+}
+
+// ! PooledObject returns the recycled object of the task whose context ctx is. The object is
+// ! taken from the pool on the first call and stays the same for the rest of the task, retries
+// ! included. It returns nil outside a task or when the pool has no WithObjectPool.
+// ! The task must not keep the object after it returns.
+func PooledObject(ctx context.Context) any {
+	lease, ok := ctx.Value(objectLeaseKey{}).(*objectLease)
+	if !ok {
+		return nil
+	}
+	return lease.acquire()
+}
+
+// ! objectPool is a bounded free list of reusable objects.
+type objectPool struct {
+	newObject func() any
+	reset     func(any)
+	idle      chan any //! Free objects, with room for one per worker.
+}
+
+// ! objectLeaseKey is the context key under which a task's objectLease is stored.
+type objectLeaseKey struct{}
+
+// ! objectLease lazily binds one pooled object to one task, so tasks that never ask for
+// ! an object do not take one.
+type objectLease struct {
+	objects *objectPool
+	once    sync.Once
+	object  any
+}
+
+func (lease *objectLease) acquire() any {
+	lease.once.Do(func() {
+		select {
+		case lease.object = <-lease.objects.idle:
+		default:
+			lease.object = lease.objects.newObject()
+		}
+	})
+	return lease.object
+}
+
+// ! release resets the object, if the task took one, and offers it to later tasks.
+func (lease *objectLease) release() {
+	if lease.object == nil {
+		return
+	}
+	lease.objects.reset(lease.object)
+	select {
+	case lease.objects.idle <- lease.object:
+	default: //! Enough idle objects already; let this one be garbage collected.
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// ! writePayload stands in for a task that renders its output into a temporary buffer.
+func writePayload(buffer *bytes.Buffer) {
+	buffer.Grow(benchmarkBufferSize)
+	for index := 0; index < 256; index++ {
+		buffer.WriteByte(byte(index))
+	}
+}
+
+func BenchmarkObjectPerTask(b *testing.B) {
+	pool, err := New(WithWorkers(4), WithQueueSize(64))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		pool.Submit(TaskFunc(func(context.Context) error {
+			writePayload(new(bytes.Buffer))
+			return nil
+		}))
+	}
+	pool.Close()
+}
+
+func BenchmarkObjectPool(b *testing.B) {
+	pool, err := New(WithWorkers(4), WithQueueSize(64), WithObjectPool(
+		func() any { return new(bytes.Buffer) },
+		func(object any) { object.(*bytes.Buffer).Reset() },
+	))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		pool.Submit(TaskFunc(func(ctx context.Context) error {
+			writePayload(PooledObject(ctx).(*bytes.Buffer))
+			return nil
+		}))
+	}
+	pool.Close()
+}
+
+func TestObjectPoolResetsAndReuses(t *testing.T) {
+	created, resets := 0, 0
+	pool := newTestPool(t, WithWorkers(1), WithObjectPool(
+		func() any { created++; return new(bytes.Buffer) },
+		func(object any) { resets++; object.(*bytes.Buffer).Reset() },
+	))
+	for range 5 {
+		pool.Submit(TaskFunc(func(ctx context.Context) error {
+			buffer := PooledObject(ctx).(*bytes.Buffer)
+			if buffer.Len() != 0 {
+				t.Errorf("task got a buffer holding %q", buffer.String())
+			}
+			buffer.WriteString("used")
+			return nil
+		}))
+	}
+	pool.Wait()
+	if created != 1 || resets != 5 {
+		t.Errorf("created %d objects and reset %d times, want 1 and 5", created, resets)
+	}
+	if PooledObject(context.Background()) != nil {
+		t.Errorf("PooledObject returned an object outside a task")
+	}
+}
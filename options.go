@@ -0,0 +1,137 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// ! Defaults used by New when the corresponding option is not given.
+const (
+	defaultTotalWorkers    = 3
+	defaultQueueSize       = 10
+	defaultMaxWorkerSanity = 100_000
+	defaultShutdownGrace   = 30 * time.Second //! How long NewWithContext lets the queue drain once its parent is cancelled.
+)
+
+// ! Option configures a Pool at construction time.
+type Option func(pool *Pool)
+
+// ! WithWorkers sets how many worker goroutines the pool starts. New rejects zero or fewer with
+// ! ErrNoWorkers, since such a pool would queue tasks that never run, unless WithSynchronous
+// ! is also given, in which case the count does not matter.
+func WithWorkers(totalWorkers int) Option {
+	return func(pool *Pool) {
+		pool.totalWorkers = totalWorkers
+	}
+}
+
+// ! WithMaxWorkerSanityLimit sets the largest number of workers New accepts. The guardrail
+// ! exists so that a typo such as 1000000 workers fails fast instead of spawning a million
+// ! goroutines and exhausting memory. The default is 100,000; raise it if you really need more.
+func WithMaxWorkerSanityLimit(maxWorkers int) Option {
+	return func(pool *Pool) {
+		pool.maxWorkerSanity = maxWorkers
+	}
+}
+
+// ! WithQueueSize sets how many tasks may wait in the queue before Submit blocks.
+func WithQueueSize(queueSize int) Option {
+	return func(pool *Pool) {
+		pool.queueSize = queueSize
+	}
+}
+
+// ! WithQueueTTL drops tasks that have waited in the queue longer than ttl instead of running
+// ! them, because by then nobody is waiting for their result any more, as with a request-scoped
+// ! task whose client has long given up. The age is checked when a worker picks the task up;
+// ! an expired task is reported through its handle as Expired with ErrTaskExpired. Delayed
+// ! tasks age from when their timer fires. Zero, the default, keeps tasks however long they wait.
+func WithQueueTTL(ttl time.Duration) Option {
+	return func(pool *Pool) {
+		pool.queueTTL = ttl
+	}
+}
+
+// ! WithRetry runs a task up to maxAttempts times in total while it keeps returning an error.
+// ! The error of the last attempt is the one reported on the task handle.
+func WithRetry(maxAttempts int) Option {
+	return func(pool *Pool) {
+		pool.maxAttempts = maxAttempts
+	}
+}
+
+// ! WithRetryBudget caps the number of retries the whole pool may perform within any sliding
+// ! window, so that many tasks failing at once cannot turn into a retry storm against a
+// ! downstream. Once the budget is spent, failing tasks skip their remaining attempts, report
+// ! their error straight away and are handed to the dead-letter callback of
+// ! WithMaxLifetimeAttempts, if one is set. Stats.RetryBudgetRemaining shows what is left of
+// ! the current window. It only has an effect together with WithRetry.
+func WithRetryBudget(maxRetriesPerWindow int, window time.Duration) Option {
+	return func(pool *Pool) {
+		pool.retryBudget = newRetryBudget(maxRetriesPerWindow, window)
+	}
+}
+
+// ! WithThreadPinning makes every worker lock itself to an OS thread for its whole lifetime,
+// ! for tasks that rely on thread-local native state (cgo libraries, OpenGL contexts, some
+// ! HSM clients). Each worker then occupies a dedicated thread, and while a task blocks that
+// ! thread cannot run other goroutines, so expect lower throughput with many workers. A timed
+// ! attempt (see WithTaskTimeout) runs on a goroutine of its own, locked to a thread for the
+// ! whole attempt, which need not be its worker's.
+func WithThreadPinning() Option {
+	return func(pool *Pool) {
+		pool.pinThreads = true
+	}
+}
+
+// ! WithLIFO makes workers take the most recently submitted task first among tasks of equal
+// ! priority, turning the queue into a stack, for real-time data where fresh events matter
+// ! more than old ones. During a backlog this cuts the latency of new tasks at the price of
+// ! starving old ones indefinitely; combine it with WithQueueTTL to drop those once they are
+// ! stale, or with WithPriorityAntiStarvation to still run the oldest plain task regularly.
+func WithLIFO() Option {
+	return func(pool *Pool) {
+		pool.queue.lifo = true
+	}
+}
+
+// ! WithLogger sets where the pool logs operational events such as abandoned tasks.
+// ! By default it logs to standard error.
+func WithLogger(logger *log.Logger) Option {
+	return func(pool *Pool) {
+		pool.logger = logger
+	}
+}
+
+// ! WithTaskTimeout cancels the context of every task attempt that runs longer than timeout.
+// ! A task that still has not returned gracePeriod after that is considered leaked: its
+// ! worker abandons it, logs the leak, and moves on to the next task as a replacement,
+// ! so uncooperative tasks cannot quietly eat the pool. See WithMaxLeakedWorkers.
+func WithTaskTimeout(timeout time.Duration, gracePeriod time.Duration) Option {
+	return func(pool *Pool) {
+		pool.taskTimeout = timeout
+		pool.leakGracePeriod = gracePeriod
+	}
+}
+
+// ! WithMaxLeakedWorkers caps how many abandoned tasks may still be running at once.
+// ! Beyond the cap a worker keeps waiting for its timed-out task instead of being replaced,
+// ! which bounds the number of goroutines at the cost of pool capacity. The default is the
+// ! number of workers.
+func WithMaxLeakedWorkers(maxLeakedWorkers int) Option {
+	return func(pool *Pool) {
+		pool.maxLeakedWorkers = maxLeakedWorkers
+	}
+}
+
+// ! WithPriorityAntiStarvation bounds how long plain tasks can wait behind prioritized ones.
+// ! Every k-th dispatch (the k-th, 2k-th, and so on, counted over the pool's lifetime) takes
+// ! the oldest queued task that was submitted without a priority, whatever the priorities of
+// ! the other queued tasks. If no such task is queued, that dispatch follows priority order
+// ! as usual. With k = 1 every dispatch prefers plain tasks, so the queue is FIFO for them.
+// ! Once Shutdown has started, dispatch follows priorities alone.
+func WithPriorityAntiStarvation(k int) Option {
+	return func(pool *Pool) {
+		pool.queue.antiStarvationInterval = k
+	}
+}
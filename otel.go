@@ -0,0 +1,83 @@
+package main
+
+import "context"
+
+// ! OTelMeter is the part of an OpenTelemetry metric.Meter that WithOTelMetrics uses, reduced
+// ! to plain functions so the pool does not depend on the OpenTelemetry module. An adapter
+// ! over a real meter creates each instrument with the given name, unit and description and
+// ! returns a closure over it:
+// !
+// !	func (adapter meterAdapter) Counter(name, unit, description string) func(context.Context, int64) {
+// !		counter, _ := adapter.meter.Int64Counter(name, metric.WithUnit(unit), metric.WithDescription(description))
+// !		return func(ctx context.Context, increment int64) { counter.Add(ctx, increment) }
+// !	}
+// !
+// ! Histogram does the same with Float64Histogram and Record, Gauge registers an
+// ! Int64ObservableGauge whose callback observes observe().
+type OTelMeter interface {
+	Counter(name, unit, description string) func(ctx context.Context, increment int64)
+	Histogram(name, unit, description string) func(ctx context.Context, value float64)
+	Gauge(name, unit, description string, observe func() int64)
+}
+
+// ! WithOTelMetrics reports the pool's lifecycle to OpenTelemetry instruments created from
+// ! meter, named after the semantic conventions:
+// !
+// !	workerpool.queue.depth     gauge, {task}      tasks waiting in the queue
+// !	workerpool.workers         gauge, {worker}    worker slots in service
+// !	workerpool.task.duration   histogram, s       execution time of each task, retries included
+// !	workerpool.task.completed  counter, {task}    tasks that finished without an error
+// !	workerpool.task.failed     counter, {task}    tasks whose final attempt failed
+// !
+// ! Instruments are created once, by New. Each finished task is recorded with the context it
+// ! ran under, so exemplars and baggage the meter reads from it apply, and recording allocates
+// ! nothing beyond what the instruments themselves do. Gauges are read when the meter collects
+// ! them, each taking the pool mutex briefly.
+func WithOTelMetrics(meter OTelMeter) Option {
+	return func(pool *Pool) {
+		pool.otelMetrics = &otelMetrics{meter: meter}
+	}
+}
+
+// ! otelMetrics holds the instruments of WithOTelMetrics.
+type otelMetrics struct {
+	meter     OTelMeter
+	duration  func(ctx context.Context, value float64)
+	completed func(ctx context.Context, increment int64)
+	failed    func(ctx context.Context, increment int64)
+}
+
+// ! registerOTelMetrics creates the instruments of WithOTelMetrics.
+func (pool *Pool) registerOTelMetrics() {
+	metrics := pool.otelMetrics
+	if metrics == nil {
+		return
+	}
+	meter := metrics.meter
+	meter.Gauge("workerpool.queue.depth", "{task}", "Tasks waiting in the queue.", func() int64 {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+		return int64(pool.queue.len())
+	})
+	meter.Gauge("workerpool.workers", "{worker}", "Worker slots in service.", func() int64 {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+		return int64(pool.activeWorkers)
+	})
+	metrics.duration = meter.Histogram("workerpool.task.duration", "s", "Execution time of a task, retries included.")
+	metrics.completed = meter.Counter("workerpool.task.completed", "{task}", "Tasks that finished without an error.")
+	metrics.failed = meter.Counter("workerpool.task.failed", "{task}", "Tasks whose final attempt failed.")
+}
+
+// ! record reports a finished task. It does nothing without WithOTelMetrics.
+func (metrics *otelMetrics) record(ctx context.Context, seconds float64, err error) {
+	if metrics == nil {
+		return
+	}
+	metrics.duration(ctx, seconds)
+	if err != nil {
+		metrics.failed(ctx, 1)
+	} else {
+		metrics.completed(ctx, 1)
+	}
+}
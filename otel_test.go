@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// ! fakeMeter keeps every instrument's running total by name.
+type fakeMeter struct {
+	mutex  sync.Mutex
+	totals map[string]float64
+	counts map[string]int
+	gauges map[string]func() int64
+}
+
+func newFakeMeter() *fakeMeter {
+	return &fakeMeter{totals: map[string]float64{}, counts: map[string]int{}, gauges: map[string]func() int64{}}
+}
+
+func (meter *fakeMeter) add(name string, value float64) {
+	meter.mutex.Lock()
+	defer meter.mutex.Unlock()
+	meter.totals[name] += value
+	meter.counts[name]++
+}
+
+func (meter *fakeMeter) Counter(name, _, _ string) func(context.Context, int64) {
+	return func(_ context.Context, increment int64) { meter.add(name, float64(increment)) }
+}
+
+func (meter *fakeMeter) Histogram(name, _, _ string) func(context.Context, float64) {
+	return func(_ context.Context, value float64) { meter.add(name, value) }
+}
+
+func (meter *fakeMeter) Gauge(name, _, _ string, observe func() int64) {
+	meter.gauges[name] = observe
+}
+
+func TestOTelMetricsRecordsLifecycle(t *testing.T) {
+	meter := newFakeMeter()
+	pool := newTestPool(t, WithWorkers(3), WithOTelMetrics(meter))
+	for index := range 5 {
+		pool.Submit(TaskFunc(func(context.Context) error {
+			if index%2 == 0 {
+				return errors.New("odd one out")
+			}
+			return nil
+		}))
+	}
+	pool.Wait()
+
+	if got := meter.gauges["workerpool.workers"](); got != 3 {
+		t.Errorf("workerpool.workers = %d, want 3", got)
+	}
+	if got := meter.gauges["workerpool.queue.depth"](); got != 0 {
+		t.Errorf("workerpool.queue.depth = %d after Wait, want 0", got)
+	}
+	meter.mutex.Lock()
+	defer meter.mutex.Unlock()
+	if completed, failed := meter.totals["workerpool.task.completed"], meter.totals["workerpool.task.failed"]; completed != 2 || failed != 3 {
+		t.Errorf("got %v completed and %v failed, want 2 and 3", completed, failed)
+	}
+	if got := meter.counts["workerpool.task.duration"]; got != 5 {
+		t.Errorf("recorded %d durations, want 5", got)
+	}
+}
+
+func TestOTelMetricsRecordDoesNotAllocate(t *testing.T) {
+	noop := func(context.Context, int64) {}
+	metrics := &otelMetrics{duration: func(context.Context, float64) {}, completed: noop, failed: noop}
+	ctx := context.Background()
+	if allocs := testing.AllocsPerRun(100, func() { metrics.record(ctx, 0.5, nil) }); allocs != 0 {
+		t.Fatalf("record allocates %v times per call", allocs)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// ! PanicPolicy decides what the pool does when a task panics.
+type PanicPolicy int
+
+const (
+	//! Recover turns the panic into a *PanicError reported on the task handle, and the worker keeps going. This is the default.
+	Recover PanicPolicy = iota
+	//! Rethrow stops the pool and re-raises the panic from Wait or Close.
+	Rethrow
+	//! Ignore swallows the panic; the task counts as completed without an error.
+	Ignore
+)
+
+// ! PanicError is the error reported for a task that panicked under the Recover policy.
+type PanicError struct {
+	Value any    //! The value passed to panic.
+	Stack []byte //! The stack of the worker goroutine at the time of the panic.
+}
+
+func (panicError *PanicError) Error() string {
+	return fmt.Sprintf("worker pool: task panicked: %v", panicError.Value)
+}
+
+// ! WithPanicPolicy chooses how task panics are handled, see PanicPolicy.
+// !
+// ! A panic can only unwind the goroutine it happens on, so Rethrow cannot crash the
+// ! submitter directly. Instead the first panic closes the pool, drops every queued task,
+// ! and is raised again in whichever goroutine calls Wait or Close, usually main. The
+// ! re-raised value is the original *PanicError, whose Stack holds the worker's stack.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(pool *Pool) {
+		pool.panicPolicy = policy
+	}
+}
+
+// ! attemptResult describes how a single run of a task ended.
+type attemptResult struct {
+	err      error
+	panicked bool //! The attempt panicked, whatever the panic policy made of it.
+	leaked   bool //! The attempt ignored its timeout and was abandoned, see runAttemptWithTimeout.
+	timeout  TimeoutFired
+}
+
+// ! runAttempt runs a task once and applies the panic policy if it panics.
+func (pool *Pool) runAttempt(ctx context.Context, currentTask *task, scratch *WorkerScratch) (result attemptResult) {
+	defer func() {
+		value := recover()
+		if value == nil {
+			return
+		}
+		pool.counters.panics.Add(1)
+		result.panicked = true
+		panicError := &PanicError{Value: value, Stack: debug.Stack()}
+		switch pool.panicPolicy {
+		case Ignore:
+			result.err = nil
+		case Rethrow:
+			if pool.rethrownPanic.CompareAndSwap(nil, panicError) {
+				pool.abort()
+			}
+			result.err = panicError
+		default:
+			result.err = panicError
+		}
+	}()
+	result.err = currentTask.run(ctx, scratch)
+	return result
+}
+
+// ! abort closes the pool from inside and drops every queued task, so that Wait and Close
+// ! return promptly and can re-raise a panic.
+func (pool *Pool) abort() {
+	pool.mutex.Lock()
+	pool.closed = true
+	dropped := pool.queue.removeAll()
+	dropped = append(dropped, pool.diskSpill.removeAll()...)
+	pool.taskAvailable.Broadcast()
+	pool.spaceAvailable.Broadcast()
+	pool.mutex.Unlock()
+
+	pool.cancelScheduled()
+	for _, droppedTask := range dropped {
+		pool.cancelTask(droppedTask)
+	}
+}
+
+// ! rethrowPanic re-raises the panic recorded under the Rethrow policy, if there is one.
+func (pool *Pool) rethrowPanic() {
+	if panicError := pool.rethrownPanic.Load(); panicError != nil {
+		panic(panicError)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ! BatchItemStatus is how far ParallelMap got with one input.
+type BatchItemStatus int
+
+const (
+	ItemNotStarted BatchItemStatus = iota //! Never ran: refused, dropped from the queue, or never submitted.
+	ItemSucceeded                         //! Ran, and its final attempt returned no error.
+	ItemFailed                            //! Ran, and its final attempt returned an error.
+)
+
+func (status BatchItemStatus) String() string {
+	switch status {
+	case ItemSucceeded:
+		return "succeeded"
+	case ItemFailed:
+		return "failed"
+	default:
+		return "not started"
+	}
+}
+
+// ! BatchItem is the outcome of one input of ParallelMap. Value is only meaningful when Status
+// ! is ItemSucceeded. Err is the task's error when it failed, and why it never ran when it did
+// ! not start, such as ctx.Err() or an error matching ErrPoolClosed.
+type BatchItem[R any] struct {
+	Value  R
+	Err    error
+	Status BatchItemStatus
+}
+
+// ! ParallelMap runs fn on every input in parallel across the pool and blocks until each has
+// ! finished or been given up on, keeping whatever was computed when the batch is interrupted.
+// ! items[i] always belongs to inputs[i]. Once ctx ends, inputs still queued or not yet
+// ! submitted are dropped and running ones have their context cancelled, as with
+// ! SubmitBatchCtx; a Close or Shutdown meanwhile likewise leaves the remaining inputs
+// ! unstarted. err is nil when every input ran, successfully or not; otherwise it is ctx.Err()
+// ! if ctx ended, or else the error that kept the first unstarted input from running. To
+// ! resume, submit again exactly the inputs whose Status is ItemNotStarted.
+func ParallelMap[T, R any](ctx context.Context, pool *Pool, inputs []T, fn func(ctx context.Context, input T) (R, error)) (items []BatchItem[R], err error) {
+	items = make([]BatchItem[R], len(inputs))
+	handles := make([]*TaskHandle, len(inputs))
+	started := make([]atomic.Bool, len(inputs))
+	marker := &batchMarker{}
+	stop := context.AfterFunc(ctx, func() { pool.abandonBatch(marker, ctx.Err()) })
+	defer stop()
+	for index, input := range inputs {
+		if items[index].Err = ctx.Err(); items[index].Err != nil {
+			continue
+		}
+		item := &items[index] //! Each task writes to its own slot, so no locking is needed.
+		run := func(taskContext context.Context, _ *WorkerScratch) error {
+			if err := ctx.Err(); err != nil {
+				return err //! Picked up before abandonBatch could drop it.
+			}
+			started[index].Store(true)
+			value, err := fn(taskContext, input)
+			deliverOutput(taskContext, func() { item.Value = value })
+			return err
+		}
+		handles[index], item.Err = pool.enqueueContext(ctx, &task{run: run, batch: marker})
+	}
+
+	var unstartedErr error
+	for index, handle := range handles {
+		item := &items[index]
+		if handle != nil {
+			<-handle.done
+			if started[index].Load() {
+				item.Err = handle.err
+				item.Status = ItemSucceeded
+				if item.Err != nil {
+					item.Status = ItemFailed
+				}
+				continue
+			}
+			item.Err = pool.waitError(handle)
+		}
+		if unstartedErr == nil {
+			unstartedErr = item.Err
+		}
+	}
+	if unstartedErr != nil && ctx.Err() != nil {
+		return items, ctx.Err()
+	}
+	return items, unstartedErr
+}
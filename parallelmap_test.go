@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParallelMapKeepsPartialResultsOnCancel(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	ctx, cancel := context.WithCancel(context.Background())
+	failure := errors.New("bad input")
+	done := make(chan struct{})
+	inputs := []int{0, 1, 2, 3, 4, 5}
+	var items []BatchItem[int]
+	var err error
+	go func() {
+		defer close(done)
+		items, err = ParallelMap(ctx, pool, inputs, func(ctx context.Context, input int) (int, error) {
+			switch input {
+			case 0:
+				return 10, nil
+			case 1:
+				return 0, failure
+			}
+			cancel() //! Interrupts the batch from its third input on.
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	}()
+	returnsWithin(t, time.Second, "ParallelMap", func() { <-done })
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if items[0].Status != ItemSucceeded || items[0].Value != 10 {
+		t.Errorf("items[0] = %+v, want succeeded with 10", items[0])
+	}
+	if items[1].Status != ItemFailed || !errors.Is(items[1].Err, failure) {
+		t.Errorf("items[1] = %+v, want failed", items[1])
+	}
+	unstarted := 0
+	for _, item := range items[2:] {
+		switch item.Status {
+		case ItemNotStarted:
+			unstarted++
+			if item.Err == nil {
+				t.Errorf("an unstarted item has no error")
+			}
+		case ItemSucceeded:
+			t.Errorf("an interrupted item succeeded: %+v", item)
+		}
+	}
+	if unstarted == 0 {
+		t.Errorf("no item was left unstarted: %+v", items)
+	}
+}
+
+func TestParallelMapWithoutInterruption(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(3))
+	items, err := ParallelMap(context.Background(), pool, []int{1, 2, 3}, func(_ context.Context, input int) (int, error) {
+		return input * input, nil
+	})
+	if err != nil {
+		t.Fatalf("ParallelMap: %v", err)
+	}
+	for index, item := range items {
+		if want := (index + 1) * (index + 1); item.Status != ItemSucceeded || item.Value != want {
+			t.Errorf("items[%d] = %+v, want %d", index, item, want)
+		}
+	}
+}
+
+func TestParallelMapAfterClose(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	pool.Close()
+	items, err := ParallelMap(context.Background(), pool, []string{"a"}, func(context.Context, string) (int, error) { return 1, nil })
+	if !errors.Is(err, ErrPoolClosed) || items[0].Status != ItemNotStarted {
+		t.Fatalf("got %+v, %v; want the item unstarted and ErrPoolClosed", items, err)
+	}
+}
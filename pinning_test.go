@@ -0,0 +1,50 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// ! threadHopper returns a task that yields between its steps and reports whether it stayed
+// ! on one OS thread. A moved task sends false.
+func threadHopper(stayed chan<- bool) Task {
+	return TaskFunc(func(context.Context) error {
+		thread := syscall.Gettid()
+		for range 20 {
+			runtime.Gosched()
+			time.Sleep(50 * time.Microsecond)
+			if syscall.Gettid() != thread {
+				stayed <- false
+				return nil
+			}
+		}
+		stayed <- true
+		return nil
+	})
+}
+
+func TestThreadPinningKeepsTasksOnTheirThread(t *testing.T) {
+	for name, options := range map[string][]Option{
+		"untimed": {WithWorkers(4), WithThreadPinning()},
+		"timed":   {WithWorkers(4), WithThreadPinning(), WithTaskTimeout(time.Minute, time.Minute)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pool := newTestPool(t, options...)
+			stayed := make(chan bool, 16)
+			for range cap(stayed) {
+				pool.Submit(threadHopper(stayed))
+			}
+			pool.Wait()
+			for range cap(stayed) {
+				if !<-stayed {
+					t.Fatal("a task moved to another OS thread while pinned")
+				}
+			}
+		})
+	}
+}
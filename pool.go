@@ -0,0 +1,811 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ! Pool runs submitted tasks on a fixed set of worker goroutines.
+// ! Tasks are held in a bounded priority queue until a worker is free to pick them up;
+// ! Submit blocks while the queue is full, which applies backpressure to producers.
+type Pool struct {
+	totalWorkers      int
+	maxWorkerSanity   int //! Upper bound on totalWorkers that New accepts, see WithMaxWorkerSanityLimit.
+	queueSize         int
+	queueTTL          time.Duration //! Longest a task may wait in the queue and still run, see WithQueueTTL.
+	maxAttempts       int           //! How many times a failing task is run before its error is final.
+	retryBackoff      time.Duration //! Wait before the first retry, doubling for each further one, see WithRetryBackoff.
+	maxRetryBackoff   time.Duration
+	retryJitter       float64 //! Fraction of each backoff that may be cut at random, see WithRetryJitter.
+	delivery          DeliverySemantics
+	retryBudget       *retryBudget //! Optional limit on retries across all tasks, see WithRetryBudget.
+	panicPolicy       PanicPolicy
+	pinThreads        bool //! Lock each worker to its own OS thread, see WithThreadPinning.
+	synchronous       bool //! Run tasks inline on the submitting goroutine, see WithSynchronous.
+	logger            *log.Logger
+	workerNamePrefix  string                                             //! Prefix of worker names in logs and profiles, see WithWorkerNamePrefix.
+	workerInit        func(workerId int) error                           //! Optional per-worker setup, see WithWorkerInit.
+	warmup            func(workerId int) error                           //! Optional per-worker priming, see WithWarmup.
+	workerTeardown    func(workerId int)                                 //! Optional per-worker cleanup, see WithWorkerTeardown.
+	name              string                                             //! Registry name, see WithName; empty for unnamed pools.
+	standbyMember     bool                                               //! Created by a StandbyPool, which cannot spill to disk.
+	traceExtractor    func(ctx context.Context) (traceId, spanId string) //! Optional, see WithTraceExtractor.
+	failFastInit      bool                                               //! New fails on the first failed init, see WithFailFastInit.
+	minHealthyWorkers int                                                //! Inits that must succeed for New to succeed, see WithMinHealthyWorkers.
+	initErr           error                                              //! First init error, kept for checkInit; guarded by mutex.
+
+	capabilities       func(workerId int) []string //! Optional worker labels, see WithWorkerCapabilities.
+	capabilityPolicy   CapabilityPolicy
+	workerCapabilities map[int][]string //! Labels of each worker id, fixed by New.
+	clock              Clock
+
+	taskTimeout      time.Duration //! Per-attempt timeout, zero when tasks may run forever.
+	leakGracePeriod  time.Duration //! How long a timed-out task gets to return before it is abandoned.
+	maxLeakedWorkers int           //! How many abandoned tasks may run at once before workers stop being replaced.
+
+	latencySLO      time.Duration //! End-to-end latency above which onLatencyBreach is called, zero when disabled.
+	onLatencyBreach func(taskId int, total time.Duration, dominant LatencyComponent)
+
+	objectPool *objectPool //! Optional per-task reusable objects, see WithObjectPool.
+
+	loadShedding        *loadShedding                  //! Optional overload detector, see WithLoadShedding. Guarded by mutex.
+	diskSpill           *diskSpill                     //! Optional overflow queue on disk, see WithDiskSpill. Guarded by mutex.
+	budget              *weightedSemaphore             //! Optional limit on the total cost of running tasks, see WithConcurrencyBudget.
+	cooperativeYield    *cooperativeYield              //! Optional tracker of running priorities, see WithCooperativeYield.
+	rateLimiter         *rateLimiter                   //! Optional admission rate limit, see WithRateLimit. Guarded by mutex.
+	workerRate          *rateLimiter                   //! Template for the per-worker buckets, see WithPerWorkerRateLimit.
+	workerLimiters      map[int]*rateLimiter           //! Per-worker buckets by worker id, each used only by its worker.
+	leakyBucket         *leakyBucket                   //! Optional dispatch smoother, see WithLeakyBucket.
+	adaptiveConcurrency *adaptiveLimiter               //! Optional AIMD limit on running tasks, see WithAdaptiveConcurrency.
+	random              *lockedRand                    //! Source of randomness, see WithRandSource; nil for the global one.
+	onEnqueue           func(taskId int, at time.Time) //! Optional, see WithOnEnqueue.
+	onDequeue           func(taskId int, at time.Time) //! Optional, see WithOnDequeue.
+	slowTasks           *slowTasks                     //! Optional, see WithSlowTaskTracking.
+
+	quarantinePanics  int //! Panics within quarantineWindow that retire a worker slot, zero when disabled.
+	quarantineWindow  time.Duration
+	maxTasksPerWorker int //! Tasks after which a worker is replaced, zero when disabled.
+
+	resultSinks []resultSink //! Receive the Result of every task that ran, see result.go.
+	sinksMutex  sync.RWMutex //! Guards resultSinks, which can grow after New, and sinksClosed.
+	sinksClosed bool         //! Set by closeResultSinks; sinks added later are closed at once.
+
+	results                *resultChannel //! Sink behind Results, created on its first call.
+	resultsOnce            sync.Once
+	strictResults          bool //! Results waits for its reader instead of dropping, see WithStrictResultDelivery.
+	strictResultsWarnAfter time.Duration
+
+	mutex               sync.Mutex
+	taskAvailable       *sync.Cond                                         //! Signalled when a task is queued or the pool is closed.
+	spaceAvailable      *sync.Cond                                         //! Signalled when a worker takes a task off the queue.
+	submitLine          *list.List                                         //! Submitters in arrival order, see WithFairSubmit; nil without it.
+	replaceSlots        map[string]*task                                   //! Latest task submitted per slot, see SubmitReplace; guarded by mutex.
+	orderedWindow       *orderedWindow                                     //! Optional, see WithOrderedWindow.
+	groups              map[string]*taskGroup                              //! Groups tasks were submitted to, see GroupTag; guarded by mutex.
+	failFastGroups      map[string]bool                                    //! Groups configured with WithGroupFailFast.
+	shutdownHooks       []func()                                           //! Run once the pool has wound down, see WithShutdownHook.
+	exclusiveLines      map[string]*exclusiveLine                          //! Keys with an active SubmitExclusive task; guarded by mutex.
+	priorityBands       *priorityBands                                     //! Optional, see WithPriorityBands.
+	keyBreaker          *keyBreaker                                        //! Optional, see WithPerKeyCircuitBreaker.
+	circuitBreaker      *keyBreaker                                        //! Optional, see WithCircuitBreaker.
+	memoryPressure      *memoryPressure                                    //! See WithMemoryPressureScaling.
+	recorder            *dispatchRecorder                                  //! See WithRecorder.
+	otelMetrics         *otelMetrics                                       //! See WithOTelMetrics.
+	coalescer           *resultCoalescer                                   //! See WithResultCoalescing.
+	statuses            taskStatuses                                       //! See Status.
+	dropMissedDeadlines bool                                               //! See WithDropMissedDeadlines.
+	dispatchReplay      *dispatchReplay                                    //! See WithReplay.
+	spinBeforePark      int                                                //! See WithSpinBeforePark.
+	pushes              atomic.Uint64                                      //! Tasks ever pushed, polled by spinning workers.
+	latencyProfile      *latencyProfile                                    //! Optional, see WithLatencyProfile.
+	drainRate           drainRate                                          //! Completion rate behind EstimatedDrainTime.
+	cpuBudget           *cpuBudget                                         //! Optional, see WithCPUTimeBudget.
+	routeWeights        map[string]float64                                 //! Worker group weights for SubmitRouted; guarded by mutex.
+	maxLifetimeAttempts int                                                //! See WithMaxLifetimeAttempts; 0 for no cap.
+	deadLetter          func(info TaskInfo, err error)                     //! Optional, see WithMaxLifetimeAttempts.
+	heartbeatTimeout    time.Duration                                      //! See WithHeartbeatTimeout.
+	onMissedHeartbeat   func(info TaskInfo, silentFor time.Duration)       //! Called for a silent task.
+	callbacks           *callbackDispatcher                                //! Optional, see WithCallbackDispatcher.
+	idempotencyStore    IdempotencyStore                                   //! Optional, see WithIdempotencyStore.
+	resultPayloads      bool                                               //! Results carry their Task, see WithResultPayloads.
+	retryPredicate      func(err error) bool                               //! Optional, see WithRetryPredicate.
+	microBatchSize      int                                                //! Most tasks a MicroBatch handler gets at once, see WithMicroBatch.
+	microBatchWait      time.Duration                                      //! How long a worker waits to fill a batch.
+	batchArrived        *sync.Cond                                         //! Signalled when a MicroBatch task is queued while a worker collects a batch.
+	batchCollectors     int                                                //! Workers collecting a batch right now.
+	depthWaiters        int                                                //! WaitBelow calls in progress, so dequeue only wakes waiters when someone cares.
+	admissionController func(info TaskInfo) (admitted bool, reason string) //! Optional, see WithAdmissionController.
+	drains              int                                                //! Drain and DrainAndReject calls in progress.
+	rejectingDrains     int                                                //! Those of them that refuse submissions.
+	drainHeld           []*task                                            //! Tasks submitted during a Drain, queued once it completes.
+	queue               taskQueue
+	closed              bool
+	closeOnce           sync.Once
+	shutdownOnce        sync.Once
+	shuttingDown        chan struct{}                //! Closed by Shutdown, which interrupts retry backoffs.
+	unprocessed         []TaskInfo                   //! Tasks Shutdown gave up on; nil unless a Shutdown is collecting them.
+	outstanding         int                          //! Submitted tasks that are scheduled, queued or running.
+	scheduled           map[*task]Timer              //! Delayed tasks whose timer has not fired yet, see SubmitAfter.
+	held                map[*task]struct{}           //! Tasks waiting for their dependencies, see SubmitWithDependencies.
+	runningCancels      map[*task]context.CancelFunc //! Running tasks, so CancelByTag and TaskHandle.Cancel can reach them.
+	runningSince        map[*task]time.Time          //! When each task a worker is executing started, see OldestRunningTaskAge.
+	progressTasks       map[int]*TaskHandle          //! Unfinished tasks submitted with SubmitWithProgress, by id.
+	activeWorkers       int                          //! Worker slots that have not been quarantined or failed their init.
+	readyWorkers        int                          //! Running workers that have finished their init, see WaitReady.
+	stateChanged        chan struct{}                //! Closed and cleared on every change waiters may care about, see waitFor.
+	lastTaskId          int
+
+	poolContext       context.Context //! Parent of every task context; cancelled once Close has drained the pool.
+	cancelPoolContext context.CancelFunc
+
+	workersWaitGroup sync.WaitGroup //! Tracks running worker goroutines.
+	goroutines       atomic.Int64   //! Every goroutine the pool started that is still running, see GoroutineCount.
+	busyWorkers      atomic.Int64   //! Workers executing a task right now, see Utilization.
+
+	counters      poolCounters
+	classStats    classStats
+	rethrownPanic atomic.Pointer[PanicError] //! First panic seen under the Rethrow policy.
+}
+
+// ! task is a unit of work sitting in the queue together with the handle returned to its submitter.
+type task struct {
+	id       int
+	work     Task //! The submitted Task value, nil for closures. Needed to spill the task to disk.
+	run      func(ctx context.Context, scratch *WorkerScratch) error
+	onCancel func()      //! Optional, called if the task is dropped from the queue before it runs.
+	guard    func() bool //! Optional, checked at dispatch; the task is skipped if it returns false.
+
+	enqueuedAt time.Time
+	tags       map[string]string //! Labels given at submit time, never modified afterwards.
+	traceId    string            //! Trace of the submitting context, see WithTraceExtractor.
+	spanId     string
+	identity   *taskIdentity //! Inherited identity of a resubmitted task; nil for new ones.
+
+	priority         int
+	prioritized      bool          //! Submitted with an explicit priority rather than as plain FIFO work.
+	queueIndex       int           //! Position in the priority heap, or -1 once the task has left the queue.
+	order            int           //! Tie-breaker among equal priorities, see taskQueue.push.
+	shed             bool          //! Set at dispatch when load shedding decided to drop the task.
+	spillPath        string        //! File holding the encoded task while it comes from disk, see WithDiskSpill.
+	capability       string        //! Capability a worker needs to run the task, see SubmitRequiring.
+	cost             int           //! Units of the concurrency budget the task takes, see SubmitWeighted.
+	timeout          time.Duration //! Per-attempt timeout overriding the pool's, see TaskSpec.
+	submitStack      []uintptr     //! Who submitted the task, under WithLatencyProfile.
+	band             int           //! Index of the priority band the task runs in, see WithPriorityBands.
+	dispatchSequence int           //! Place in the WithRecorder output, set at dispatch.
+	forgotten        bool          //! Submitted with SubmitAndForget, so no Result is published.
+	deadline         time.Time     //! See SubmitWithDeadline; zero for none.
+	hardTimeout      time.Duration //! When the attempt is abandoned, overriding the grace period; see SubmitWithTimeouts.
+	maxAttempts      int           //! Attempt limit overriding the pool's, see TaskSpec.
+
+	slot         string          //! Replacement slot, see SubmitReplace.
+	callback     func(err error) //! Called once the task has finished, see SubmitCallback.
+	batch        *batchMarker    //! The SubmitBatchCtx call the task belongs to.
+	exclusiveKey string          //! Serialization key, see SubmitExclusive.
+	exclusive    *exclusiveLine  //! The key's line, set by holdExclusive.
+	batchKey     any             //! The MicroBatch the task belongs to, compared by identity; nil for plain tasks.
+	batchItem    any             //! The item handed to the MicroBatch's handler.
+	replaced     *task           //! Task this one displaced from its slot, handed from admit to dropReplaced.
+
+	dependencies []*TaskHandle //! Tasks that must finish before this one is queued, see SubmitWithDependencies.
+
+	reportsProgress bool //! Submitted with SubmitWithProgress, so TaskProgress can find it.
+	noWait          bool //! Fail admission instead of waiting for space or a token, see TrySubmit.
+	handle          *TaskHandle
+}
+
+// ! New creates a pool and starts its workers immediately. It returns an error wrapping
+// ! ErrTooManyWorkers, and starts nothing, if more workers are configured than the sanity
+// ! limit allows (see WithMaxWorkerSanityLimit), and one wrapping ErrNoWorkers if fewer than
+// ! one worker is configured for a pool that is not synchronous.
+func New(options ...Option) (*Pool, error) {
+	pool := &Pool{
+		totalWorkers:    defaultTotalWorkers,
+		maxWorkerSanity: defaultMaxWorkerSanity,
+		queueSize:       defaultQueueSize,
+		maxAttempts:     1,
+		logger:          log.New(os.Stderr, "worker pool: ", log.LstdFlags),
+		clock:           realClock{},
+		scheduled:       make(map[*task]Timer),
+		held:            make(map[*task]struct{}),
+		runningCancels:  make(map[*task]context.CancelFunc),
+		runningSince:    make(map[*task]time.Time),
+		progressTasks:   make(map[int]*TaskHandle),
+		shuttingDown:    make(chan struct{}),
+	}
+	pool.statuses.capacity = defaultStatusRetention
+	for _, option := range options {
+		option(pool)
+	}
+	if pool.totalWorkers < 1 && !pool.synchronous {
+		return nil, fmt.Errorf("%w: %d workers configured, so nothing would ever drain the queue; "+
+			"to run tasks on the submitting goroutine instead, say so with WithSynchronous",
+			ErrNoWorkers, pool.totalWorkers)
+	}
+	if pool.totalWorkers > pool.maxWorkerSanity {
+		return nil, fmt.Errorf("%w: %d workers requested, the limit is %d; this is usually a configuration typo, "+
+			"and if so many workers are really intended, raise the limit with WithMaxWorkerSanityLimit",
+			ErrTooManyWorkers, pool.totalWorkers, pool.maxWorkerSanity)
+	}
+	if err := pool.priorityBands.check(pool.totalWorkers); err != nil {
+		return nil, err
+	}
+	if pool.standbyMember && pool.diskSpill != nil {
+		return nil, ErrStandbyDiskSpill
+	}
+	if err := pool.loadReplay(); err != nil {
+		return nil, err
+	}
+	if pool.maxLeakedWorkers == 0 {
+		pool.maxLeakedWorkers = pool.totalWorkers
+	}
+	if pool.adaptiveConcurrency != nil {
+		pool.adaptiveConcurrency.maxLimit = float64(max(pool.totalWorkers, adaptiveMinLimit))
+	}
+	if pool.workerRate != nil {
+		pool.workerLimiters = make(map[int]*rateLimiter, pool.totalWorkers)
+		for workerId := 1; workerId <= pool.totalWorkers; workerId++ {
+			limiter := *pool.workerRate
+			pool.workerLimiters[workerId] = &limiter
+		}
+	}
+	if pool.objectPool != nil {
+		pool.objectPool.idle = make(chan any, pool.totalWorkers)
+	}
+	if pool.retryBudget != nil {
+		pool.retryBudget.clock = pool.clock
+	}
+	checkInit := (pool.failFastInit || pool.minHealthyWorkers > 0) && !pool.synchronous
+	pool.assignCapabilities()
+	pool.taskAvailable = sync.NewCond(&pool.mutex)
+	pool.batchArrived = sync.NewCond(&pool.mutex)
+	pool.spaceAvailable = sync.NewCond(&pool.mutex)
+	pool.poolContext, pool.cancelPoolContext = context.WithCancel(context.Background())
+	pool.startResultSinks()
+	pool.startCallbackDispatcher()
+	pool.startMemorySampler()
+	pool.registerOTelMetrics()
+	pool.startCoalescer()
+
+	pool.activeWorkers = pool.totalWorkers
+	if !pool.synchronous {
+		for workerId := 1; workerId <= pool.totalWorkers; workerId++ {
+			pool.workersWaitGroup.Add(1)
+			pool.spawn(func() { pool.worker(workerId) }, pool.workersWaitGroup.Done)
+		}
+	}
+	if checkInit {
+		if err := pool.checkInit(); err != nil {
+			return nil, err
+		}
+	}
+	//! Registered last, so that a pool New gives up on is never visible to PoolByName, and
+	//! under the mutex, so that nothing submitted through the registry runs before the
+	//! spilled tasks, which are recovered only now so that no failed New runs or deletes them.
+	pool.mutex.Lock()
+	err := pool.registerName()
+	if err == nil && pool.diskSpill != nil {
+		pool.recoverSpilled()
+		pool.refillFromSpill()
+		pool.taskAvailable.Broadcast()
+	}
+	pool.mutex.Unlock()
+	if err != nil {
+		pool.closeAndWait()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// ! Submit queues a task and returns a handle that can be used to wait for it and read its error.
+// ! It blocks while the queue is full, or while no rate-limit token is available (see WithRateLimit),
+// ! and returns ErrPoolClosed once the pool has been closed. Refusals are *RejectionError values.
+func (pool *Pool) Submit(work Task) (*TaskHandle, error) {
+	return pool.enqueue(&task{work: work, run: runTask(work)})
+}
+
+// ! SubmitWait submits run and blocks until it has run, returning its error. It goes through
+// ! the same admission as Submit, so it waits for queue space and returns submission errors,
+// ! such as ErrPoolClosed, without running anything. If the task is dropped before it runs,
+// ! it returns ErrTaskCancelled, wrapped together with ErrPoolClosed when Shutdown or Close
+// ! gave up on it.
+func (pool *Pool) SubmitWait(run func() error) error {
+	handle, err := pool.Submit(TaskFunc(func(context.Context) error { return run() }))
+	if err != nil {
+		return err
+	}
+	<-handle.done
+	return pool.waitError(handle)
+}
+
+// ! SubmitFunc is the closure convenience form of Submit for tasks that need neither a context nor an error.
+func (pool *Pool) SubmitFunc(run func()) (*TaskHandle, error) {
+	return pool.enqueue(&task{run: runFunc(run)})
+}
+
+// ! SubmitIndexed is SubmitFunc for tasks submitted in a loop: index is evaluated at the call
+// ! and handed to run, so every task sees its own value. It guards against the classic capture
+// ! bug, where a closure reads a loop variable that is shared by every iteration, as with any
+// ! loop before Go 1.22 or one whose variable is declared outside it, and all tasks end up
+// ! seeing its final value. Write pool.SubmitIndexed(i, func(i int) { process(items[i]) })
+// ! rather than pool.SubmitFunc(func() { process(items[i]) }).
+func (pool *Pool) SubmitIndexed(index int, run func(index int)) (*TaskHandle, error) {
+	return pool.enqueue(&task{run: runFunc(func() { run(index) })})
+}
+
+// ! SubmitAndForget runs a task whose outcome nobody will look at. It is fully accounted for,
+// ! in Stats, by Wait and by Shutdown, which wait for it like any other, but no handle is
+// ! returned and no Result is published to the result sinks, so nothing is left waiting to be
+// ! read. The error only reports a refused submission; a panic is still handled by the pool's
+// ! panic policy.
+func (pool *Pool) SubmitAndForget(run func()) error {
+	_, err := pool.enqueue(&task{run: runFunc(run), forgotten: true})
+	return err
+}
+
+// ! SubmitWithScratch is like SubmitFunc, but the task receives the scratch space of the worker
+// ! that runs it. The scratch space is only valid until the task returns.
+func (pool *Pool) SubmitWithScratch(run func(scratch *WorkerScratch)) (*TaskHandle, error) {
+	return pool.enqueue(&task{run: func(_ context.Context, scratch *WorkerScratch) error {
+		run(scratch)
+		return nil
+	}})
+}
+
+// ! SubmitWithCancel is like SubmitFunc, but onCancel is called instead of run if the task
+// ! is dropped from the queue by CancelQueued before a worker picks it up.
+func (pool *Pool) SubmitWithCancel(run func(), onCancel func()) (*TaskHandle, error) {
+	return pool.enqueue(&task{run: runFunc(run), onCancel: onCancel})
+}
+
+// ! SubmitWithPriority is like Submit, but tasks with a higher priority are dispatched before
+// ! tasks with a lower one. Tasks submitted without a priority run at priority 0.
+// ! See WithPriorityAntiStarvation for how plain tasks are kept from starving.
+func (pool *Pool) SubmitWithPriority(priority int, work Task) (*TaskHandle, error) {
+	return pool.enqueue(&task{work: work, run: runTask(work), priority: priority, prioritized: true})
+}
+
+// ! SubmitTagged is like Submit, but attaches labels to the task, such as user_id or class,
+// ! which show up wherever the pool describes its tasks. The map is copied.
+func (pool *Pool) SubmitTagged(tags map[string]string, work Task) (*TaskHandle, error) {
+	return pool.enqueue(&task{work: work, run: runTask(work), tags: copyTags(tags)})
+}
+
+// ! SubmitGuarded is like SubmitFunc, but guard is checked when a worker picks the task up
+// ! and the task is skipped if guard returns false. Use it for work that may have become
+// ! stale while it was queued, such as re-rendering a frame that has since been superseded.
+func (pool *Pool) SubmitGuarded(guard func() bool, run func()) (*TaskHandle, error) {
+	return pool.enqueue(&task{run: runFunc(run), guard: guard})
+}
+
+// ! enqueue assigns the task an id and a handle, waits for queue space and hands it to the workers.
+func (pool *Pool) enqueue(newTask *task) (*TaskHandle, error) {
+	return pool.enqueueContext(context.Background(), newTask)
+}
+
+// ! enqueueContext is enqueue with a deadline: it gives up with ctx.Err() if ctx ends while
+// ! the task is still waiting for queue space or a rate-limit token.
+func (pool *Pool) enqueueContext(ctx context.Context, newTask *task) (*TaskHandle, error) {
+	if err := pool.consultController(newTask); err != nil {
+		return nil, err
+	}
+	if err := pool.checkCircuit(); err != nil {
+		return nil, err
+	}
+	if err := pool.checkQuarantine(newTask); err != nil {
+		return nil, err
+	}
+	newTask.submitStack = pool.latencyProfile.submitStack()
+	if pool.synchronous {
+		return pool.runInline(newTask)
+	}
+	handle, err := pool.admit(ctx, newTask)
+	if err == nil {
+		pool.dropReplaced(newTask)
+		pool.announceEnqueued(newTask)
+	}
+	return handle, err
+}
+
+// ! admit applies the admission policy to a task and queues or spills it.
+func (pool *Pool) admit(ctx context.Context, newTask *task) (*TaskHandle, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if err := pool.waitForAdmission(ctx, newTask); err != nil {
+		return nil, err
+	}
+	if pool.overflowing() {
+		return nil, pool.reject(RejectedBucketOverflow, ErrBucketOverflow)
+	}
+	if pool.cpuBudget.exhausted(pool.clock.Now()) {
+		return nil, pool.reject(RejectedCPUBudget, ErrCPUBudgetExceeded)
+	}
+	if err := pool.memoryLimitError(); err != nil {
+		return nil, err
+	}
+	if pool.shouldShed(newTask.priority) {
+		pool.counters.shed.Add(1)
+		return nil, pool.reject(RejectedShed, ErrTaskShed)
+	}
+
+	if pool.holdExclusive(newTask) {
+		pool.register(newTask)
+		return newTask.handle, nil
+	}
+	if pool.holdForDrain(newTask) {
+		pool.register(newTask)
+		pool.takeSlot(newTask)
+		return newTask.handle, nil
+	}
+	if len(newTask.dependencies) > 0 {
+		pool.register(newTask)
+		pool.holdForDependencies(newTask)
+		return newTask.handle, nil
+	}
+	if pool.canSpill(newTask) && (pool.queue.len() >= pool.queueSize || pool.diskSpill.len() > 0) {
+		//! Once anything is on disk, later tasks follow it there so that order is preserved.
+		if err := pool.diskSpill.write(newTask); err != nil {
+			return nil, err
+		}
+		newTask.enqueuedAt = pool.clock.Now()
+		pool.register(newTask)
+		return newTask.handle, nil
+	}
+	pool.register(newTask)
+	pool.push(newTask)
+	pool.takeSlot(newTask)
+	return newTask.handle, nil
+}
+
+// ! register assigns an accepted task its id and handle and counts it as outstanding.
+// ! The pool mutex must be held.
+func (pool *Pool) register(newTask *task) {
+	pool.lastTaskId++
+	newTask.id = pool.lastTaskId
+	if newTask.handle == nil {
+		newTask.handle = newTaskHandle(pool.lastTaskId)
+	} else {
+		newTask.handle.id.Store(int64(pool.lastTaskId)) //! Handed over by DrainTo, see adoptWaiting.
+	}
+	pool.statuses.set(newTask.id, TaskQueued)
+	newTask.handle.pool = pool
+	if newTask.identity != nil {
+		newTask.handle.identity = newTask.identity //! A resubmission, see taskIdentity.
+	}
+	if newTask.reportsProgress {
+		pool.progressTasks[newTask.id] = newTask.handle
+	}
+	pool.outstanding++
+	pool.counters.submitted.Add(1)
+	if newTask.handle.group == nil { //! A task handed over stays in its first pool's group.
+		pool.joinGroup(newTask)
+	}
+	newTask.handle.exclusive = newTask.exclusive
+	newTask.handle.callback = newTask.callback
+}
+
+// ! push puts a registered task on the queue and wakes a worker. The pool mutex must be held.
+func (pool *Pool) push(newTask *task) {
+	if newTask.enqueuedAt.IsZero() { //! Spilled tasks keep the time they were accepted.
+		newTask.enqueuedAt = pool.clock.Now()
+	}
+	pool.queue.push(newTask)
+	pool.pushes.Add(1)
+	if newTask.batchKey != nil && pool.batchCollectors > 0 {
+		pool.batchArrived.Broadcast()
+	}
+	pool.updateOverload()
+	storeMax(&pool.counters.peakQueueDepth, int64(pool.queue.len()))
+	pool.signalTaskAvailable(newTask)
+}
+
+// ! Wait blocks until every task submitted so far has completed. The pool stays open, and
+// ! calling Wait again, from any number of goroutines, is safe: with nothing left to wait
+// ! for it returns immediately.
+func (pool *Pool) Wait() {
+	pool.waitFor(context.Background(), pool.isIdle)
+	pool.rethrowPanic()
+}
+
+// ! Close stops accepting new tasks, lets the workers finish everything already queued,
+// ! and blocks until all of them have exited. Delayed tasks that are not due yet, and tasks
+// ! left over because no remaining worker could run them, are cancelled. Close is idempotent:
+// ! later calls, including concurrent ones, wait for the first to finish and then return.
+func (pool *Pool) Close() {
+	pool.closeAndWait()
+	pool.rethrowPanic()
+}
+
+// ! closeAndWait is Close without rethrowing a panic, so it can run on any goroutine.
+func (pool *Pool) closeAndWait() {
+	pool.closeOnce.Do(func() {
+		pool.mutex.Lock()
+		pool.closed = true
+		pool.taskAvailable.Broadcast()
+		pool.spaceAvailable.Broadcast()
+		pool.batchArrived.Broadcast()
+		pool.mutex.Unlock()
+		pool.stopMemorySampler()
+		pool.cancelScheduled()
+		pool.cancelHeld()
+		pool.cancelDrainHeld()
+		pool.cancelExclusiveWaiting()
+
+		pool.workersWaitGroup.Wait()
+		pool.mutex.Lock()
+		leftover := pool.queue.removeAll()
+		pool.mutex.Unlock()
+		for _, leftoverTask := range leftover {
+			pool.cancelTask(leftoverTask)
+		}
+		pool.callbacks.stop()
+		pool.closeResultSinks()
+		for _, hook := range pool.shutdownHooks {
+			hook()
+		}
+		pool.cancelPoolContext()
+		pool.deregisterName()
+	})
+}
+
+// ! finishTask accounts for a task that has run, been skipped or been cancelled.
+func (pool *Pool) finishTask() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pool.outstanding--
+	pool.broadcastStateChange()
+}
+
+// ! worker pulls tasks off the queue until the pool is closed and the queue is empty,
+// ! or until its slot is quarantined or recycled.
+func (pool *Pool) worker(workerId int) {
+	pool.labelWorker(workerId)
+	if pool.pinThreads {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+	if !pool.initWorker(workerId) {
+		return
+	}
+	defer pool.tearDown(workerId)
+	defer pool.setReady(-1)
+	slot := newWorkerSlot(workerId)
+	slot.capabilities = pool.workerCapabilities[workerId]
+	for {
+		nextTask, ok := pool.dequeue(slot)
+		if !ok {
+			return
+		}
+		pool.recordDispatch(slot, nextTask)
+		pool.awaitDispatchSlot()
+		pool.awaitWorkerToken(workerId)
+		pool.announceDequeued(nextTask)
+		pool.busyWorkers.Add(1)
+		pool.execute(nextTask, slot)
+		pool.leaveBand(nextTask)
+		pool.leaveMemoryGate()
+		pool.busyWorkers.Add(-1)
+		if pool.quarantineIfNeeded(slot) || pool.recycleIfNeeded(slot) {
+			return
+		}
+	}
+}
+
+// ! dequeue blocks until a task the slot can run is available. It reports false once the pool
+// ! is closed and drained of such tasks.
+func (pool *Pool) dequeue(slot *workerSlot) (*task, bool) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	var nextTask *task
+	spun := false
+	for {
+		pool.refillFromSpill()
+		if pool.memoryThrottled() {
+			pool.taskAvailable.Wait()
+			continue
+		}
+		if pool.replaying() {
+			if nextTask = pool.takeReplayed(slot); nextTask != nil && pool.enterBand(nextTask) {
+				break
+			}
+			if nextTask != nil {
+				pool.queue.push(nextTask) //! Lost its turn to the band rule; retried as the same step.
+				pool.dispatchReplay.next--
+			}
+			pool.taskAvailable.Wait()
+			continue
+		}
+		if nextTask = pool.queue.pop(slot.capabilities); nextTask != nil {
+			if pool.enterBand(nextTask) {
+				break
+			}
+			pool.queue.push(nextTask) //! Its band may not take another worker; wait for one to finish.
+			pool.taskAvailable.Wait()
+			continue
+		}
+		if pool.closed {
+			return nil, false
+		}
+		if !spun && pool.spinBeforePark > 0 {
+			spun = true
+			pool.spinForTask()
+			continue
+		}
+		pool.taskAvailable.Wait()
+	}
+	pool.refillFromSpill()
+	pool.enterMemoryGate()
+	pool.numberDispatch(nextTask)
+	pool.leaveSlot(nextTask)
+	nextTask.shed = pool.shouldShed(nextTask.priority)
+	pool.signalSpace()
+	if pool.depthWaiters > 0 {
+		pool.broadcastStateChange() //! Wakes WaitBelow.
+	}
+	return nextTask, true
+}
+
+// ! expired reports whether a task has outlived the queue TTL, see WithQueueTTL. Tasks that
+// ! never went through the queue, as in synchronous pools, do not expire.
+func (pool *Pool) expired(queuedTask *task) bool {
+	if pool.queueTTL <= 0 || queuedTask.enqueuedAt.IsZero() {
+		return false
+	}
+	return pool.clock.Now().Sub(queuedTask.enqueuedAt) > pool.queueTTL
+}
+
+// ! execute runs a single task, retrying it while attempts and the retry budget allow,
+// ! then records its final error on the handle and marks the handle as done.
+func (pool *Pool) execute(currentTask *task, slot *workerSlot) {
+	defer pool.finishTask()
+	defer pool.forgetProgress(currentTask)
+	if currentTask.shed {
+		pool.counters.shed.Add(1)
+		currentTask.handle.finish(outcomeShed, ErrTaskShed)
+		pool.forgetSpilled(currentTask)
+		return
+	}
+	if pool.expired(currentTask) {
+		pool.counters.expired.Add(1)
+		currentTask.handle.finish(outcomeExpired, ErrTaskExpired)
+		pool.forgetSpilled(currentTask)
+		return
+	}
+	if pool.missedDeadline(currentTask) {
+		pool.counters.expired.Add(1)
+		currentTask.handle.finish(outcomeExpired, ErrDeadlineExceeded)
+		pool.forgetSpilled(currentTask)
+		return
+	}
+	if currentTask.guard != nil && !currentTask.guard() {
+		pool.counters.skipped.Add(1)
+		currentTask.handle.finish(outcomeSkipped, nil)
+		return
+	}
+	if pool.dispatchCancelled(currentTask) || pool.refuseExhausted(currentTask, slot) || pool.refuseQuarantined(currentTask, slot) {
+		return
+	}
+	if duplicate, err := pool.alreadyProcessed(currentTask); duplicate || err != nil {
+		pool.finishUnrun(currentTask, slot, err)
+		return
+	}
+	budgetLease := pool.leaseBudget(currentTask)
+	defer budgetLease.release()
+	pool.adaptiveConcurrency.acquire()
+	startedAt := pool.clock.Now()
+	pool.markRunning(currentTask, startedAt)
+	defer pool.markRunning(currentTask, time.Time{})
+	taskContext := pool.poolContext
+	if budgetLease != nil {
+		taskContext = context.WithValue(taskContext, budgetLeaseKey{}, budgetLease)
+	}
+	var lease *objectLease
+	if pool.objectPool != nil {
+		lease = &objectLease{objects: pool.objectPool}
+		taskContext = context.WithValue(taskContext, objectLeaseKey{}, lease)
+	}
+	taskContext, untrack := pool.trackRunning(taskContext, currentTask)
+	defer untrack()
+	if pool.cooperativeYield != nil {
+		taskContext = pool.cooperativeYield.enter(taskContext, currentTask.priority)
+		defer pool.cooperativeYield.leave(currentTask.priority)
+	}
+	var result attemptResult
+	interrupted, budgetSpent := false, false
+	attempts := 0
+	for attempt := 1; ; attempt++ {
+		attempts = attempt
+		result = pool.runAttemptWithTimeout(taskContext, currentTask, slot.scratch)
+		currentTask.handle.identity.attempts.Add(1)
+		if result.panicked {
+			slot.recordPanic(pool.clock.Now())
+		}
+		if result.err == nil || result.leaked || taskContext.Err() != nil {
+			break
+		}
+		var retry bool
+		if retry, budgetSpent = pool.shouldRetry(currentTask, attempt, result.err); !retry {
+			break
+		}
+		if interrupted = !pool.backoff(taskContext, attempt); interrupted {
+			break
+		}
+		budgetLease.reacquire() //! The task may have released its units early, see SubmitWithRelease.
+		pool.counters.retries.Add(1)
+	}
+	if result.leaked {
+		//! The abandoned task may still be using the worker's scratch space and its pooled object.
+		slot.scratch = newWorkerScratch(slot.id)
+	} else if lease != nil {
+		lease.release()
+	}
+	if interrupted {
+		//! Shut down or cancelled while waiting to retry: the task was never processed to the end.
+		pool.adaptiveConcurrency.abandon()
+		pool.counters.cancelled.Add(1)
+		pool.recordUnprocessed(currentTask)
+		currentTask.handle.finish(outcomeCancelled, ErrTaskCancelled)
+		pool.forgetSpilled(currentTask)
+		return
+	}
+	err := result.err
+	if err != nil {
+		pool.counters.failed.Add(1)
+		if budgetSpent || pool.lifetimeExhausted(currentTask) {
+			pool.sendToDeadLetter(currentTask, err)
+		}
+	} else {
+		pool.counters.completed.Add(1)
+		pool.markProcessed(currentTask)
+	}
+	finishedAt := pool.clock.Now()
+	pool.adaptiveConcurrency.done(finishedAt.Sub(startedAt), err != nil || result.leaked)
+	if class, ok := currentTask.tags[ClassTag]; ok {
+		pool.classStats.record(class, finishedAt.Sub(startedAt), err)
+	}
+	pool.checkLatency(currentTask, startedAt, finishedAt)
+	pool.slowTasks.record(currentTask, finishedAt.Sub(startedAt))
+	pool.latencyProfile.record(currentTask, finishedAt.Sub(startedAt), finishedAt)
+	pool.counters.cpuNanos.Add(int64(finishedAt.Sub(startedAt)))
+	pool.cpuBudget.record(finishedAt, finishedAt.Sub(startedAt))
+	pool.drainRate.record(finishedAt)
+	pool.keyBreaker.record(breakerKey(currentTask), finishedAt, err != nil)
+	pool.circuitBreaker.record(circuitKey, finishedAt, err != nil)
+	pool.otelMetrics.record(taskContext, finishedAt.Sub(startedAt).Seconds(), err)
+	currentTask.handle.finish(outcomeRan, err)
+	pool.forgetSpilled(currentTask)
+	if currentTask.forgotten {
+		pool.orderedWindow.skip(currentTask.id, pool.deliverResult)
+		return
+	}
+	finished := Result{
+		TaskId:       currentTask.id,
+		Err:          err,
+		WorkerId:     slot.id,
+		Attempts:     attempts,
+		EnqueuedAt:   currentTask.enqueuedAt,
+		StartedAt:    startedAt,
+		FinishedAt:   finishedAt,
+		ExecDuration: finishedAt.Sub(startedAt),
+		TraceId:      currentTask.traceId,
+		SpanId:       currentTask.spanId,
+		Timeout:      result.timeout,
+		identity:     currentTask.handle.identity,
+	}
+	if pool.resultPayloads {
+		finished.Task = currentTask.work
+	}
+	if !finished.EnqueuedAt.IsZero() {
+		finished.QueueWait = startedAt.Sub(finished.EnqueuedAt)
+	}
+	pool.publishResult(finished)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ! newTestPool creates a pool for a test and closes it when the test ends.
+func newTestPool(t *testing.T, options ...Option) *Pool {
+	t.Helper()
+	pool, err := New(options...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// ! returnsWithin fails the test if run has not returned within timeout.
+func returnsWithin(t *testing.T, timeout time.Duration, what string, run func()) {
+	t.Helper()
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		run()
+	}()
+	select {
+	case <-finished:
+	case <-time.After(timeout):
+		t.Fatalf("%s did not return within %v", what, timeout)
+	}
+}
+
+func TestWaitTwiceReturnsImmediately(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	for range 5 {
+		pool.SubmitFunc(func() { time.Sleep(time.Millisecond) })
+	}
+	pool.Wait()
+	returnsWithin(t, 100*time.Millisecond, "second Wait", pool.Wait)
+
+	var waiters sync.WaitGroup
+	for range 10 {
+		waiters.Go(pool.Wait)
+	}
+	returnsWithin(t, 100*time.Millisecond, "concurrent Waits", waiters.Wait)
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	ran := 0
+	var mutex sync.Mutex
+	for range 5 {
+		pool.SubmitFunc(func() {
+			time.Sleep(5 * time.Millisecond)
+			mutex.Lock()
+			ran++
+			mutex.Unlock()
+		})
+	}
+
+	var closers sync.WaitGroup
+	for range 5 {
+		closers.Go(func() {
+			pool.Close()
+			//! Every call, not just the first, returns only once the queue has drained.
+			mutex.Lock()
+			defer mutex.Unlock()
+			if ran != 5 {
+				t.Errorf("Close returned after %d of 5 tasks", ran)
+			}
+		})
+	}
+	returnsWithin(t, time.Second, "concurrent Closes", closers.Wait)
+	returnsWithin(t, 100*time.Millisecond, "Close after Close", pool.Close)
+	returnsWithin(t, 100*time.Millisecond, "Wait after Close", pool.Wait)
+}
+
+func TestSubmitAfterCloseReturnsErrPoolClosed(t *testing.T) {
+	pool := newTestPool(t)
+	pool.Close()
+
+	submissions := map[string]func() (*TaskHandle, error){
+		"Submit": func() (*TaskHandle, error) {
+			return pool.Submit(TaskFunc(func(context.Context) error { return nil }))
+		},
+		"SubmitFunc": func() (*TaskHandle, error) { return pool.SubmitFunc(func() {}) },
+		"TrySubmit": func() (*TaskHandle, error) {
+			return pool.TrySubmit(TaskFunc(func(context.Context) error { return nil }))
+		},
+		"SubmitWithPriority": func() (*TaskHandle, error) {
+			return pool.SubmitWithPriority(1, TaskFunc(func(context.Context) error { return nil }))
+		},
+	}
+	for name, submit := range submissions {
+		handle, err := submit()
+		if !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("%s after Close: got error %v, want ErrPoolClosed", name, err)
+		}
+		var rejection *RejectionError
+		if !errors.As(err, &rejection) || rejection.Reason != RejectedPoolClosed {
+			t.Errorf("%s after Close: got %v, want a RejectionError for a closed pool", name, err)
+		}
+		if handle != nil {
+			t.Errorf("%s after Close returned a handle", name)
+		}
+	}
+	if err := pool.SubmitWait(func() error { return nil }); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("SubmitWait after Close: got error %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestSubmitWaitReportsDroppedTasks(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := make(chan struct{})
+	pool.SubmitFunc(func() { <-release })
+
+	waited := make(chan error, 1)
+	go func() { waited <- pool.SubmitWait(func() error { return nil }) }()
+	time.Sleep(10 * time.Millisecond)
+	pool.CancelQueued()
+	if err := <-waited; !errors.Is(err, ErrTaskCancelled) || errors.Is(err, ErrPoolClosed) {
+		t.Errorf("SubmitWait for a task CancelQueued dropped returned %v", err)
+	}
+
+	go func() { waited <- pool.SubmitWait(func() error { return nil }) }()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	pool.Shutdown(ctx)
+	if err := <-waited; !errors.Is(err, ErrTaskCancelled) || !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("SubmitWait for a task Shutdown gave up on returned %v", err)
+	}
+	close(release)
+}
+
+func TestSubmitIndexedPassesEachIndex(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	ran := make([]atomic.Int32, 10)
+	var index int //! Shared by every iteration, as loop variables were before Go 1.22.
+	for index = 0; index < len(ran); index++ {
+		pool.SubmitIndexed(index, func(index int) { ran[index].Add(1) })
+	}
+	pool.Wait()
+	for index := range ran {
+		if count := ran[index].Load(); count != 1 {
+			t.Errorf("index %d ran %d times, want once", index, count)
+		}
+	}
+}
+
+func TestSubmitAndForgetIsTrackedWithoutResult(t *testing.T) {
+	var mutex sync.Mutex
+	var published []int
+	pool := newTestPool(t, WithWorkers(2), WithResultBatcher(1, time.Millisecond, func(batch []Result) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, result := range batch {
+			published = append(published, result.TaskId)
+		}
+	}))
+	var ran atomic.Int32
+	for range 5 {
+		if err := pool.SubmitAndForget(func() { time.Sleep(time.Millisecond); ran.Add(1) }); err != nil {
+			t.Fatalf("SubmitAndForget: %v", err)
+		}
+	}
+	handle, err := pool.SubmitFunc(func() {})
+	if err != nil {
+		t.Fatalf("SubmitFunc: %v", err)
+	}
+	pool.Wait()
+	if got := ran.Load(); got != 5 {
+		t.Fatalf("Wait returned with %d of 5 forgotten tasks run", got)
+	}
+	if stats := pool.Stats(); stats.Submitted != 6 || stats.Completed != 6 {
+		t.Fatalf("got %d submitted and %d completed, want 6 of each", stats.Submitted, stats.Completed)
+	}
+	pool.Close()
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(published) != 1 || published[0] != handle.Id() {
+		t.Fatalf("published results of tasks %v, want only task %d", published, handle.Id())
+	}
+}
+
+func TestNewRefusesAPoolWithoutWorkers(t *testing.T) {
+	for _, workers := range []int{0, -3} {
+		pool, err := New(WithWorkers(workers))
+		if !errors.Is(err, ErrNoWorkers) {
+			t.Errorf("New with %d workers returned %v, want ErrNoWorkers", workers, err)
+		}
+		if pool != nil {
+			t.Errorf("New with %d workers returned a pool along with its error", workers)
+		}
+	}
+}
+
+func TestSynchronousPoolNeedsNoWorkers(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(0), WithSynchronous())
+	ran := false
+	handle, err := pool.Submit(TaskFunc(func(context.Context) error {
+		ran = true
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("a synchronous pool with zero workers refused a task: %v", err)
+	}
+	if !ran || !finished(handle) {
+		t.Fatal("the synchronous pool did not run the task on submit")
+	}
+}
+
+func TestNewRefusesMoreWorkersThanTheSanityLimit(t *testing.T) {
+	if _, err := New(WithWorkers(defaultMaxWorkerSanity + 1)); !errors.Is(err, ErrTooManyWorkers) {
+		t.Errorf("New above the default limit returned %v, want ErrTooManyWorkers", err)
+	}
+	if _, err := New(WithWorkers(5), WithMaxWorkerSanityLimit(4)); !errors.Is(err, ErrTooManyWorkers) {
+		t.Errorf("New above a lowered limit returned %v, want ErrTooManyWorkers", err)
+	}
+	pool, err := New(WithWorkers(5), WithMaxWorkerSanityLimit(5))
+	if err != nil {
+		t.Fatalf("New at the limit: %v", err)
+	}
+	pool.Close()
+}
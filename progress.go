@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"math"
+)
+
+// ! SubmitWithProgress is like Submit for long tasks that report their own progress: run
+// ! receives a report function it may call, from any goroutine, with a percentage between
+// ! 0 and 100. The latest value can be read through TaskProgress or the handle's Progress.
+func (pool *Pool) SubmitWithProgress(run func(ctx context.Context, report func(percent float64)) error) (*TaskHandle, error) {
+	progressTask := &task{reportsProgress: true}
+	progressTask.run = func(ctx context.Context, _ *WorkerScratch) error {
+		return run(ctx, progressTask.handle.reportProgress)
+	}
+	return pool.enqueue(progressTask)
+}
+
+// ! TaskProgress returns the percentage last reported by the task with the given id, which
+// ! must have been submitted with SubmitWithProgress and not have finished yet. It returns -1
+// ! for any other id. Once the task is done, read its final progress from its handle instead.
+func (pool *Pool) TaskProgress(taskId int) float64 {
+	pool.mutex.Lock()
+	handle, ok := pool.progressTasks[taskId]
+	pool.mutex.Unlock()
+	if !ok {
+		return -1
+	}
+	return handle.Progress()
+}
+
+// ! forgetProgress stops tracking a finished task for TaskProgress.
+func (pool *Pool) forgetProgress(finishedTask *task) {
+	if !finishedTask.reportsProgress {
+		return
+	}
+	pool.mutex.Lock()
+	delete(pool.progressTasks, finishedTask.id)
+	pool.mutex.Unlock()
+}
+
+// ! Progress returns the percentage the task last reported, see SubmitWithProgress, or 0 if
+// ! it has reported nothing yet.
+func (handle *TaskHandle) Progress() float64 {
+	return math.Float64frombits(handle.progress.Load())
+}
+
+// ! reportProgress stores a progress report, clamped to the range 0 to 100.
+func (handle *TaskHandle) reportProgress(percent float64) {
+	handle.progress.Store(math.Float64bits(min(max(percent, 0), 100)))
+}
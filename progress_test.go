@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubmitWithProgressReportsTheLatestPercentage(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	reported, next := make(chan struct{}), make(chan struct{})
+	handle, err := pool.SubmitWithProgress(func(ctx context.Context, report func(percent float64)) error {
+		for _, percent := range []float64{-5, 40, 250} {
+			report(percent)
+			reported <- struct{}{}
+			<-next
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []float64{0, 40, 100} {
+		<-reported
+		if got := pool.TaskProgress(handle.Id()); got != want {
+			t.Errorf("TaskProgress = %v, want %v clamped", got, want)
+		}
+		if got := handle.Progress(); got != want {
+			t.Errorf("Progress = %v, want %v", got, want)
+		}
+		next <- struct{}{}
+	}
+	returnsWithin(t, time.Second, "the progress task", func() { <-handle.Done() })
+
+	returnsWithin(t, time.Second, "TaskProgress to forget the finished task", func() {
+		for pool.TaskProgress(handle.Id()) != -1 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	if got := handle.Progress(); got != 100 {
+		t.Errorf("the handle's final Progress = %v, want 100", got)
+	}
+	plain, _ := pool.Submit(noopTask)
+	if got := pool.TaskProgress(plain.Id()); got != -1 {
+		t.Errorf("TaskProgress of a task without progress = %v, want -1", got)
+	}
+}
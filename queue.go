@@ -0,0 +1,238 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// ! taskQueue holds the tasks waiting for a worker. Tasks are dispatched by priority, highest
+// ! first, and in submission order among equal priorities. Tasks submitted without a priority
+// ! run at priority 0 and are additionally kept in arrival order, so that the anti-starvation
+// ! policy can reach the oldest of them directly. Tasks that require a worker capability wait
+// ! in a heap of their own per capability, so that only capable workers see them, and take
+// ! no part in anti-starvation. Under WithLIFO equal priorities run newest first instead.
+// ! The pool mutex guards the queue.
+type taskQueue struct {
+	byPriority    priorityHeap
+	unprioritized []*task                  //! Tasks submitted without a priority, oldest first. May contain tasks already dispatched.
+	restricted    map[string]*priorityHeap //! Tasks requiring a capability, by capability, see SubmitRequiring.
+	restrictedLen int
+
+	antiStarvationInterval int //! Every this many dispatches serve the oldest unprioritized task; zero disables it.
+	dispatches             int
+	strictPriority         bool //! Set once Shutdown starts, so anti-starvation no longer skips ahead.
+	lifo                   bool //! Break priority ties newest first, see WithLIFO.
+
+	scheduler Scheduler             //! Custom order of tasks without a capability, see WithScheduler.
+	scheduled map[*task]*QueuedTask //! Tasks queued in the scheduler and not withdrawn since.
+}
+
+// ! len returns the number of queued tasks.
+func (queue *taskQueue) len() int {
+	return len(queue.byPriority) + queue.restrictedLen + len(queue.scheduled)
+}
+
+// ! heapOf returns the heap a task belongs in, creating it for a new capability.
+func (queue *taskQueue) heapOf(queuedTask *task) *priorityHeap {
+	if queuedTask.capability == "" {
+		return &queue.byPriority
+	}
+	if queue.restricted == nil {
+		queue.restricted = make(map[string]*priorityHeap)
+	}
+	tasks, ok := queue.restricted[queuedTask.capability]
+	if !ok {
+		tasks = &priorityHeap{}
+		queue.restricted[queuedTask.capability] = tasks
+	}
+	return tasks
+}
+
+// ! push adds a task to the queue.
+func (queue *taskQueue) push(newTask *task) {
+	if queue.scheduler != nil && newTask.capability == "" {
+		queue.pushScheduled(newTask)
+		return
+	}
+	newTask.order = newTask.id
+	if queue.lifo {
+		newTask.order = -newTask.id
+	}
+	heap.Push(queue.heapOf(newTask), newTask)
+	if newTask.capability != "" {
+		queue.restrictedLen++
+	} else if !newTask.prioritized {
+		queue.unprioritized = append(queue.unprioritized, newTask)
+	}
+}
+
+// ! pop removes and returns the task that a worker with the given capabilities should run
+// ! next, or nil if nothing queued is for it.
+func (queue *taskQueue) pop(capabilities []string) *task {
+	if queue.scheduler != nil {
+		if nextTask := queue.popScheduled(); nextTask != nil {
+			return nextTask
+		}
+	}
+	var best *priorityHeap
+	if len(queue.byPriority) > 0 {
+		best = &queue.byPriority
+	}
+	if queue.restrictedLen > 0 {
+		for _, capability := range capabilities {
+			if tasks := queue.restricted[capability]; tasks != nil && len(*tasks) > 0 {
+				if best == nil || less((*tasks)[0], (*best)[0]) {
+					best = tasks
+				}
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	if best != &queue.byPriority {
+		queue.restrictedLen--
+		nextTask := heap.Pop(best).(*task)
+		queue.forget(nextTask)
+		return nextTask
+	}
+
+	queue.dispatches++
+	if queue.antiStarvationInterval > 0 && !queue.strictPriority && queue.dispatches%queue.antiStarvationInterval == 0 {
+		if oldest := queue.oldestUnprioritized(); oldest != nil {
+			heap.Remove(&queue.byPriority, oldest.queueIndex)
+			queue.forget(oldest)
+			return oldest
+		}
+	}
+	nextTask := heap.Pop(&queue.byPriority).(*task)
+	queue.forget(nextTask)
+	return nextTask
+}
+
+// ! raise gives a queued task a higher priority and moves it up accordingly.
+func (queue *taskQueue) raise(queuedTask *task, priority int) {
+	queuedTask.priority = priority
+	if _, ok := queue.scheduled[queuedTask]; ok {
+		return //! The scheduler keeps the priority it was given.
+	}
+	heap.Fix(queue.heapOf(queuedTask), queuedTask.queueIndex)
+}
+
+// ! all returns every queued task, in no particular order, without removing anything.
+func (queue *taskQueue) all() []*task {
+	queued := make([]*task, 0, queue.len())
+	queued = append(queued, queue.byPriority...)
+	for scheduledTask := range queue.scheduled {
+		queued = append(queued, scheduledTask)
+	}
+	for _, tasks := range queue.restricted {
+		queued = append(queued, *tasks...)
+	}
+	return queued
+}
+
+// ! removeAll empties the queue and returns every task that was in it, in dispatch order.
+func (queue *taskQueue) removeAll() []*task {
+	var dispatched []*task
+	if queue.scheduler != nil {
+		for nextTask := queue.popScheduled(); nextTask != nil; nextTask = queue.popScheduled() {
+			dispatched = append(dispatched, nextTask)
+		}
+	}
+	removed := queue.all()
+	for _, removedTask := range removed {
+		removedTask.queueIndex = -1
+	}
+	sort.Slice(removed, func(i, j int) bool { return less(removed[i], removed[j]) })
+	queue.byPriority = nil
+	queue.unprioritized = nil
+	queue.restricted = nil
+	queue.restrictedLen = 0
+	if queue.scheduler != nil {
+		clear(queue.scheduled) //! Anything left there the scheduler failed to return.
+	}
+	return append(dispatched, removed...)
+}
+
+// ! removeMatching removes every queued task for which match returns true and returns them,
+// ! in no particular order.
+func (queue *taskQueue) removeMatching(match func(*task) bool) []*task {
+	var removed []*task
+	for _, queued := range queue.all() {
+		if match(queued) {
+			removed = append(removed, queued)
+		}
+	}
+	for _, removedTask := range removed {
+		if _, ok := queue.scheduled[removedTask]; ok {
+			delete(queue.scheduled, removedTask) //! The scheduler still holds it; popScheduled skips it.
+			queue.forget(removedTask)
+			continue
+		}
+		heap.Remove(queue.heapOf(removedTask), removedTask.queueIndex)
+		if removedTask.capability != "" {
+			queue.restrictedLen--
+		}
+		queue.forget(removedTask)
+	}
+	return removed
+}
+
+// ! oldestUnprioritized returns the longest-waiting task submitted without a priority, if any,
+// ! discarding entries at the front that have already left the queue.
+func (queue *taskQueue) oldestUnprioritized() *task {
+	for len(queue.unprioritized) > 0 {
+		oldest := queue.unprioritized[0]
+		if oldest.queueIndex >= 0 {
+			return oldest
+		}
+		queue.unprioritized[0] = nil
+		queue.unprioritized = queue.unprioritized[1:]
+	}
+	return nil
+}
+
+// ! forget marks a task as no longer queued.
+func (queue *taskQueue) forget(removedTask *task) {
+	removedTask.queueIndex = -1
+	if len(queue.byPriority) == 0 {
+		queue.unprioritized = nil //! Nothing can be left in it, so drop the stale entries at once.
+	}
+}
+
+// ! priorityHeap implements heap.Interface ordered by priority, then by the order the queue
+// ! gave each task on push.
+type priorityHeap []*task
+
+func (tasks priorityHeap) Len() int { return len(tasks) }
+
+func (tasks priorityHeap) Less(i, j int) bool { return less(tasks[i], tasks[j]) }
+
+// ! less reports whether a should be dispatched before b on priority alone.
+func less(a *task, b *task) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.order < b.order
+}
+
+func (tasks priorityHeap) Swap(i, j int) {
+	tasks[i], tasks[j] = tasks[j], tasks[i]
+	tasks[i].queueIndex = i
+	tasks[j].queueIndex = j
+}
+
+func (tasks *priorityHeap) Push(value any) {
+	newTask := value.(*task)
+	newTask.queueIndex = len(*tasks)
+	*tasks = append(*tasks, newTask)
+}
+
+func (tasks *priorityHeap) Pop() any {
+	old := *tasks
+	last := old[len(old)-1]
+	old[len(old)-1] = nil //! Drop the reference so the task can be garbage collected once it has run.
+	*tasks = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! dispatchOrder runs queue on a single worker, which is kept busy until every task is queued,
+// ! and returns the names of the tasks in the order they ran. Names starting with "P" are
+// ! submitted with priority 5, the others as plain tasks. options are added to the pool's.
+func dispatchOrder(t *testing.T, antiStarvation int, queue []string, options ...Option) []string {
+	t.Helper()
+	options = append([]Option{WithWorkers(1), WithQueueSize(len(queue)), WithPriorityAntiStarvation(antiStarvation)}, options...)
+	pool := newTestPool(t, options...)
+	started, release := make(chan struct{}), make(chan struct{})
+	pool.SubmitFunc(func() {
+		close(started)
+		<-release
+	})
+	<-started //! The blocker is dispatch number 1.
+
+	var mutex sync.Mutex
+	var order []string
+	for _, name := range queue {
+		record := TaskFunc(func(context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			order = append(order, name)
+			return nil
+		})
+		var err error
+		if name[0] == 'P' {
+			_, err = pool.SubmitWithPriority(5, record)
+		} else {
+			_, err = pool.Submit(record)
+		}
+		if err != nil {
+			t.Fatalf("submitting %s: %v", name, err)
+		}
+	}
+	close(release)
+	pool.Wait()
+	return order
+}
+
+func TestAntiStarvationInterleaving(t *testing.T) {
+	queued := []string{"P1", "P2", "P3", "P4", "P5", "P6", "F1", "F2", "F3"}
+	//! Dispatches 3, 6 and 9 take the oldest plain task; all others follow priority.
+	want := []string{"P1", "F1", "P2", "P3", "F2", "P4", "P5", "F3", "P6"}
+	if got := dispatchOrder(t, 3, queued); !slices.Equal(got, want) {
+		t.Errorf("with k = 3 got order %v, want %v", got, want)
+	}
+}
+
+func TestAntiStarvationWithoutPlainTasksFollowsPriority(t *testing.T) {
+	queued := []string{"P1", "P2", "P3", "F1"}
+	//! Dispatch 2 takes the only plain task, so dispatch 4 finds none and keeps to priority order.
+	want := []string{"F1", "P1", "P2", "P3"}
+	if got := dispatchOrder(t, 2, queued); !slices.Equal(got, want) {
+		t.Errorf("with k = 2 got order %v, want %v", got, want)
+	}
+}
+
+func TestAntiStarvationEveryDispatch(t *testing.T) {
+	queued := []string{"P1", "F1", "P2", "F2", "F3"}
+	want := []string{"F1", "F2", "F3", "P1", "P2"}
+	if got := dispatchOrder(t, 1, queued); !slices.Equal(got, want) {
+		t.Errorf("with k = 1 got order %v, want %v", got, want)
+	}
+}
+
+func TestPriorityWithoutAntiStarvation(t *testing.T) {
+	queued := []string{"F1", "P1", "F2", "P2"}
+	want := []string{"P1", "P2", "F1", "F2"}
+	if got := dispatchOrder(t, 0, queued); !slices.Equal(got, want) {
+		t.Errorf("without anti-starvation got order %v, want %v", got, want)
+	}
+}
+
+func TestLIFOTakesTheNewestTaskOfEachPriority(t *testing.T) {
+	queued := []string{"F1", "P1", "F2", "P2", "F3"}
+	want := []string{"P2", "P1", "F3", "F2", "F1"}
+	if got := dispatchOrder(t, 0, queued, WithLIFO()); !slices.Equal(got, want) {
+		t.Errorf("LIFO got order %v, want %v", got, want)
+	}
+}
+
+func TestLIFOWithAntiStarvationStillRunsTheOldestPlainTask(t *testing.T) {
+	queued := []string{"P1", "P2", "P3", "P4", "P5", "P6", "F1", "F2", "F3"}
+	//! Dispatches 3, 6 and 9 take the oldest plain task; all others the newest by priority.
+	want := []string{"P6", "F1", "P5", "P4", "F2", "P3", "P2", "F3", "P1"}
+	if got := dispatchOrder(t, 3, queued, WithLIFO()); !slices.Equal(got, want) {
+		t.Errorf("LIFO with k = 3 got order %v, want %v", got, want)
+	}
+}
+
+func TestQueueTTLExpiresTasksThatWaitedTooLong(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithQueueTTL(time.Minute))
+	release := occupy(t, pool)
+	stale, _ := pool.Submit(noopTask)
+	delayed, _ := pool.SubmitAfter(50*time.Second, noopTask)
+	clock.advance(50 * time.Second) //! The delayed task joins the queue now.
+	clock.advance(30 * time.Second)
+	close(release)
+	returnsWithin(t, time.Second, "the queued tasks", func() { pool.Flush(stale, delayed) })
+
+	if !stale.Expired() || !errors.Is(stale.Err(), ErrTaskExpired) {
+		t.Errorf("the task queued for 80s ended with %v, want it expired with ErrTaskExpired", stale.Err())
+	}
+	if delayed.Expired() || delayed.Err() != nil {
+		t.Errorf("the delayed task queued for 30s since its timer fired ended with %v, want it run", delayed.Err())
+	}
+	if expired := pool.Stats().Expired; expired != 1 {
+		t.Errorf("Stats.Expired = %d, want 1", expired)
+	}
+}
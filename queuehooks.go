@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+)
+
+// ! WithOnEnqueue calls hook whenever a task joins the queue, with the task's id and the time it
+// ! joined. Paired with WithOnDequeue it lets a tracer open a span covering just the time a task
+// ! waited, separate from the span of its execution. Tasks spilled to disk count as queued from
+// ! the moment they are accepted, and delayed tasks from when their timer fires. The hook runs on
+// ! the submitting goroutine after the pool's lock is released, so it must be quick but may call
+// ! back into the pool. A worker may already have picked the task up by then, so rely on the
+// ! timestamps rather than on the order in which the two hooks are called.
+func WithOnEnqueue(hook func(taskId int, at time.Time)) Option {
+	return func(pool *Pool) {
+		pool.onEnqueue = hook
+	}
+}
+
+// ! WithOnDequeue calls hook whenever a worker takes a task off the queue to run it, with the
+// ! task's id and the time it left. The hook runs on the worker right before the task does.
+// ! Tasks dropped from the queue without running, such as by CancelQueued or Close, are not
+// ! reported. Synchronous pools have no queue and call neither hook.
+func WithOnDequeue(hook func(taskId int, at time.Time)) Option {
+	return func(pool *Pool) {
+		pool.onDequeue = hook
+	}
+}
+
+// ! announceEnqueued reports a task that has just joined the queue to the OnEnqueue hook.
+// ! It must be called without holding the pool mutex.
+func (pool *Pool) announceEnqueued(queuedTask *task) {
+	if pool.onEnqueue != nil {
+		pool.onEnqueue(queuedTask.id, queuedTask.enqueuedAt)
+	}
+}
+
+// ! announceDequeued reports a task a worker is about to run to the OnDequeue hook.
+func (pool *Pool) announceDequeued(dequeuedTask *task) {
+	if pool.onDequeue != nil {
+		pool.onDequeue(dequeuedTask.id, pool.clock.Now())
+	}
+}
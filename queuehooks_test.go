@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! queueEvents records what the OnEnqueue and OnDequeue hooks saw, by task id.
+type queueEvents struct {
+	mutex    sync.Mutex
+	enqueued map[int]time.Time
+	dequeued map[int]time.Time
+}
+
+func (events *queueEvents) options(pool func() *Pool) []Option {
+	events.enqueued, events.dequeued = map[int]time.Time{}, map[int]time.Time{}
+	return []Option{
+		WithOnEnqueue(func(taskId int, at time.Time) {
+			pool().Stats() //! The hook may call back into the pool.
+			events.mutex.Lock()
+			events.enqueued[taskId] = at
+			events.mutex.Unlock()
+		}),
+		WithOnDequeue(func(taskId int, at time.Time) {
+			events.mutex.Lock()
+			events.dequeued[taskId] = at
+			events.mutex.Unlock()
+		}),
+	}
+}
+
+func (events *queueEvents) of(taskId int) (enqueued, dequeued time.Time) {
+	events.mutex.Lock()
+	defer events.mutex.Unlock()
+	return events.enqueued[taskId], events.dequeued[taskId]
+}
+
+func TestQueueHooksBracketTheWaitInTheQueue(t *testing.T) {
+	clock := newFakeClock()
+	var events queueEvents
+	var pool *Pool
+	pool = newTestPool(t, append(events.options(func() *Pool { return pool }), WithWorkers(1), WithClock(clock))...)
+	start := clock.Now()
+	release := occupy(t, pool)
+	waiting, _ := pool.Submit(noopTask)
+	delayed, _ := pool.SubmitAfter(time.Second, noopTask)
+	cancelled, _ := pool.Submit(noopTask)
+	cancelled.Cancel()
+	clock.advance(time.Second)
+	clock.advance(2 * time.Second)
+	close(release)
+	returnsWithin(t, time.Second, "the queued tasks", func() { pool.Flush(waiting, delayed, cancelled) })
+
+	if enqueued, dequeued := events.of(waiting.Id()); !enqueued.Equal(start) || !dequeued.Equal(start.Add(3*time.Second)) {
+		t.Errorf("the waiting task joined at %v and left at %v, want %v and 3s later", enqueued, dequeued, start)
+	}
+	if enqueued, _ := events.of(delayed.Id()); !enqueued.Equal(start.Add(time.Second)) {
+		t.Errorf("the delayed task joined at %v, want when its timer fired, %v", enqueued, start.Add(time.Second))
+	}
+	if _, dequeued := events.of(cancelled.Id()); !dequeued.IsZero() {
+		t.Errorf("the cancelled task was reported leaving the queue at %v", dequeued)
+	}
+}
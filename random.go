@@ -0,0 +1,32 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// ! WithRandSource makes every random choice the pool makes, such as retry jitter (see
+// ! WithRetryJitter), draw from source, so that tests and benchmarks can be made reproducible
+// ! by passing a seeded source like rand.NewPCG(1, 2). Without it the pool uses the randomly
+// ! seeded generator of math/rand/v2. The pool serializes its calls to source.
+func WithRandSource(source rand.Source) Option {
+	return func(pool *Pool) {
+		pool.random = &lockedRand{random: rand.New(source)}
+	}
+}
+
+// ! lockedRand makes a *rand.Rand safe for the pool's goroutines to share.
+type lockedRand struct {
+	mutex  sync.Mutex
+	random *rand.Rand
+}
+
+// ! randomFloat returns a random number in [0, 1) from the pool's source.
+func (pool *Pool) randomFloat() float64 {
+	if pool.random == nil {
+		return rand.Float64()
+	}
+	pool.random.mutex.Lock()
+	defer pool.random.mutex.Unlock()
+	return pool.random.random.Float64()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ! WithRateLimit caps how fast tasks are admitted to the pool with a token bucket: tasksPerSecond
+// ! tokens are added per second, up to burst, and every submitted task takes one. Submit blocks
+// ! until a token is available, in addition to waiting for queue space. Delayed tasks take
+// ! their token when they are submitted, not when their timer fires.
+func WithRateLimit(tasksPerSecond float64, burst int) Option {
+	return func(pool *Pool) {
+		pool.rateLimiter = &rateLimiter{rate: tasksPerSecond, burst: float64(burst), tokens: float64(burst)}
+	}
+}
+
+// ! SetRateLimit changes the rate limit of a running pool, for instance to back off while a
+// ! downstream answers with 429s and speed up again once it recovers. Tokens earned at the old
+// ! rate are kept, up to the new burst. Tasks already admitted are unaffected, and submitters
+// ! waiting for a token re-check against the new rate at once. A pool created without
+// ! WithRateLimit starts out with a full bucket; tasksPerSecond of zero or less removes the limit.
+func (pool *Pool) SetRateLimit(tasksPerSecond float64, burst int) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	defer pool.spaceAvailable.Broadcast()
+
+	if tasksPerSecond <= 0 {
+		pool.rateLimiter = nil
+		return
+	}
+	limiter := pool.rateLimiter
+	if limiter == nil {
+		pool.rateLimiter = &rateLimiter{rate: tasksPerSecond, burst: float64(burst), tokens: float64(burst)}
+		return
+	}
+	limiter.refill(pool.clock.Now())
+	limiter.rate = tasksPerSecond
+	limiter.burst = float64(burst)
+	limiter.tokens = min(limiter.tokens, limiter.burst)
+}
+
+// ! rateLimiter is a token bucket. The pool mutex guards it.
+type rateLimiter struct {
+	rate   float64 //! Tokens added per second.
+	burst  float64
+	tokens float64
+	last   time.Time //! When tokens was last brought up to date; zero before the first use.
+}
+
+// ! take consumes a token if one is available at now. Otherwise it consumes nothing and
+// ! returns how long it will be until the next token. It is safe on a nil limiter.
+func (limiter *rateLimiter) take(now time.Time) time.Duration {
+	if limiter == nil {
+		return 0
+	}
+	limiter.refill(now)
+	if limiter.tokens >= 1 {
+		limiter.tokens--
+		return 0
+	}
+	return time.Duration((1 - limiter.tokens) / limiter.rate * float64(time.Second))
+}
+
+// ! refill adds the tokens earned since the last update, up to burst.
+func (limiter *rateLimiter) refill(now time.Time) {
+	if !limiter.last.IsZero() {
+		limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.rate
+		if limiter.tokens > limiter.burst {
+			limiter.tokens = limiter.burst
+		}
+	}
+	limiter.last = now
+}
+
+// ! SubmitCtx is like Submit, but one deadline covers the whole admission policy: it blocks
+// ! until the queue has space and a rate-limit token is available (see WithRateLimit), or
+// ! until ctx ends, in which case it returns ctx.Err(). A token is only taken once there is
+// ! queue space for the task, so a submission that times out waiting for space costs nothing.
+func (pool *Pool) SubmitCtx(ctx context.Context, work Task) (*TaskHandle, error) {
+	newTask := &task{work: work, run: runTask(work)}
+	pool.stampTrace(ctx, newTask)
+	return pool.enqueueContext(ctx, newTask)
+}
+
+// ! waitForAdmission blocks until the task may be added to the queue or spilled, and a rate-limit
+// ! token has been taken for it. It returns ErrPoolClosed once the pool is closed, and ctx.Err()
+// ! if ctx ends first. The pool mutex must be held; it is released while waiting.
+func (pool *Pool) waitForAdmission(ctx context.Context, newTask *task) error {
+	wake := func() {
+		pool.mutex.Lock()
+		pool.spaceAvailable.Broadcast()
+		pool.mutex.Unlock()
+	}
+	stopWake := context.AfterFunc(ctx, wake)
+	defer stopWake()
+	var blockedSince time.Time
+	defer func() {
+		if !blockedSince.IsZero() {
+			pool.counters.submitBlocked.Add(1)
+			pool.counters.submitBlockedNanos.Add(int64(pool.clock.Now().Sub(blockedSince)))
+		}
+	}()
+	block := func() {
+		if blockedSince.IsZero() {
+			blockedSince = pool.clock.Now()
+		}
+		pool.spaceAvailable.Wait()
+	}
+	place := pool.joinSubmitLine()
+	defer pool.leaveSubmitLine(place)
+
+	for {
+		if pool.closed {
+			return pool.reject(RejectedPoolClosed, ErrPoolClosed)
+		}
+		if pool.rejectingDrains > 0 {
+			return pool.reject(RejectedDraining, ErrPoolDraining)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !pool.atHeadOfLine(place) {
+			if newTask.noWait {
+				return pool.reject(RejectedQueueFull, ErrQueueFull)
+			}
+			block()
+			continue
+		}
+		if pool.queue.len()+len(pool.drainHeld) < pool.queueSize || pool.canSpill(newTask) || pool.replacesQueued(newTask) {
+			wait := pool.rateLimiter.take(pool.clock.Now())
+			if wait <= 0 {
+				return nil
+			}
+			if newTask.noWait {
+				return pool.reject(RejectedRateLimited, ErrRateLimited)
+			}
+			//! Space is there but no token yet: sleep until the next one, or until something else wakes us.
+			timer := pool.clock.AfterFunc(wait, wake)
+			block()
+			timer.Stop()
+			continue
+		}
+		if newTask.noWait {
+			return pool.reject(RejectedQueueFull, ErrQueueFull)
+		}
+		block()
+	}
+}
+
+// ! WithPerWorkerRateLimit gives every worker a token bucket of its own, admitting
+// ! tasksPerSecond tasks per second up to burst, for downstreams sharded so that each worker
+// ! talks to one shard with its own quota. A worker that has picked up a task waits for a
+// ! token from its bucket before running it. The bucket belongs to the worker id, so a worker
+// ! replaced by WithMaxTasksPerWorker keeps its quota. Together with WithRateLimit both limits
+// ! apply: the pool-wide bucket admits tasks at Submit, and the worker's bucket then paces
+// ! their dispatch.
+func WithPerWorkerRateLimit(tasksPerSecond float64, burst int) Option {
+	return func(pool *Pool) {
+		pool.workerRate = &rateLimiter{rate: tasksPerSecond, burst: float64(burst), tokens: float64(burst)}
+	}
+}
+
+// ! awaitWorkerToken blocks until the worker's own bucket has a token for its next task. It
+// ! returns early once the pool is being torn down. Each bucket is only used by its worker.
+func (pool *Pool) awaitWorkerToken(workerId int) {
+	if pool.workerRate == nil {
+		return
+	}
+	limiter := pool.workerLimiters[workerId]
+	for {
+		wait := limiter.take(pool.clock.Now())
+		if wait <= 0 {
+			return
+		}
+		elapsed := make(chan struct{})
+		timer := pool.clock.AfterFunc(wait, func() { close(elapsed) })
+		select {
+		case <-elapsed:
+		case <-pool.poolContext.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
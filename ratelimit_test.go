@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetRateLimitChangesTheLimitOfARunningPool(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock))
+	pool.SetRateLimit(1, 2) //! Starts out with a full bucket of two.
+	for range 2 {
+		if _, err := pool.TrySubmit(noopTask); err != nil {
+			t.Fatalf("refused with tokens in the bucket: %v", err)
+		}
+	}
+	_, err := pool.TrySubmit(noopTask)
+	assertRejected(t, err, RejectedRateLimited, ErrRateLimited)
+
+	clock.advance(10 * time.Second) //! Earns ten tokens, of which the burst keeps two.
+	pool.SetRateLimit(1, 1)
+	if _, err := pool.TrySubmit(noopTask); err != nil {
+		t.Fatalf("refused with a token kept from the old rate: %v", err)
+	}
+	_, err = pool.TrySubmit(noopTask)
+	assertRejected(t, err, RejectedRateLimited, ErrRateLimited)
+}
+
+func TestSetRateLimitWakesWaitingSubmitters(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(20), WithClock(clock), WithRateLimit(1.0/3600, 1))
+	pool.Submit(noopTask) //! Takes the only token; the next one is an hour away.
+	submitted := make(chan error)
+	go func() {
+		_, err := pool.Submit(noopTask)
+		submitted <- err
+	}()
+	returnsWithin(t, time.Second, "the submitter to wait for a token", func() {
+		for clock.pending() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	pool.SetRateLimit(0, 0)
+	returnsWithin(t, time.Second, "the waiting submitter once the limit is lifted", func() {
+		if err := <-submitted; err != nil {
+			t.Error(err)
+		}
+	})
+	for range 10 {
+		if _, err := pool.TrySubmit(noopTask); err != nil {
+			t.Fatalf("refused without a rate limit: %v", err)
+		}
+	}
+}
+
+func TestPerWorkerRateLimitPacesEachWorkerAcrossRecycling(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithPerWorkerRateLimit(1, 2), WithMaxTasksPerWorker(1))
+	first, _ := pool.Submit(noopTask)
+	second, _ := pool.Submit(noopTask)
+	returnsWithin(t, time.Second, "the tasks within the worker's burst", func() { pool.Flush(first, second) })
+
+	third, _ := pool.Submit(noopTask) //! Runs on the second replacement, which must keep the spent bucket.
+	returnsWithin(t, time.Second, "the worker to wait for a token", func() {
+		for clock.pending() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	if finished(third) {
+		t.Fatal("a recycled worker ran a task on a fresh bucket")
+	}
+	clock.advance(time.Second)
+	returnsWithin(t, time.Second, "the task once the worker earned a token", func() { <-third.Done() })
+}
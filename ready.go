@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// ! WithWorkerInit runs init on every worker goroutine before it starts pulling tasks, for
+// ! per-worker setup such as opening a database connection. It runs again for the
+// ! replacement whenever a worker is recycled (see WithMaxTasksPerWorker). A worker whose init
+// ! returns an error logs it and never starts, shrinking the pool by one; if no worker is
+// ! left, the pool closes itself and cancels whatever is queued. See WaitReady.
+func WithWorkerInit(init func(workerId int) error) Option {
+	return func(pool *Pool) {
+		pool.workerInit = init
+	}
+}
+
+// ! WithWorkerTeardown runs teardown on every worker goroutine whose init succeeded as it
+// ! exits, to release what the init acquired: when the pool closes, when its slot is
+// ! quarantined, and when it is recycled (see WithMaxTasksPerWorker). A recycled worker's
+// ! replacement is started first, so its init may run while the old worker tears down. A
+// ! teardown that panics is logged.
+func WithWorkerTeardown(teardown func(workerId int)) Option {
+	return func(pool *Pool) {
+		pool.workerTeardown = teardown
+	}
+}
+
+// ! tearDown runs the teardown hook for an exiting worker, logging a panic instead of
+// ! crashing the process.
+func (pool *Pool) tearDown(workerId int) {
+	if pool.workerTeardown == nil {
+		return
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			pool.logger.Printf("%s panicked during teardown: %v", pool.workerName(workerId), recovered)
+		}
+	}()
+	pool.workerTeardown(workerId)
+}
+
+// ! WithFailFastInit makes New wait for the init of every worker (see WithWorkerInit) and, as
+// ! soon as one fails, close the pool again and return an error wrapping ErrWorkerInit and the
+// ! init's error, instead of quietly starting a degraded pool, say while the database is
+// ! unreachable at boot.
+func WithFailFastInit() Option {
+	return func(pool *Pool) {
+		pool.failFastInit = true
+	}
+}
+
+// ! WithMinHealthyWorkers makes New wait for the init of every worker, and close the pool
+// ! again and fail unless at least n of them succeeded. The error wraps ErrNotEnoughWorkers
+// ! and the first init error. It tolerates a few failing inits where WithFailFastInit
+// ! tolerates none; the workers that failed are still not restarted.
+func WithMinHealthyWorkers(n int) Option {
+	return func(pool *Pool) {
+		pool.minHealthyWorkers = n
+	}
+}
+
+// ! checkInit is the last step of New with WithFailFastInit or WithMinHealthyWorkers. It waits
+// ! until every worker has finished its init, or one has failed under fail-fast, and closes the
+// ! pool and returns the error New should report if the outcome is not good enough.
+func (pool *Pool) checkInit() error {
+	pool.waitFor(context.Background(), func() bool {
+		return pool.readyWorkers >= pool.activeWorkers || (pool.failFastInit && pool.initErr != nil)
+	})
+	pool.mutex.Lock()
+	ready, initErr := pool.readyWorkers, pool.initErr
+	pool.mutex.Unlock()
+
+	var err error
+	switch {
+	case pool.failFastInit && initErr != nil:
+		err = fmt.Errorf("%w: %w", ErrWorkerInit, initErr)
+	case ready < pool.minHealthyWorkers:
+		err = fmt.Errorf("%w: %d of %d workers initialized, %d required",
+			ErrNotEnoughWorkers, ready, pool.totalWorkers, pool.minHealthyWorkers)
+		if initErr != nil {
+			err = fmt.Errorf("%w; first init error: %w", err, initErr)
+		}
+	}
+	if err != nil {
+		pool.closeAndWait()
+	}
+	return err
+}
+
+// ! WaitReady blocks until at least minWorkers workers have finished their init and are ready
+// ! to pull tasks, and returns nil. It returns ctx.Err() if ctx ends first, and an error
+// ! wrapping ErrNotEnoughWorkers as soon as failed inits or quarantined slots leave too few
+// ! workers for minWorkers ever to be reached. A synchronous pool is always ready.
+func (pool *Pool) WaitReady(ctx context.Context, minWorkers int) error {
+	if pool.synchronous {
+		return nil
+	}
+	err := pool.waitFor(ctx, func() bool {
+		return pool.readyWorkers >= minWorkers || pool.activeWorkers < minWorkers
+	})
+	if err != nil {
+		return err
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if pool.readyWorkers < minWorkers {
+		return fmt.Errorf("%w: %d workers requested, at most %d can become ready",
+			ErrNotEnoughWorkers, minWorkers, pool.activeWorkers)
+	}
+	return nil
+}
+
+// ! initWorker runs the init hook for a starting worker and marks it ready. It reports false,
+// ! after retiring the slot, if init failed and the worker must not start.
+func (pool *Pool) initWorker(workerId int) bool {
+	if pool.workerInit != nil {
+		if err := pool.workerInit(workerId); err != nil {
+			pool.mutex.Lock()
+			if pool.initErr == nil {
+				pool.initErr = fmt.Errorf("%s: %w", pool.workerName(workerId), err)
+			}
+			pool.activeWorkers--
+			remaining := pool.activeWorkers
+			pool.broadcastStateChange()
+			pool.mutex.Unlock()
+
+			pool.logger.Printf("%s failed to initialize and is not started: %v; %d workers remain",
+				pool.workerName(workerId), err, remaining)
+			if remaining == 0 {
+				pool.logger.Printf("CRITICAL: no worker is left; closing the pool and cancelling queued tasks")
+				pool.abort()
+			}
+			return false
+		}
+	}
+	pool.warmUp(workerId)
+	pool.setReady(1)
+	return true
+}
+
+// ! setReady adjusts the number of ready workers and wakes WaitReady.
+func (pool *Pool) setReady(delta int) {
+	pool.mutex.Lock()
+	pool.readyWorkers += delta
+	pool.broadcastStateChange()
+	pool.mutex.Unlock()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var errDatabaseDown = errors.New("database unreachable")
+
+// ! failingInit fails the init of the first failures workers.
+func failingInit(failures int) func(workerId int) error {
+	return func(workerId int) error {
+		if workerId <= failures {
+			return errDatabaseDown
+		}
+		return nil
+	}
+}
+
+func TestFailFastInitFailsNew(t *testing.T) {
+	pool, err := New(WithWorkers(4), WithWorkerInit(failingInit(1)), WithFailFastInit(),
+		WithLogger(log.New(io.Discard, "", 0)))
+	if !errors.Is(err, ErrWorkerInit) || !errors.Is(err, errDatabaseDown) {
+		t.Fatalf("New error = %v, want ErrWorkerInit wrapping the init error", err)
+	}
+	if pool != nil {
+		t.Fatal("New returned a pool along with its error")
+	}
+}
+
+func TestFailFastInitSucceedsWhenAllInitsDo(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(3), WithWorkerInit(failingInit(0)), WithFailFastInit())
+	pool.mutex.Lock()
+	ready := pool.readyWorkers
+	pool.mutex.Unlock()
+	if ready != 3 {
+		t.Fatalf("ReadyWorkers = %d right after New, want 3", ready)
+	}
+}
+
+func TestMinHealthyWorkers(t *testing.T) {
+	quiet := WithLogger(log.New(io.Discard, "", 0))
+	pool := newTestPool(t, WithWorkers(4), WithWorkerInit(failingInit(1)), WithMinHealthyWorkers(3), quiet)
+	ran := make(chan struct{})
+	pool.SubmitFunc(func() { close(ran) })
+	<-ran
+
+	_, err := New(WithWorkers(4), WithWorkerInit(failingInit(2)), WithMinHealthyWorkers(3), quiet)
+	if !errors.Is(err, ErrNotEnoughWorkers) || !errors.Is(err, errDatabaseDown) {
+		t.Fatalf("New error = %v, want ErrNotEnoughWorkers wrapping the init error", err)
+	}
+}
+
+func TestWarmupRunsBeforeTasksAndFailuresAreNonFatal(t *testing.T) {
+	var logged bytes.Buffer
+	warming, primed := make(chan struct{}, 3), make(chan struct{})
+	pool := newTestPool(t, WithWorkers(3), WithLogger(log.New(&logged, "", 0)),
+		WithWarmup(func(workerId int) error {
+			warming <- struct{}{}
+			<-primed
+			switch workerId {
+			case 2:
+				return errors.New("cache unavailable")
+			case 3:
+				panic("priming blew up")
+			}
+			return nil
+		}))
+	var ran atomic.Int32
+	for range 6 {
+		pool.SubmitFunc(func() { ran.Add(1) })
+	}
+	for range 3 {
+		<-warming //! Every worker is held in its warmup, so none can have pulled a task.
+	}
+	if got := ran.Load(); got != 0 {
+		t.Fatalf("%d tasks ran before any worker had warmed up", got)
+	}
+	close(primed)
+	pool.Wait()
+	if err := pool.WaitReady(context.Background(), 3); err != nil {
+		t.Fatalf("WaitReady after failed warmups: %v", err)
+	}
+	for _, want := range []string{"cache unavailable", "priming blew up"} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("log %q does not mention %q", logged.String(), want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ! WithRecorder writes the pool's dispatch order to w, one line per task handed to a worker:
+// !
+// !	dispatch 1 worker 3 task 17 at 2026-10-14T09:30:00.123456789Z
+// !
+// ! Lines may reach w slightly out of order, the sequence number is authoritative. A failed
+// ! write is logged and ends the recording. Feed the output to WithReplay to reproduce the run.
+func WithRecorder(w io.Writer) Option {
+	return func(pool *Pool) {
+		pool.recorder = &dispatchRecorder{writer: w}
+	}
+}
+
+// ! WithReplay forces a recorded dispatch order, see WithRecorder, onto this run, to reproduce
+// ! a failure that only shows up under one interleaving. Tasks are matched by id, so the run
+// ! must submit the same tasks in the same order as the recorded one. Each recorded step waits
+// ! until its task is queued and its worker is free, and no other task is dispatched in the
+// ! meantime, which serializes the pool as much as the recording requires; concurrency is
+// ! traded for determinism only while steps remain. Steps naming a worker the pool does not
+// ! have may go to any worker. Once the recording is used up, or Close begins, the pool
+// ! dispatches normally. New fails with ErrInvalidReplay if r cannot be parsed. Not to be
+// ! confused with Pool.Replay, which resubmits failed tasks.
+func WithReplay(r io.Reader) Option {
+	return func(pool *Pool) {
+		pool.dispatchReplay = &dispatchReplay{source: r}
+	}
+}
+
+// ! dispatchRecorder writes the lines of WithRecorder.
+type dispatchRecorder struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	failed bool
+	last   int //! Sequence number of the latest dispatch, guarded by the pool mutex.
+}
+
+// ! dispatchStep is a recorded dispatch.
+type dispatchStep struct {
+	worker int
+	taskId int
+}
+
+// ! dispatchReplay is the state of WithReplay, guarded by the pool mutex once New returns.
+type dispatchReplay struct {
+	source io.Reader
+	steps  []dispatchStep
+	next   int //! Index of the step to dispatch next.
+}
+
+// ! loadReplay parses the recording given to WithReplay.
+func (pool *Pool) loadReplay() error {
+	replay := pool.dispatchReplay
+	if replay == nil {
+		return nil
+	}
+	type numbered struct {
+		sequence int
+		step     dispatchStep
+	}
+	var recorded []numbered
+	scanner := bufio.NewScanner(replay.source)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry numbered
+		//! The timestamp is for people reading the recording; replay ignores it.
+		if _, err := fmt.Sscanf(line, "dispatch %d worker %d task %d",
+			&entry.sequence, &entry.step.worker, &entry.step.taskId); err != nil {
+			return fmt.Errorf("%w: line %d: %q: %v", ErrInvalidReplay, lineNumber, line, err)
+		}
+		recorded = append(recorded, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReplay, err)
+	}
+	slices.SortFunc(recorded, func(a, b numbered) int { return a.sequence - b.sequence })
+	for _, entry := range recorded {
+		replay.steps = append(replay.steps, entry.step)
+	}
+	replay.source = nil
+	return nil
+}
+
+// ! replaying reports whether dispatch still follows a recording. The pool mutex must be held.
+func (pool *Pool) replaying() bool {
+	replay := pool.dispatchReplay
+	if replay == nil || replay.next == len(replay.steps) {
+		return false
+	}
+	if pool.closed {
+		replay.next = len(replay.steps) //! The recorded tasks may never come; do not hold up Close.
+		return false
+	}
+	return true
+}
+
+// ! takeReplayed removes the task of the next recorded step from the queue if the slot is the
+// ! step's worker and the task is queued, or returns nil. The pool mutex must be held.
+func (pool *Pool) takeReplayed(slot *workerSlot) *task {
+	replay := pool.dispatchReplay
+	step := replay.steps[replay.next]
+	if step.worker != slot.id && step.worker >= 1 && step.worker <= pool.totalWorkers {
+		return nil
+	}
+	taken := pool.queue.removeMatching(func(queued *task) bool { return queued.id == step.taskId })
+	if len(taken) == 0 {
+		return nil
+	}
+	replay.next++
+	pool.taskAvailable.Broadcast() //! The next step may belong to another worker.
+	return taken[0]
+}
+
+// ! numberDispatch gives a task just handed to a worker its place in the recording. The pool
+// ! mutex must be held.
+func (pool *Pool) numberDispatch(dispatched *task) {
+	if pool.recorder == nil {
+		return
+	}
+	pool.recorder.last++
+	dispatched.dispatchSequence = pool.recorder.last
+}
+
+// ! recordDispatch writes the WithRecorder line of a task dispatched to a worker.
+func (pool *Pool) recordDispatch(slot *workerSlot, dispatched *task) {
+	recorder := pool.recorder
+	if recorder == nil {
+		return
+	}
+	line := fmt.Sprintf("dispatch %d worker %d task %d at %s\n",
+		dispatched.dispatchSequence, slot.id, dispatched.id, pool.clock.Now().UTC().Format(time.RFC3339Nano))
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	if recorder.failed {
+		return
+	}
+	if _, err := io.WriteString(recorder.writer, line); err != nil {
+		recorder.failed = true
+		pool.logger.Printf("could not record the dispatch of task %d: %v; recording stops", dispatched.id, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! parseRecording returns the worker and task of each recorded dispatch, in order.
+func parseRecording(t *testing.T, recording string) []dispatchStep {
+	t.Helper()
+	pool := &Pool{dispatchReplay: &dispatchReplay{source: strings.NewReader(recording)}}
+	if err := pool.loadReplay(); err != nil {
+		t.Fatalf("loadReplay: %v", err)
+	}
+	return pool.dispatchReplay.steps
+}
+
+func TestReplayForcesRecordedDispatchOrder(t *testing.T) {
+	const script = "dispatch 2 worker 1 task 1\n" +
+		"dispatch 1 worker 2 task 3\n" +
+		"dispatch 3 worker 2 task 2\n" +
+		"dispatch 4 worker 3 task 4\n"
+	var recording bytes.Buffer
+	var mutex sync.Mutex
+	var ran []int
+	pool := newTestPool(t, WithWorkers(3), WithReplay(strings.NewReader(script)), WithRecorder(&recording))
+	for taskId := 1; taskId <= 4; taskId++ {
+		if _, err := pool.Submit(TaskFunc(func(context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			ran = append(ran, taskId)
+			return nil
+		})); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	pool.Wait()
+	pool.Close()
+
+	want := []dispatchStep{{2, 3}, {1, 1}, {2, 2}, {3, 4}}
+	if got := parseRecording(t, recording.String()); !slices.Equal(got, want) {
+		t.Fatalf("replayed dispatches %v, want %v", got, want)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(ran) != 4 || ran[0] != 3 {
+		t.Fatalf("tasks ran in order %v, want task 3 first", ran)
+	}
+}
+
+func TestReplayEndsAtClose(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithReplay(strings.NewReader("dispatch 1 worker 1 task 99\n")))
+	handle, err := pool.Submit(noopTask)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	returnsWithin(t, time.Second, "Close", pool.Close)
+	<-handle.Done()
+}
+
+func TestReplayRejectsMalformedRecording(t *testing.T) {
+	_, err := New(WithReplay(strings.NewReader("dispatch 1 worker one task 2\n")))
+	if !errors.Is(err, ErrInvalidReplay) {
+		t.Fatalf("New: got %v, want ErrInvalidReplay", err)
+	}
+}
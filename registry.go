@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// ! registry holds the pools created with WithName, for admin and debug handlers.
+var registry struct {
+	mutex sync.RWMutex
+	pools map[string]*Pool
+}
+
+// ! WithName registers the pool under name in a process-wide registry, so an admin or debug
+// ! handler can enumerate every pool of a large service, see Pools and PoolByName, without the
+// ! application passing references around. The pool leaves the registry once Close has
+// ! finished. New fails with ErrPoolNameTaken while another registered pool has the name.
+func WithName(name string) Option {
+	return func(pool *Pool) {
+		pool.name = name
+	}
+}
+
+// ! Name returns the name given with WithName, or "" for an unnamed pool.
+func (pool *Pool) Name() string {
+	return pool.name
+}
+
+// ! Pools returns a snapshot of the registered pools by name, see WithName.
+func Pools() map[string]*Pool {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	return maps.Clone(registry.pools)
+}
+
+// ! PoolByName returns the registered pool with the given name, see WithName.
+func PoolByName(name string) (*Pool, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	pool, ok := registry.pools[name]
+	return pool, ok
+}
+
+// ! registerName adds a named pool to the registry.
+func (pool *Pool) registerName() error {
+	if pool.name == "" {
+		return nil
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, taken := registry.pools[pool.name]; taken {
+		return fmt.Errorf("%w: %q", ErrPoolNameTaken, pool.name)
+	}
+	if registry.pools == nil {
+		registry.pools = make(map[string]*Pool)
+	}
+	registry.pools[pool.name] = pool
+	return nil
+}
+
+// ! deregisterName removes a closed pool from the registry.
+func (pool *Pool) deregisterName() {
+	if pool.name == "" {
+		return
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if registry.pools[pool.name] == pool {
+		delete(registry.pools, pool.name)
+	}
+}
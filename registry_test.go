@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestNamedPoolsRegisterUntilClosed(t *testing.T) {
+	orders := newTestPool(t, WithWorkers(1), WithName("orders"))
+	newTestPool(t, WithWorkers(1)) //! Unnamed, so not listed.
+	if found, ok := PoolByName("orders"); !ok || found != orders || found.Name() != "orders" {
+		t.Fatalf("PoolByName(orders) = %p, %v; want %p", found, ok, orders)
+	}
+	if _, err := New(WithWorkers(1), WithName("orders")); !errors.Is(err, ErrPoolNameTaken) {
+		t.Fatalf("New with a taken name: got %v, want ErrPoolNameTaken", err)
+	}
+	if pools := Pools(); len(pools) != 1 || pools["orders"] != orders {
+		t.Fatalf("Pools() = %v, want just orders", pools)
+	}
+
+	orders.Close()
+	if _, ok := PoolByName("orders"); ok {
+		t.Fatalf("a closed pool is still registered")
+	}
+	reused := newTestPool(t, WithWorkers(1), WithName("orders"))
+	if found, _ := PoolByName("orders"); found != reused {
+		t.Fatalf("the name was not free for a new pool after Close")
+	}
+}
+
+func TestFailedNewLeavesNameFree(t *testing.T) {
+	failing := func(int) error { return errors.New("no connection") }
+	_, err := New(WithWorkers(1), WithName("flaky"), WithWorkerInit(failing), WithFailFastInit(),
+		WithLogger(log.New(io.Discard, "", 0)))
+	if !errors.Is(err, ErrWorkerInit) {
+		t.Fatalf("New with a failing init: got %v, want ErrWorkerInit", err)
+	}
+	if _, ok := PoolByName("flaky"); ok {
+		t.Fatalf("a pool New gave up on is registered")
+	}
+	newTestPool(t, WithWorkers(1), WithName("flaky"))
+}
+
+func TestShardedPoolNamesEachShard(t *testing.T) {
+	sharded, err := NewShardedPool(3, WithWorkers(1), WithName("orders"))
+	if err != nil {
+		t.Fatalf("NewShardedPool with a name: %v", err)
+	}
+	for index, shard := range sharded.Shards() {
+		want := fmt.Sprintf("orders/%d", index)
+		if found, ok := PoolByName(want); !ok || found != shard || shard.Name() != want {
+			t.Fatalf("PoolByName(%s) = %p, %v; want shard %d", want, found, ok, index)
+		}
+	}
+	sharded.Close()
+	if pools := Pools(); len(pools) != 0 {
+		t.Fatalf("Pools() = %v after Close, want none", pools)
+	}
+}
+
+func TestStandbyPoolNamesEachGeneration(t *testing.T) {
+	standbyPool, err := NewStandbyPool(context.Background(), WithWorkers(1), WithName("orders"))
+	if err != nil {
+		t.Fatalf("NewStandbyPool with a name: %v", err)
+	}
+	defer standbyPool.Close()
+	if standbyPool.Primary().Name() != "orders/1" {
+		t.Fatalf("primary named %q, want orders/1", standbyPool.Primary().Name())
+	}
+	if _, ok := PoolByName("orders/2"); !ok {
+		t.Fatalf("the standby is not registered as orders/2")
+	}
+
+	if err := standbyPool.Promote(context.Background()); err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	if found, ok := PoolByName("orders/2"); !ok || found != standbyPool.Primary() {
+		t.Fatalf("orders/2 is not the promoted primary")
+	}
+	if _, ok := PoolByName("orders/3"); !ok {
+		t.Fatalf("the new standby is not registered as orders/3")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ! RejectionReason says why the pool refused a submission, see RejectionError.
+type RejectionReason int
+
+const (
+	RejectedPoolClosed        RejectionReason = iota + 1 //! The pool has been closed, see ErrPoolClosed.
+	RejectedQueueFull                                    //! No queue space and the caller would not wait, see ErrQueueFull.
+	RejectedRateLimited                                  //! No rate-limit token and the caller would not wait, see ErrRateLimited.
+	RejectedCircuitOpen                                  //! The pool's circuit breaker is open, see WithCircuitBreaker.
+	RejectedMemoryLimit                                  //! The heap is over the memory limit, see WithMemoryLimit.
+	RejectedShed                                         //! Dropped by load shedding, see ErrTaskShed.
+	RejectedBucketOverflow                               //! The leaky bucket is full, see ErrBucketOverflow.
+	RejectedCostExceedsBudget                            //! The task costs more than the whole budget, see ErrCostExceedsBudget.
+	RejectedNoCapableWorker                              //! No worker has the required capability, see ErrNoCapableWorker.
+	RejectedDraining                                     //! DrainAndReject is in progress, see ErrPoolDraining.
+	RejectedByController                                 //! The admission controller refused it, see ErrAdmissionDenied.
+	RejectedCPUBudget                                    //! The CPU time budget is used up, see ErrCPUBudgetExceeded.
+	RejectedKeyQuarantined                               //! The task's key is quarantined, see ErrKeyQuarantined.
+)
+
+// ! String returns the reason in lower case, as used in log lines and error messages.
+func (reason RejectionReason) String() string {
+	switch reason {
+	case RejectedPoolClosed:
+		return "pool closed"
+	case RejectedQueueFull:
+		return "queue full"
+	case RejectedRateLimited:
+		return "rate limited"
+	case RejectedCircuitOpen:
+		return "circuit open"
+	case RejectedMemoryLimit:
+		return "memory limit"
+	case RejectedShed:
+		return "shed"
+	case RejectedBucketOverflow:
+		return "bucket overflow"
+	case RejectedCostExceedsBudget:
+		return "cost exceeds budget"
+	case RejectedNoCapableWorker:
+		return "no capable worker"
+	case RejectedDraining:
+		return "draining"
+	case RejectedByController:
+		return "denied by admission controller"
+	case RejectedCPUBudget:
+		return "cpu budget exceeded"
+	case RejectedKeyQuarantined:
+		return "key quarantined"
+	default:
+		return fmt.Sprintf("RejectionReason(%d)", int(reason))
+	}
+}
+
+// ! RejectionError is the error type of every submission the pool refuses. It wraps one of
+// ! the sentinel errors, so errors.Is keeps working, while errors.As gives callers the reason
+// ! and the queue depth at the moment of the refusal to decide whether to retry or drop.
+// ! Giving up because the caller's own context ended is not a rejection and is reported as
+// ! ctx.Err() as before, and neither is a failure to spill the task to disk, which is returned
+// ! as the encoder or the file system reported it.
+type RejectionError struct {
+	Reason     RejectionReason
+	QueueDepth int   //! Tasks waiting in the queue when the submission was refused.
+	Err        error //! The sentinel for Reason, such as ErrPoolClosed, possibly wrapped with details.
+}
+
+func (rejection *RejectionError) Error() string {
+	return fmt.Sprintf("%v (queue depth %d)", rejection.Err, rejection.QueueDepth)
+}
+
+func (rejection *RejectionError) Unwrap() error {
+	return rejection.Err
+}
+
+// ! reject builds the error for a refused submission. The pool mutex must be held.
+func (pool *Pool) reject(reason RejectionReason, err error) *RejectionError {
+	return &RejectionError{Reason: reason, QueueDepth: pool.queue.len(), Err: err}
+}
+
+// ! rejectUnlocked is reject for checks made before taking the pool mutex.
+func (pool *Pool) rejectUnlocked(reason RejectionReason, err error) *RejectionError {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.reject(reason, err)
+}
+
+// ! TrySubmit is like Submit, but never blocks: when the queue is full it fails with
+// ! ErrQueueFull, and when no rate-limit token is available (see WithRateLimit) it fails
+// ! with ErrRateLimited, both wrapped in a RejectionError.
+func (pool *Pool) TrySubmit(work Task) (*TaskHandle, error) {
+	return pool.enqueue(&task{work: work, run: runTask(work), noWait: true})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ! assertRejected fails the test unless err is a RejectionError for reason wrapping sentinel.
+func assertRejected(t *testing.T, err error, reason RejectionReason, sentinel error) {
+	t.Helper()
+	var rejection *RejectionError
+	if !errors.As(err, &rejection) || rejection.Reason != reason || !errors.Is(err, sentinel) {
+		t.Errorf("got error %v, want a %v rejection wrapping %v", err, reason, sentinel)
+	}
+}
+
+func TestRefusedSubmissionsAreRejectionErrors(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(1), WithConcurrencyBudget(2))
+	_, err := pool.SubmitWeighted(noopTask, 3)
+	assertRejected(t, err, RejectedCostExceedsBudget, ErrCostExceedsBudget)
+	_, err = pool.SubmitWithRelease(3, func(context.Context, func()) error { return nil })
+	assertRejected(t, err, RejectedCostExceedsBudget, ErrCostExceedsBudget)
+	_, err = pool.SubmitRequiring("gpu", noopTask)
+	assertRejected(t, err, RejectedNoCapableWorker, ErrNoCapableWorker)
+
+	release := make(chan struct{})
+	defer close(release)
+	pool.SubmitFunc(func() { <-release })
+	pool.SubmitFunc(func() {})
+	_, err = pool.TrySubmit(noopTask)
+	assertRejected(t, err, RejectedQueueFull, ErrQueueFull)
+}
+
+func TestAdmissionControllerRefusesWithReason(t *testing.T) {
+	controller := func(info TaskInfo) (bool, string) {
+		if info.Tags["tenant"] == "suspended" {
+			return false, "tenant suspended"
+		}
+		return true, ""
+	}
+	pool := newTestPool(t, WithWorkers(1), WithAdmissionController(controller))
+
+	_, err := pool.SubmitTagged(map[string]string{"tenant": "suspended"}, noopTask)
+	assertRejected(t, err, RejectedByController, ErrAdmissionDenied)
+	if err != nil && !strings.Contains(err.Error(), "tenant suspended") {
+		t.Errorf("rejection %q does not carry the controller's reason", err)
+	}
+	if _, err := pool.SubmitTagged(map[string]string{"tenant": "active"}, noopTask); err != nil {
+		t.Fatalf("admitted task was refused: %v", err)
+	}
+}
+
+func TestCircuitBreakerRefusesSubmissionsWhileOpen(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithCircuitBreaker(2, time.Minute))
+	failing := TaskFunc(func(context.Context) error { return errors.New("downstream unavailable") })
+	for range 2 {
+		handle, _ := pool.Submit(failing)
+		<-handle.Done()
+	}
+	_, err := pool.Submit(noopTask)
+	assertRejected(t, err, RejectedCircuitOpen, ErrCircuitOpen)
+
+	clock.advance(time.Minute)
+	if _, err := pool.Submit(noopTask); err != nil {
+		t.Fatalf("Submit once the circuit closed: %v", err)
+	}
+}
+
+func TestMemoryLimitRefusesSubmissionsOverTheLimit(t *testing.T) {
+	clock := newFakeClock()
+	var heap atomic.Uint64
+	heap.Store(2 << 20)
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithLogger(log.New(io.Discard, "", 0)),
+		WithMemoryLimit(1<<20),
+		func(pool *Pool) { pool.memoryPressure.readHeap = heap.Load })
+	clock.advance(memorySampleInterval)
+	_, err := pool.Submit(noopTask)
+	assertRejected(t, err, RejectedMemoryLimit, ErrMemoryLimit)
+
+	heap.Store(1 << 19)
+	clock.advance(memorySampleInterval)
+	handle, err := pool.Submit(noopTask)
+	if err != nil {
+		t.Fatalf("Submit once the heap shrank: %v", err)
+	}
+	returnsWithin(t, time.Second, "a task under the limit", func() { <-handle.Done() })
+}
@@ -0,0 +1,58 @@
+package main
+
+// ! SubmitReplace is like SubmitFunc, but keeps at most one queued task per slot: if a task
+// ! submitted for the same slot is still waiting in the queue, the new one takes its place and
+// ! the old one's handle finishes as cancelled with ErrTaskReplaced, without running. This suits
+// ! recompute-on-change work where only the newest state is worth processing. A task for the
+// ! slot that is already running is left alone, and the new one queues behind it. Replacing
+// ! never waits for queue space, since the replaced task frees its own; submitting for a slot
+// ! with nothing queued waits like SubmitFunc does.
+func (pool *Pool) SubmitReplace(slot string, run func()) (*TaskHandle, error) {
+	return pool.enqueue(&task{run: runFunc(run), slot: slot})
+}
+
+// ! replacesQueued reports whether a task submitted with SubmitReplace will replace one still
+// ! queued for its slot. The pool mutex must be held.
+func (pool *Pool) replacesQueued(newTask *task) bool {
+	if newTask.slot == "" {
+		return false
+	}
+	queued, ok := pool.replaceSlots[newTask.slot]
+	return ok && queued.queueIndex >= 0
+}
+
+// ! takeSlot makes a task that was just queued the one waiting for its slot, unqueueing the
+// ! task it replaces, which is left in newTask.replaced for dropReplaced. The pool mutex must be held.
+func (pool *Pool) takeSlot(newTask *task) {
+	if newTask.slot == "" {
+		return
+	}
+	if pool.replacesQueued(newTask) {
+		previous := pool.replaceSlots[newTask.slot]
+		pool.queue.removeMatching(func(queued *task) bool { return queued == previous })
+		newTask.replaced = previous
+	}
+	if pool.replaceSlots == nil {
+		pool.replaceSlots = make(map[string]*task)
+	}
+	pool.replaceSlots[newTask.slot] = newTask
+}
+
+// ! leaveSlot frees the slot of a task a worker has picked up. The pool mutex must be held.
+func (pool *Pool) leaveSlot(dispatched *task) {
+	if dispatched.slot != "" && pool.replaceSlots[dispatched.slot] == dispatched {
+		delete(pool.replaceSlots, dispatched.slot)
+	}
+}
+
+// ! dropReplaced finishes the task a SubmitReplace displaced, if any.
+func (pool *Pool) dropReplaced(newTask *task) {
+	replaced := newTask.replaced
+	if replaced == nil {
+		return
+	}
+	newTask.replaced = nil
+	pool.counters.cancelled.Add(1)
+	replaced.handle.finish(outcomeCancelled, ErrTaskReplaced)
+	pool.finishTask()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSubmitReplaceKeepsOnlyTheLatest(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(2))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var runs, latest atomic.Int32
+	pool.SubmitReplace("render", func() { close(started); <-release; runs.Add(1) })
+	<-started
+
+	var handles []*TaskHandle
+	for version := int32(1); version <= 5; version++ {
+		handle, err := pool.SubmitReplace("render", func() { runs.Add(1); latest.Store(version) })
+		if err != nil {
+			t.Fatalf("SubmitReplace %d: %v", version, err)
+		}
+		handles = append(handles, handle)
+	}
+	other, _ := pool.SubmitReplace("other", func() { runs.Add(1) })
+	close(release)
+	pool.Wait()
+
+	if runs.Load() != 3 || latest.Load() != 5 {
+		t.Fatalf("%d runs, latest version %d; want the running task, version 5 and the other slot", runs.Load(), latest.Load())
+	}
+	for _, replaced := range handles[:4] {
+		if !replaced.Cancelled() || !errors.Is(replaced.Err(), ErrTaskReplaced) {
+			t.Fatalf("replaced task %d: cancelled %v, err %v", replaced.Id(), replaced.Cancelled(), replaced.Err())
+		}
+	}
+	if handles[4].Err() != nil || other.Err() != nil {
+		t.Fatalf("surviving tasks failed: %v, %v", handles[4].Err(), other.Err())
+	}
+}
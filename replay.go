@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ! WithResultPayloads makes every Result carry the Task value it ran (see Result.Task), so
+// ! that Replay can submit failed tasks again. Each result then keeps its task reachable for as
+// ! long as the result is, which for sinks such as WithResultBatcher or a caller collecting a
+// ! whole batch's results can pin a lot of memory. Tasks submitted as closures, such as with
+// ! SubmitFunc, have no Task value and never carry one.
+func WithResultPayloads() Option {
+	return func(pool *Pool) {
+		pool.resultPayloads = true
+	}
+}
+
+// ! Replay submits the task of every failed result again, in the order given, for the
+// ! operator workflow of running a batch, inspecting the failures, fixing the downstream and
+// ! replaying just those. It relies on WithResultPayloads: failed results without a Task are
+// ! skipped and reported by an error wrapping ErrNotReplayable. Tasks go through Submit, so
+// ! they wait for queue space and get new ids, and do not keep the priority or tags they were
+// ! first submitted with, but they keep their cancellation: a task whose original handle was
+// ! cancelled is dropped as cancelled instead of running, and cancelling the original handle
+// ! later cancels the replayed task too. Under WithDeliverySemantics(AtMostOnce) nothing is
+// ! submitted and the error wraps ErrNotReplayable, as a failed task may have had its side
+// ! effects already. Replay returns the handles of the tasks it submitted; the returned error
+// ! also joins every refused submission, naming the position of its result.
+func (pool *Pool) Replay(results []Result) ([]*TaskHandle, error) {
+	if pool.delivery == AtMostOnce {
+		return nil, fmt.Errorf("%w: the pool delivers tasks at most once", ErrNotReplayable)
+	}
+	var handles []*TaskHandle
+	var errs []error
+	missing := 0
+	for index, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if result.Task == nil {
+			missing++
+			continue
+		}
+		handle, err := pool.enqueue(&task{work: result.Task, run: runTask(result.Task), identity: result.identity})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("result %d: %w", index, err))
+			continue
+		}
+		handles = append(handles, handle)
+	}
+	if missing > 0 {
+		errs = append(errs, fmt.Errorf("%w: %d failed results carry no task", ErrNotReplayable, missing))
+	}
+	return handles, errors.Join(errs...)
+}
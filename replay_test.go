@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReplayResubmitsOnlyFailures(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2), WithResultPayloads())
+	results := pool.Results()
+	var downstreamFixed atomic.Bool
+	var replayed atomic.Int32
+	flaky := TaskFunc(func(context.Context) error {
+		if downstreamFixed.Load() {
+			replayed.Add(1)
+			return nil
+		}
+		return errors.New("downstream down")
+	})
+	pool.Submit(flaky)
+	pool.Submit(flaky)
+	pool.Submit(noopTask)
+	pool.enqueue(&task{run: func(context.Context, *WorkerScratch) error { return errors.New("closure") }})
+	pool.Wait()
+
+	var batch []Result
+	for range 4 {
+		batch = append(batch, <-results)
+	}
+	downstreamFixed.Store(true)
+	handles, err := pool.Replay(batch)
+	if !errors.Is(err, ErrNotReplayable) {
+		t.Fatalf("Replay error = %v, want ErrNotReplayable for the closure", err)
+	}
+	pool.Wait()
+	if len(handles) != 2 || replayed.Load() != 2 {
+		t.Fatalf("replayed %d tasks, %d ran; want the 2 failures", len(handles), replayed.Load())
+	}
+}
+
+func TestReplayKeepsCancellation(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithResultPayloads())
+	results := pool.Results()
+	var runs atomic.Int32
+	failing := TaskFunc(func(context.Context) error {
+		runs.Add(1)
+		return errors.New("failed")
+	})
+	cancelledBefore, _ := pool.Submit(failing)
+	cancelledAfter, _ := pool.Submit(failing)
+	pool.Wait()
+	batch := []Result{<-results, <-results}
+	cancelledBefore.Cancel()
+
+	release := make(chan struct{})
+	pool.Submit(TaskFunc(func(context.Context) error { <-release; return nil })) //! Holds the replays in the queue.
+	handles, err := pool.Replay(batch)
+	if err != nil || len(handles) != 2 {
+		t.Fatalf("Replay returned %d handles, %v", len(handles), err)
+	}
+	cancelledAfter.Cancel()
+	close(release)
+	pool.Wait()
+	for _, handle := range handles {
+		if !handle.Cancelled() {
+			t.Errorf("replayed task %d was not cancelled: %v", handle.Id(), handle.Err())
+		}
+	}
+	if runs.Load() != 2 {
+		t.Errorf("tasks ran %d times, want only the 2 originals", runs.Load())
+	}
+}
+
+func TestMaxLifetimeAttemptsStopsRepeatedReplays(t *testing.T) {
+	var deadLettered []error
+	pool := newTestPool(t, WithWorkers(1), WithRetry(2), WithResultPayloads(),
+		WithMaxLifetimeAttempts(3, func(info TaskInfo, err error) { deadLettered = append(deadLettered, err) }))
+	results := pool.Results()
+	var runs atomic.Int32
+	broken := TaskFunc(func(context.Context) error {
+		runs.Add(1)
+		return errors.New("permanently broken")
+	})
+	pool.Submit(broken) //! Two attempts.
+	pool.Wait()
+	first := <-results
+
+	handles, _ := pool.Replay([]Result{first}) //! One more attempt, then the cap is reached.
+	pool.Wait()
+	second := <-results
+	handles, _ = pool.Replay([]Result{second})
+	pool.Wait()
+	if err := handles[0].Err(); !errors.Is(err, ErrAttemptsExhausted) {
+		t.Errorf("the third submission failed with %v, want ErrAttemptsExhausted", err)
+	}
+	if runs.Load() != 3 {
+		t.Errorf("the task ran %d times, want 3", runs.Load())
+	}
+	if len(deadLettered) != 2 || !errors.Is(deadLettered[1], ErrAttemptsExhausted) {
+		t.Errorf("dead-lettered %v, want the last run's error and then ErrAttemptsExhausted", deadLettered)
+	}
+}
+
+func TestReplayRefusesUnderAtMostOnce(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithResultPayloads(), WithDeliverySemantics(AtMostOnce))
+	results := pool.Results()
+	var runs atomic.Int32
+	pool.Submit(TaskFunc(func(context.Context) error {
+		runs.Add(1)
+		return errors.New("charged the card, then timed out")
+	}))
+	failed := <-results
+
+	handles, err := pool.Replay([]Result{failed})
+	if !errors.Is(err, ErrNotReplayable) || len(handles) != 0 {
+		t.Fatalf("Replay under AtMostOnce = %d handles, %v; want none and ErrNotReplayable", len(handles), err)
+	}
+	pool.Wait()
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("the task ran %d times, want once", got)
+	}
+}
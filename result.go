@@ -0,0 +1,159 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ! Result describes a task that has finished running, with enough timing and provenance to
+// ! attribute its latency after the fact.
+type Result struct {
+	TaskId   int
+	Err      error
+	WorkerId int //! The worker that ran the task; synchronous pools report 1.
+	Attempts int //! How many times the task ran, 1 unless it was retried.
+
+	EnqueuedAt   time.Time     //! When the task joined the queue; zero if it never queued, as in synchronous pools.
+	StartedAt    time.Time     //! When the first attempt started.
+	FinishedAt   time.Time     //! When the last attempt returned.
+	QueueWait    time.Duration //! StartedAt minus EnqueuedAt, or zero without an EnqueuedAt.
+	ExecDuration time.Duration //! FinishedAt minus StartedAt, retry backoffs included.
+
+	TraceId string //! Trace of the context given to SubmitCtx; empty without WithTraceExtractor.
+	SpanId  string //! Span of that context, likewise.
+
+	Timeout TimeoutFired //! Which timeout, if any, fired on the last attempt, see SubmitWithTimeouts.
+
+	Task Task //! The task that ran, for Replay; only set under WithResultPayloads.
+
+	identity *taskIdentity //! The task's identity, passed on by Replay.
+}
+
+// ! resultSink receives every Result the pool produces. close is called once by Close,
+// ! after the last worker has exited, so sinks can flush whatever they still hold.
+type resultSink interface {
+	add(result Result)
+	close()
+}
+
+// ! addResultSink attaches a sink to a running pool. Results of tasks that finished earlier are not replayed.
+// ! A sink added once Close has closed the others is closed straight away, as no result will reach it.
+func (pool *Pool) addResultSink(sink resultSink) {
+	pool.sinksMutex.Lock()
+	defer pool.sinksMutex.Unlock()
+	if pool.sinksClosed {
+		sink.close()
+		return
+	}
+	pool.resultSinks = append(pool.resultSinks, sink)
+}
+
+// ! publishResult hands a finished task's result to every configured sink, in submission
+// ! order under WithOrderedWindow.
+func (pool *Pool) publishResult(result Result) {
+	pool.orderedWindow.publish(result, pool.deliverResult)
+}
+
+// ! deliverResult passes a result to every configured sink.
+func (pool *Pool) deliverResult(result Result) {
+	pool.sinksMutex.RLock()
+	defer pool.sinksMutex.RUnlock()
+	for _, sink := range pool.resultSinks {
+		sink.add(result)
+	}
+}
+
+// ! closeResultSinks flushes and stops every configured sink.
+func (pool *Pool) closeResultSinks() {
+	pool.orderedWindow.lift(pool.deliverResult)
+	pool.sinksMutex.Lock()
+	defer pool.sinksMutex.Unlock()
+	pool.sinksClosed = true
+	for _, sink := range pool.resultSinks {
+		sink.close()
+	}
+}
+
+// ! WithResultBatcher buffers finished results and passes them to flush in batches, either
+// ! once maxBatch results have accumulated or maxWait after the first result of a batch,
+// ! whichever comes first. This amortizes expensive sinks such as bulk database inserts.
+// ! flush is always called from a single goroutine. Close flushes any partial batch.
+func WithResultBatcher(maxBatch int, maxWait time.Duration, flush func([]Result)) Option {
+	return func(pool *Pool) {
+		pool.resultSinks = append(pool.resultSinks, newResultBatcher(maxBatch, maxWait, flush))
+	}
+}
+
+// ! resultBatcher collects results on its own goroutine, running run, so workers never wait on flush.
+// ! New starts that goroutine once the configuration is known to be valid, see startResultSinks.
+type resultBatcher struct {
+	maxBatch int
+	maxWait  time.Duration
+	flush    func([]Result)
+	input    chan Result
+	finished chan struct{} //! Closed once the final batch has been flushed and run has returned.
+	closing  sync.Once
+}
+
+func newResultBatcher(maxBatch int, maxWait time.Duration, flush func([]Result)) *resultBatcher {
+	batcher := &resultBatcher{
+		maxBatch: maxBatch,
+		maxWait:  maxWait,
+		flush:    flush,
+		input:    make(chan Result, maxBatch),
+		finished: make(chan struct{}),
+	}
+	return batcher
+}
+
+func (batcher *resultBatcher) add(result Result) {
+	batcher.input <- result
+}
+
+func (batcher *resultBatcher) close() {
+	batcher.closing.Do(func() { close(batcher.input) })
+	<-batcher.finished
+}
+
+func (batcher *resultBatcher) run() {
+	var batch []Result
+	timer := time.NewTimer(batcher.maxWait)
+	timer.Stop()
+
+	flushBatch := func() {
+		timer.Stop()
+		if len(batch) > 0 {
+			batcher.flush(batch)
+			batch = nil
+		}
+	}
+
+	for {
+		select {
+		case result, ok := <-batcher.input:
+			if !ok {
+				flushBatch()
+				return
+			}
+			batch = append(batch, result)
+			if len(batch) == 1 {
+				timer.Reset(batcher.maxWait) //! The wait is measured from the first result of each batch.
+			}
+			if len(batch) >= batcher.maxBatch {
+				flushBatch()
+			}
+		case <-timer.C:
+			flushBatch()
+		}
+	}
+}
+
+// ! startResultSinks starts the goroutines of the sinks that have one. New calls it only after
+// ! validating the configuration, so a rejected pool leaves no goroutine behind.
+func (pool *Pool) startResultSinks() {
+	for _, sink := range pool.resultSinks {
+		if batcher, ok := sink.(*resultBatcher); ok {
+			pool.spawn(batcher.run, func() { close(batcher.finished) })
+		}
+	}
+}
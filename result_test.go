@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestResultReportsQueueWaitAndExecution(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock))
+	results := pool.Results()
+	start := clock.Now()
+	release := occupy(t, pool)
+	handle, _ := pool.Submit(TaskFunc(func(context.Context) error {
+		clock.advance(3 * time.Second)
+		return nil
+	}))
+	clock.advance(2 * time.Second)
+	close(release)
+	<-handle.Done()
+
+	result := <-results
+	if result.TaskId != handle.Id() {
+		result = <-results //! The first was the occupying task's.
+	}
+	if !result.EnqueuedAt.Equal(start) || !result.StartedAt.Equal(start.Add(2*time.Second)) ||
+		!result.FinishedAt.Equal(start.Add(5*time.Second)) {
+		t.Errorf("enqueued %v, started %v, finished %v, want 0s, 2s and 5s after %v",
+			result.EnqueuedAt, result.StartedAt, result.FinishedAt, start)
+	}
+	if result.QueueWait != 2*time.Second || result.ExecDuration != 3*time.Second {
+		t.Errorf("QueueWait %v and ExecDuration %v, want 2s and 3s", result.QueueWait, result.ExecDuration)
+	}
+}
+
+func TestSynchronousResultHasNoQueueWait(t *testing.T) {
+	pool := newTestPool(t, WithSynchronous())
+	results := pool.Results()
+	pool.Submit(noopTask)
+	result := <-results
+	if !result.EnqueuedAt.IsZero() || result.QueueWait != 0 || result.StartedAt.IsZero() {
+		t.Errorf("synchronous result enqueued %v with QueueWait %v after starting %v, want no queue at all",
+			result.EnqueuedAt, result.QueueWait, result.StartedAt)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ! Results returns a channel that receives the Result of every task that finishes from the
+// ! first call on; later calls return the same channel. It is closed once Close has finished.
+// ! A caller that never reads it must not stall the workers, so by default the channel is
+// ! buffered for as many results as the queue and the workers can hold, and results that do
+// ! not fit are dropped, counted in Stats as ResultsDropped, with a warning logged on the first
+// ! drop. WithStrictResultDelivery makes workers wait for the reader instead.
+func (pool *Pool) Results() <-chan Result {
+	pool.resultsOnce.Do(func() {
+		pool.results = &resultChannel{
+			pool:      pool,
+			output:    make(chan Result, pool.queueSize+pool.totalWorkers),
+			strict:    pool.strictResults,
+			warnAfter: pool.strictResultsWarnAfter,
+		}
+		pool.addResultSink(pool.results)
+	})
+	return pool.results.output
+}
+
+// ! Next returns the next Result from the Results channel, blocking until a task finishes,
+// ! for loops that read better as calls than as a channel range. It reports ok=false once
+// ! Close has finished and every result has been taken, and returns ctx.Err() if ctx ends
+// ! first. Next and Results read the same channel, so each result goes to only one of them,
+// ! and, as with Results, only tasks finishing after the first call to either are seen.
+// ! Pair it with WithStrictResultDelivery if a slow reader should hold the workers back
+// ! rather than lose results.
+func (pool *Pool) Next(ctx context.Context) (Result, bool, error) {
+	results := pool.Results()
+	select {
+	case result, ok := <-results:
+		return result, ok, nil
+	case <-ctx.Done():
+		return Result{}, false, ctx.Err()
+	}
+}
+
+// ! WithStrictResultDelivery makes Results deliver every result, with a worker waiting as long
+// ! as it takes for the reader to make room. Since a reader that never comes stalls the pool,
+// ! a warning naming the worker is logged each time a send has been blocked for warnAfter.
+// ! A warnAfter of zero or less waits silently.
+func WithStrictResultDelivery(warnAfter time.Duration) Option {
+	return func(pool *Pool) {
+		pool.strictResults = true
+		pool.strictResultsWarnAfter = warnAfter
+	}
+}
+
+// ! resultChannel is the sink behind Results.
+type resultChannel struct {
+	pool      *Pool
+	output    chan Result
+	strict    bool
+	warnAfter time.Duration
+	warned    atomic.Bool //! Whether the first drop has been logged.
+	closing   sync.Once
+}
+
+func (results *resultChannel) add(result Result) {
+	select {
+	case results.output <- result:
+		return
+	default:
+	}
+	if !results.strict {
+		results.pool.counters.resultsDropped.Add(1)
+		if results.warned.CompareAndSwap(false, true) {
+			results.pool.logger.Printf("the Results channel is full, probably because nobody reads it; dropping results, " +
+				"see Stats().ResultsDropped for how many, or use WithStrictResultDelivery to wait for the reader")
+		}
+		return
+	}
+	if results.warnAfter <= 0 {
+		results.output <- result
+		return
+	}
+	for {
+		timer := time.NewTimer(results.warnAfter)
+		select {
+		case results.output <- result:
+			timer.Stop()
+			return
+		case <-timer.C:
+			results.pool.logger.Printf("the result of task %d has waited %v for a reader of the Results channel; %s is stalled",
+				result.TaskId, results.warnAfter, results.pool.workerName(result.WorkerId))
+		}
+	}
+}
+
+func (results *resultChannel) close() {
+	results.closing.Do(func() { close(results.output) })
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestResultsDropsWhenNobodyReads(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2), WithQueueSize(2), WithLogger(log.New(io.Discard, "", 0)))
+	results := pool.Results()
+	for range 20 {
+		pool.SubmitFunc(func() {})
+	}
+	pool.Close() //! Must not hang although nobody reads the channel.
+
+	received := 0
+	for range results {
+		received++
+	}
+	if dropped := pool.Stats().ResultsDropped; received != 4 || dropped != 16 {
+		t.Fatalf("received %d results and dropped %d, want the 4 that fit the buffer and 16", received, dropped)
+	}
+	if late, open := <-pool.Results(); open {
+		t.Fatalf("Results after Close delivered %v", late)
+	}
+}
+
+func TestStrictResultsDeliversEverything(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithStrictResultDelivery(10*time.Millisecond),
+		WithLogger(log.New(io.Discard, "", 0)))
+	results := pool.Results()
+	go func() {
+		for range 30 {
+			pool.SubmitFunc(func() {})
+		}
+		pool.Close()
+	}()
+	time.Sleep(50 * time.Millisecond) //! Workers wait for the reader meanwhile.
+
+	received := 0
+	for range results {
+		received++
+	}
+	if received != 30 || pool.Stats().ResultsDropped != 0 {
+		t.Fatalf("received %d of 30 results, %d dropped", received, pool.Stats().ResultsDropped)
+	}
+}
+
+func TestNextPullsUntilClosed(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	pool.Results() //! Start collecting before anything can finish.
+	for range 3 {
+		pool.SubmitFunc(func() {})
+	}
+	go func() {
+		pool.Wait()
+		pool.Close()
+	}()
+
+	pulled := 0
+	for {
+		_, ok, err := pool.Next(context.Background())
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if !ok {
+			break
+		}
+		pulled++
+	}
+	if pulled != 3 {
+		t.Fatalf("pulled %d results, want 3", pulled)
+	}
+}
+
+func TestNextHonoursContext(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, ok, err := pool.Next(ctx); ok || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Next on an idle pool = ok %v, err %v; want the deadline", ok, err)
+	}
+}
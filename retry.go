@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ! WithRetryBackoff makes a failing task wait before each retry: initial before the first,
+// ! twice as long before the second, and so on, up to maxBackoff. The worker is occupied while
+// ! it waits. Shutdown interrupts the wait at once and gives up on the task. It only has an
+// ! effect together with WithRetry.
+func WithRetryBackoff(initial time.Duration, maxBackoff time.Duration) Option {
+	return func(pool *Pool) {
+		pool.retryBackoff = initial
+		pool.maxRetryBackoff = maxBackoff
+	}
+}
+
+// ! WithRetryJitter randomizes each retry backoff (see WithRetryBackoff) by shortening it by up
+// ! to fraction of its length, so that tasks which failed together do not all retry in the same
+// ! instant. fraction is clamped to the range 0 to 1; 1 gives "full jitter". The randomness comes
+// ! from WithRandSource.
+func WithRetryJitter(fraction float64) Option {
+	return func(pool *Pool) {
+		pool.retryJitter = min(max(fraction, 0), 1)
+	}
+}
+
+// ! WithRetryPredicate limits retries to errors that retryable accepts, so a permanent failure,
+// ! such as a 400 Bad Request, fails at once with its error instead of burning attempts, backoff
+// ! time and retry budget. It is checked before the retry budget is consulted, so rejected
+// ! errors never take from it. It only has an effect together with WithRetry.
+func WithRetryPredicate(retryable func(err error) bool) Option {
+	return func(pool *Pool) {
+		pool.retryPredicate = retryable
+	}
+}
+
+// ! backoff waits before the retry that follows the given failed attempt. It reports false if
+// ! the wait was cut short by Shutdown, by the pool aborting or by ctx, the task's context, being
+// ! cancelled, in which case there must be no retry.
+func (pool *Pool) backoff(ctx context.Context, attempt int) bool {
+	if pool.retryBackoff <= 0 {
+		return true
+	}
+	delay := pool.retryBackoff
+	for retry := 1; retry < attempt && delay < pool.maxRetryBackoff; retry++ {
+		delay *= 2
+	}
+	delay = min(delay, pool.maxRetryBackoff)
+	if pool.retryJitter > 0 {
+		delay -= time.Duration(float64(delay) * pool.retryJitter * pool.randomFloat())
+	}
+
+	elapsed := make(chan struct{})
+	timer := pool.clock.AfterFunc(delay, func() { close(elapsed) })
+	select {
+	case <-elapsed:
+		return true
+	case <-pool.shuttingDown:
+	case <-pool.poolContext.Done():
+	case <-ctx.Done(): //! Cancelled through its handle or CancelByTag.
+	}
+	timer.Stop()
+	return false
+}
+
+// ! shouldRetry reports whether a task that just failed its given attempt with err may run again,
+// ! and whether it may not only because the retry budget is spent.
+func (pool *Pool) shouldRetry(failedTask *task, attempt int, err error) (retry, budgetSpent bool) {
+	maxAttempts := pool.maxAttempts
+	if failedTask.maxAttempts > 0 {
+		maxAttempts = failedTask.maxAttempts
+	}
+	if attempt >= maxAttempts || pool.delivery == AtMostOnce || pool.poolContext.Err() != nil || pool.rethrownPanic.Load() != nil ||
+		pool.lifetimeExhausted(failedTask) {
+		return false, false
+	}
+	if pool.retryPredicate != nil && !pool.retryPredicate(err) {
+		return false, false
+	}
+	if pool.retryBudget != nil && !pool.retryBudget.take() {
+		return false, true
+	}
+	return true, false
+}
+
+// ! retryBudget is a sliding-window limit on retries shared by every task in the pool.
+type retryBudget struct {
+	mutex      sync.Mutex
+	clock      Clock
+	limit      int
+	window     time.Duration
+	retryTimes []time.Time //! When each retry inside the current window happened, oldest first.
+}
+
+func newRetryBudget(limit int, window time.Duration) *retryBudget {
+	return &retryBudget{clock: realClock{}, limit: limit, window: window}
+}
+
+// ! take spends one retry from the budget, reporting false if the window is already full.
+func (budget *retryBudget) take() bool {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	now := budget.clock.Now()
+	budget.expire(now)
+	if len(budget.retryTimes) >= budget.limit {
+		return false
+	}
+	budget.retryTimes = append(budget.retryTimes, now)
+	return true
+}
+
+// ! remaining reports how many retries are still allowed in the current window.
+func (budget *retryBudget) remaining() int {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	budget.expire(budget.clock.Now())
+	return budget.limit - len(budget.retryTimes)
+}
+
+// ! expire forgets retries that have slid out of the window.
+func (budget *retryBudget) expire(now time.Time) {
+	expired := 0
+	for expired < len(budget.retryTimes) && now.Sub(budget.retryTimes[expired]) >= budget.window {
+		expired++
+	}
+	budget.retryTimes = budget.retryTimes[expired:]
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errBadRequest = errors.New("400 bad request")
+
+func TestRetryPredicateSkipsPermanentErrors(t *testing.T) {
+	retryable := func(err error) bool { return !errors.Is(err, errBadRequest) }
+	pool := newTestPool(t, WithWorkers(1), WithRetry(4), WithRetryPredicate(retryable))
+
+	var permanentAttempts, transientAttempts atomic.Int32
+	permanent, _ := pool.Submit(TaskFunc(func(context.Context) error {
+		permanentAttempts.Add(1)
+		return errBadRequest
+	}))
+	transient, _ := pool.Submit(TaskFunc(func(context.Context) error {
+		if transientAttempts.Add(1) < 3 {
+			return errors.New("503 unavailable")
+		}
+		return nil
+	}))
+	pool.Wait()
+
+	if permanentAttempts.Load() != 1 || !errors.Is(permanent.Err(), errBadRequest) {
+		t.Fatalf("permanent error: %d attempts, err %v; want 1 attempt", permanentAttempts.Load(), permanent.Err())
+	}
+	if transientAttempts.Load() != 3 || transient.Err() != nil {
+		t.Fatalf("transient error: %d attempts, err %v; want success on the 3rd", transientAttempts.Load(), transient.Err())
+	}
+}
+
+func TestCancelDuringBackoffSkipsTheRetry(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithRetry(3), WithRetryBackoff(time.Hour, time.Hour))
+	var attempts atomic.Int32
+	failed := make(chan struct{}, 3)
+	handle, _ := pool.Submit(TaskFunc(func(context.Context) error {
+		attempts.Add(1)
+		failed <- struct{}{}
+		return errors.New("transient")
+	}))
+	<-failed
+	handle.Cancel() //! The task is now waiting out its backoff.
+	returnsWithin(t, time.Second, "a cancelled task's backoff", func() { <-handle.Done() })
+	if !handle.Cancelled() || attempts.Load() != 1 {
+		t.Fatalf("cancelled=%v after %d attempts, want a cancelled task that ran once", handle.Cancelled(), attempts.Load())
+	}
+}
+
+func TestRetryBudgetSendsDeniedTasksToTheDeadLetter(t *testing.T) {
+	clock := newFakeClock()
+	var mutex sync.Mutex
+	var deadLettered []error
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithRetry(3), WithRetryBudget(1, time.Minute),
+		WithMaxLifetimeAttempts(0, func(_ TaskInfo, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			deadLettered = append(deadLettered, err)
+		}))
+	if remaining := pool.Stats().RetryBudgetRemaining; remaining != 1 {
+		t.Fatalf("RetryBudgetRemaining = %d before any retry, want 1", remaining)
+	}
+	failure := errors.New("downstream unavailable")
+	var attempts atomic.Int64
+	handle, _ := pool.Submit(TaskFunc(func(context.Context) error {
+		attempts.Add(1)
+		return failure
+	}))
+	<-handle.Done()
+	if got := attempts.Load(); got != 2 {
+		t.Fatalf("ran %d attempts, want 2: the first and the one retry the budget allows", got)
+	}
+	mutex.Lock()
+	if len(deadLettered) != 1 || !errors.Is(deadLettered[0], failure) {
+		t.Fatalf("dead-lettered %v, want the task's final error", deadLettered)
+	}
+	mutex.Unlock()
+	if remaining := pool.Stats().RetryBudgetRemaining; remaining != 0 {
+		t.Fatalf("RetryBudgetRemaining = %d with the budget spent, want 0", remaining)
+	}
+
+	clock.advance(time.Minute)
+	if remaining := pool.Stats().RetryBudgetRemaining; remaining != 1 {
+		t.Fatalf("RetryBudgetRemaining = %d once the window passed, want 1", remaining)
+	}
+}
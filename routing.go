@@ -0,0 +1,59 @@
+package main
+
+import (
+	"maps"
+	"slices"
+)
+
+// ! SetRouteWeights sets how SubmitRouted spreads tasks over worker groups, a group being the
+// ! workers that advertise a capability (see WithWorkerCapabilities), for canary and A/B style
+// ! rollouts: with weights {"new-path": 5, "old-path": 95}, about 5% of routed tasks run on the
+// ! workers initialized with the new code path. Weights are relative and need not sum to
+// ! anything; groups with a weight of zero or less get nothing. It can be called at any time,
+// ! and tasks routed before keep the group they were given. The map is copied.
+func (pool *Pool) SetRouteWeights(weights map[string]float64) {
+	cloned := maps.Clone(weights)
+	pool.mutex.Lock()
+	pool.routeWeights = cloned
+	pool.mutex.Unlock()
+}
+
+// ! SubmitRouted is SubmitWeightedRandom with the weights last set by SetRouteWeights. Without
+// ! any, the task goes to any worker, as with Submit.
+func (pool *Pool) SubmitRouted(work Task) (*TaskHandle, error) {
+	pool.mutex.Lock()
+	weights := pool.routeWeights
+	pool.mutex.Unlock()
+	return pool.SubmitWeightedRandom(work, weights)
+}
+
+// ! SubmitWeightedRandom picks a worker group at random, in proportion to weights, and
+// ! submits the task with SubmitRequiring for that group's capability. The draw comes from
+// ! WithRandSource. If no group has a positive weight, the task goes to any worker.
+func (pool *Pool) SubmitWeightedRandom(work Task, weights map[string]float64) (*TaskHandle, error) {
+	return pool.SubmitRequiring(pool.pickGroup(weights), work)
+}
+
+// ! pickGroup draws a group in proportion to its weight, or returns "" if none has a positive one.
+func (pool *Pool) pickGroup(weights map[string]float64) string {
+	groups := slices.Sorted(maps.Keys(weights)) //! A stable order keeps seeded draws reproducible.
+	total := 0.0
+	for _, group := range groups {
+		total += max(weights[group], 0)
+	}
+	if total <= 0 {
+		return ""
+	}
+	draw := pool.randomFloat() * total
+	picked := ""
+	for _, group := range groups {
+		if weight := weights[group]; weight > 0 {
+			picked = group
+			if draw < weight {
+				break
+			}
+			draw -= weight
+		}
+	}
+	return picked
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// ! routedToCanary submits count routed tasks and reports how many ran on worker 1, the canary.
+func routedToCanary(pool *Pool, results <-chan Result, count int) int {
+	for range count {
+		pool.SubmitRouted(noopTask)
+	}
+	onCanary := 0
+	for range count {
+		if result := <-results; result.WorkerId == 1 {
+			onCanary++
+		}
+	}
+	return onCanary
+}
+
+func TestSubmitRoutedFollowsWeights(t *testing.T) {
+	capabilities := func(workerId int) []string {
+		if workerId == 1 {
+			return []string{"canary"}
+		}
+		return []string{"stable"}
+	}
+	pool := newTestPool(t, WithWorkers(3), WithQueueSize(1000), WithWorkerCapabilities(capabilities),
+		WithRandSource(rand.NewPCG(1, 2)))
+	results := pool.Results()
+
+	pool.SetRouteWeights(map[string]float64{"canary": 1, "stable": 9})
+	if canary := routedToCanary(pool, results, 1000); canary < 60 || canary > 140 {
+		t.Fatalf("%d of 1000 tasks ran on the canary at a 10%% weight", canary)
+	}
+	pool.SetRouteWeights(map[string]float64{"canary": 0, "stable": 1})
+	if canary := routedToCanary(pool, results, 100); canary != 0 {
+		t.Fatalf("%d tasks ran on the canary after its weight dropped to zero", canary)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"time"
+)
+
+// ! SubmitAfter accepts a task now but only queues it once delay has elapsed. The delay is
+// ! measured on the monotonic clock, so adjusting the system's wall clock in the meantime
+// ! does not make the task fire early or late. The task counts as outstanding from the start,
+// ! so Wait waits for it; Close cancels it if it is not due yet.
+func (pool *Pool) SubmitAfter(delay time.Duration, work Task) (*TaskHandle, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.closed {
+		return nil, pool.reject(RejectedPoolClosed, ErrPoolClosed)
+	}
+	delayedTask := &task{work: work, run: runTask(work)}
+	pool.register(delayedTask)
+	pool.scheduled[delayedTask] = pool.clock.AfterFunc(delay, func() { pool.fireScheduled(delayedTask) })
+	return delayedTask.handle, nil
+}
+
+// ! SubmitAt is like SubmitAfter for an absolute wall-clock time. The time is converted into
+// ! a delay once, when SubmitAt is called, by comparing it with the current wall-clock time;
+// ! from then on the wait is monotonic like SubmitAfter's. A wall-clock step after the call
+// ! therefore does not move the task: it still runs after the originally computed interval.
+// ! Times in the past run the task right away.
+func (pool *Pool) SubmitAt(at time.Time, work Task) (*TaskHandle, error) {
+	//! Dropping the monotonic reading makes Sub compare wall-clock times on both sides.
+	return pool.SubmitAfter(at.Round(0).Sub(pool.clock.Now().Round(0)), work)
+}
+
+// ! fireScheduled moves a delayed task onto the queue once its timer has fired, waiting
+// ! for queue space like Submit does.
+func (pool *Pool) fireScheduled(delayedTask *task) {
+	pool.mutex.Lock()
+	if _, pending := pool.scheduled[delayedTask]; !pending {
+		pool.mutex.Unlock() //! Close got to it first.
+		return
+	}
+	delete(pool.scheduled, delayedTask)
+	for !pool.closed && pool.queue.len() >= pool.queueSize {
+		pool.spaceAvailable.Wait()
+	}
+	if pool.closed {
+		pool.mutex.Unlock()
+		pool.cancelTask(delayedTask)
+		return
+	}
+	if pool.synchronous {
+		pool.mutex.Unlock()
+		pool.execute(delayedTask, newWorkerSlot(syncWorkerId))
+		return
+	}
+	pool.push(delayedTask)
+	pool.mutex.Unlock()
+	pool.announceEnqueued(delayedTask)
+}
+
+// ! cancelScheduled cancels every delayed task whose timer has not fired yet.
+func (pool *Pool) cancelScheduled() {
+	pool.mutex.Lock()
+	pending := pool.scheduled
+	pool.scheduled = make(map[*task]Timer)
+	pool.mutex.Unlock()
+
+	for delayedTask, timer := range pending {
+		timer.Stop()
+		pool.cancelTask(delayedTask)
+	}
+}
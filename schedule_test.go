@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! fakeClock is a Clock whose wall-clock reading and monotonic time move separately, so tests
+// ! can step the wall clock the way NTP does without any real time passing.
+type fakeClock struct {
+	mutex   sync.Mutex
+	wall    time.Time     //! What Now returns.
+	elapsed time.Duration //! Monotonic time since the clock was created.
+	timers  []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	deadline time.Duration //! Monotonic time at which the timer fires.
+	run      func()
+	stopped  bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{wall: time.Date(2026, 3, 29, 1, 30, 0, 0, time.UTC)}
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	return clock.wall
+}
+
+func (clock *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	timer := &fakeTimer{clock: clock, deadline: clock.elapsed + d, run: f}
+	clock.timers = append(clock.timers, timer)
+	return timer
+}
+
+func (timer *fakeTimer) Stop() bool {
+	timer.clock.mutex.Lock()
+	defer timer.clock.mutex.Unlock()
+	wasPending := !timer.stopped
+	timer.stopped = true
+	return wasPending
+}
+
+// ! stepWall moves only the wall clock, as an NTP correction or a DST change would.
+func (clock *fakeClock) stepWall(step time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.wall = clock.wall.Add(step)
+}
+
+// ! advance lets d of real time pass and runs the timers that fall due.
+func (clock *fakeClock) advance(d time.Duration) {
+	clock.mutex.Lock()
+	clock.elapsed += d
+	clock.wall = clock.wall.Add(d)
+	var due []*fakeTimer
+	for _, timer := range clock.timers {
+		if !timer.stopped && timer.deadline <= clock.elapsed {
+			timer.stopped = true
+			due = append(due, timer)
+		}
+	}
+	clock.mutex.Unlock()
+	for _, timer := range due {
+		timer.run()
+	}
+}
+
+// ! pending counts the timers that have neither fired nor been stopped.
+func (clock *fakeClock) pending() int {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	count := 0
+	for _, timer := range clock.timers {
+		if !timer.stopped {
+			count++
+		}
+	}
+	return count
+}
+
+// ! finished reports whether the task behind handle completes shortly.
+func finished(handle *TaskHandle) bool {
+	select {
+	case <-handle.Done():
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+var noopTask = TaskFunc(func(context.Context) error { return nil })
+
+func TestSubmitAfterIgnoresWallClockSteps(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithClock(clock))
+	handle, err := pool.SubmitAfter(10*time.Second, noopTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.stepWall(time.Hour) //! A forward step must not make the task fire early.
+	if finished(handle) {
+		t.Fatal("task fired after the wall clock stepped forward")
+	}
+	clock.stepWall(-2 * time.Hour) //! Nor must a backward step push it out.
+	clock.advance(9 * time.Second)
+	if finished(handle) {
+		t.Fatal("task fired before its delay had elapsed")
+	}
+	clock.advance(time.Second)
+	if !finished(handle) {
+		t.Fatal("task did not fire once its delay had elapsed")
+	}
+}
+
+func TestSubmitAtConvertsOnceThenRunsMonotonically(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithClock(clock))
+	handle, err := pool.SubmitAt(clock.Now().Add(time.Minute), noopTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//! The target moves by the step on the wall clock, but the interval was fixed at submit time.
+	clock.stepWall(time.Hour)
+	clock.advance(59 * time.Second)
+	if finished(handle) {
+		t.Fatal("task fired before the interval computed at submit time")
+	}
+	clock.advance(time.Second)
+	if !finished(handle) {
+		t.Fatal("task did not fire after the interval computed at submit time")
+	}
+}
+
+func TestSubmitAtInThePastRunsRightAway(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithClock(clock))
+	handle, err := pool.SubmitAt(clock.Now().Add(-time.Minute), noopTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(0)
+	if !finished(handle) {
+		t.Fatal("task due in the past did not run")
+	}
+}
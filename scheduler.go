@@ -0,0 +1,169 @@
+package main
+
+import (
+	"container/heap"
+)
+
+// ! Scheduler decides the order in which queued tasks are dispatched, for policies the
+// ! built-in queue does not offer, such as fair share between tenants. See WithScheduler.
+// !
+// ! The pool calls a Scheduler only while holding its own mutex, so calls never overlap and
+// ! the Scheduler needs no locking of its own, but it must not block and must not call back
+// ! into the pool. Dequeue must return a task that was enqueued and not yet dequeued, each
+// ! exactly once, and report false only when it holds none; the pool calls it only while Len
+// ! is positive. Tasks the pool withdraws from the queue, when they are cancelled for
+// ! instance, stay in the Scheduler and are discarded by the pool when Dequeue returns them,
+// ! so Len may count them.
+type Scheduler interface {
+	Enqueue(queued *QueuedTask)
+	Dequeue() (*QueuedTask, bool)
+	Len() int
+}
+
+// ! QueuedTask is a task waiting in a Scheduler.
+type QueuedTask struct {
+	queued *task
+	info   TaskInfo
+}
+
+// ! Info describes the task as it was when it was queued; a later TaskHandle.Boost is not
+// ! reflected.
+func (queued *QueuedTask) Info() TaskInfo {
+	return queued.info
+}
+
+// ! WithScheduler replaces the built-in queue order with a custom one. Priorities,
+// ! WithLIFO and anti-starvation are then up to the Scheduler, which sees a task's priority
+// ! in its Info. Tasks that require a worker capability, see SubmitRequiring, do not go
+// ! through the Scheduler, and a worker runs them only when the Scheduler has nothing queued.
+// ! NewFIFOScheduler, NewLIFOScheduler and NewPriorityScheduler are the built-in orders as
+// ! Schedulers, to start from or to wrap.
+func WithScheduler(scheduler Scheduler) Option {
+	return func(pool *Pool) {
+		pool.queue.scheduler = scheduler
+		pool.queue.scheduled = make(map[*task]*QueuedTask)
+	}
+}
+
+// ! pushScheduled hands a task to the custom scheduler.
+func (queue *taskQueue) pushScheduled(newTask *task) {
+	queued := &QueuedTask{queued: newTask, info: infoOf(newTask)}
+	newTask.queueIndex = 0 //! Not a heap position, it only marks the task as queued.
+	queue.scheduled[newTask] = queued
+	queue.scheduler.Enqueue(queued)
+}
+
+// ! popScheduled returns the task the custom scheduler picks next, skipping tasks that have
+// ! left the queue meanwhile, or nil if none is queued.
+func (queue *taskQueue) popScheduled() *task {
+	for len(queue.scheduled) > 0 && queue.scheduler.Len() > 0 {
+		queued, ok := queue.scheduler.Dequeue()
+		if !ok {
+			break
+		}
+		if queue.scheduled[queued.queued] != queued {
+			continue //! Withdrawn while it waited.
+		}
+		delete(queue.scheduled, queued.queued)
+		queue.forget(queued.queued)
+		return queued.queued
+	}
+	return nil
+}
+
+// ! NewFIFOScheduler returns a Scheduler that dispatches tasks in the order they were queued,
+// ! whatever their priority.
+func NewFIFOScheduler() Scheduler {
+	return &fifoScheduler{}
+}
+
+type fifoScheduler struct {
+	queued []*QueuedTask
+}
+
+func (scheduler *fifoScheduler) Enqueue(queued *QueuedTask) {
+	scheduler.queued = append(scheduler.queued, queued)
+}
+
+func (scheduler *fifoScheduler) Dequeue() (*QueuedTask, bool) {
+	if len(scheduler.queued) == 0 {
+		return nil, false
+	}
+	next := scheduler.queued[0]
+	scheduler.queued[0] = nil
+	scheduler.queued = scheduler.queued[1:]
+	return next, true
+}
+
+func (scheduler *fifoScheduler) Len() int { return len(scheduler.queued) }
+
+// ! NewLIFOScheduler returns a Scheduler that dispatches the most recently queued task first,
+// ! whatever their priority.
+func NewLIFOScheduler() Scheduler {
+	return &lifoScheduler{}
+}
+
+type lifoScheduler struct {
+	queued []*QueuedTask
+}
+
+func (scheduler *lifoScheduler) Enqueue(queued *QueuedTask) {
+	scheduler.queued = append(scheduler.queued, queued)
+}
+
+func (scheduler *lifoScheduler) Dequeue() (*QueuedTask, bool) {
+	last := len(scheduler.queued) - 1
+	if last < 0 {
+		return nil, false
+	}
+	next := scheduler.queued[last]
+	scheduler.queued[last] = nil
+	scheduler.queued = scheduler.queued[:last]
+	return next, true
+}
+
+func (scheduler *lifoScheduler) Len() int { return len(scheduler.queued) }
+
+// ! NewPriorityScheduler returns a Scheduler that dispatches by priority, highest first, and
+// ! in submission order among equal priorities, like the built-in queue without
+// ! anti-starvation.
+func NewPriorityScheduler() Scheduler {
+	return &priorityScheduler{}
+}
+
+type priorityScheduler []*QueuedTask
+
+func (scheduler *priorityScheduler) Enqueue(queued *QueuedTask) { heap.Push(scheduler, queued) }
+
+func (scheduler *priorityScheduler) Dequeue() (*QueuedTask, bool) {
+	if len(*scheduler) == 0 {
+		return nil, false
+	}
+	return heap.Pop(scheduler).(*QueuedTask), true
+}
+
+func (scheduler priorityScheduler) Len() int { return len(scheduler) }
+
+func (scheduler priorityScheduler) Less(i, j int) bool {
+	a, b := scheduler[i].info, scheduler[j].info
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.Id < b.Id
+}
+
+func (scheduler priorityScheduler) Swap(i, j int) {
+	scheduler[i], scheduler[j] = scheduler[j], scheduler[i]
+}
+
+func (scheduler *priorityScheduler) Push(value any) {
+	*scheduler = append(*scheduler, value.(*QueuedTask))
+}
+
+func (scheduler *priorityScheduler) Pop() any {
+	old := *scheduler
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	*scheduler = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// ! runInSchedulerOrder blocks the pool's only worker, submits the tasks, and returns the order
+// ! their ids ran in once the worker is let go. submit receives a task that records its id.
+func runInSchedulerOrder(t *testing.T, scheduler Scheduler, submit func(pool *Pool, record func(id int) Task)) []int {
+	t.Helper()
+	pool := newTestPool(t, WithWorkers(1), WithScheduler(scheduler))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+
+	var mutex sync.Mutex
+	var ran []int
+	submit(pool, func(id int) Task {
+		return TaskFunc(func(context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			ran = append(ran, id)
+			return nil
+		})
+	})
+	close(release)
+	pool.Wait()
+	mutex.Lock()
+	defer mutex.Unlock()
+	return ran
+}
+
+func TestSchedulerDecidesDispatchOrder(t *testing.T) {
+	ran := runInSchedulerOrder(t, NewLIFOScheduler(), func(pool *Pool, record func(int) Task) {
+		for id := 1; id <= 4; id++ {
+			pool.Submit(record(id))
+		}
+	})
+	if want := []int{4, 3, 2, 1}; !slices.Equal(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestPrioritySchedulerBreaksTiesInSubmissionOrder(t *testing.T) {
+	ran := runInSchedulerOrder(t, NewPriorityScheduler(), func(pool *Pool, record func(int) Task) {
+		pool.SubmitWithPriority(1, record(1))
+		pool.SubmitWithPriority(5, record(2))
+		pool.SubmitWithPriority(1, record(3))
+		pool.SubmitWithPriority(5, record(4))
+	})
+	if want := []int{2, 4, 1, 3}; !slices.Equal(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestSchedulerSkipsWithdrawnTasks(t *testing.T) {
+	ran := runInSchedulerOrder(t, NewFIFOScheduler(), func(pool *Pool, record func(int) Task) {
+		pool.Submit(record(1))
+		cancelled, _ := pool.Submit(record(2))
+		pool.Submit(record(3))
+		cancelled.Cancel()
+		<-cancelled.Done()
+	})
+	if want := []int{1, 3}; !slices.Equal(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// ! RequestScope groups the tasks of one request fanned out on a shared pool, see
+// ! WithRequestScope.
+type RequestScope struct {
+	pool    *Pool
+	ctx     context.Context
+	mutex   sync.Mutex
+	handles []*TaskHandle //! Every task submitted to the scope, in submission order.
+}
+
+// ! WithRequestScope starts a scope for the tasks of one request, typically with an HTTP
+// ! handler's r.Context(): every task submitted through the scope is cancelled, as by its
+// ! handle's Cancel, once ctx is done, and the scope's Wait waits for its tasks alone. Each
+// ! task is watched by a goroutine that exits when the task finishes or ctx ends, whichever
+// ! comes first, so a cancelled request leaves nothing behind. A scope remembers every task
+// ! submitted to it, so it is meant to live as long as a request, not as long as the pool.
+func (pool *Pool) WithRequestScope(ctx context.Context) *RequestScope {
+	return &RequestScope{pool: pool, ctx: ctx}
+}
+
+// ! Submit is SubmitCtx under the scope's context: it fails with the context's error once the
+// ! request has ended, including while it waits for queue space, and the task is cancelled if
+// ! the request ends before the task does.
+func (scope *RequestScope) Submit(work Task) (*TaskHandle, error) {
+	if err := scope.ctx.Err(); err != nil {
+		return nil, err
+	}
+	handle, err := scope.pool.SubmitCtx(scope.ctx, work)
+	if err != nil {
+		return nil, err
+	}
+	scope.mutex.Lock()
+	scope.handles = append(scope.handles, handle)
+	scope.mutex.Unlock()
+	scope.pool.spawn(func() {
+		select {
+		case <-handle.done:
+		case <-scope.ctx.Done():
+			handle.Cancel()
+		}
+	}, nil)
+	return handle, nil
+}
+
+// ! Wait blocks until every task submitted to the scope so far has finished, and returns the
+// ! error of the earliest submitted one that failed or was cancelled, or nil.
+func (scope *RequestScope) Wait() error {
+	scope.mutex.Lock()
+	handles := scope.handles
+	scope.mutex.Unlock()
+	var first error
+	for _, handle := range handles {
+		<-handle.done
+		if err := handle.Err(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestScopeCancelsWithItsContext(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	baseline := pool.GoroutineCount()
+	release := make(chan struct{})
+	other, _ := pool.SubmitFunc(func() { <-release }) //! Outside the scope.
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scope := pool.WithRequestScope(ctx)
+	for range 5 {
+		if _, err := scope.Submit(TaskFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	cancel()
+	var err error
+	returnsWithin(t, time.Second, "scope.Wait", func() { err = scope.Wait() })
+	if err == nil {
+		t.Fatalf("Wait returned nil for a cancelled scope")
+	}
+	if _, err := scope.Submit(noopTask); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Submit after the request ended: got %v, want context.Canceled", err)
+	}
+	select {
+	case <-other.Done():
+		t.Fatalf("a task outside the scope finished with it")
+	default:
+	}
+	close(release)
+	pool.Wait()
+	for deadline := time.Now().Add(time.Second); pool.GoroutineCount() > baseline; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left, started with %d", pool.GoroutineCount(), baseline)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRequestScopeWaitsOnlyForItsTasks(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	release := make(chan struct{})
+	defer close(release)
+	pool.SubmitFunc(func() { <-release })
+	scope := pool.WithRequestScope(context.Background())
+	failure := errors.New("boom")
+	scope.Submit(noopTask)
+	scope.Submit(TaskFunc(func(context.Context) error { return failure }))
+	var err error
+	returnsWithin(t, time.Second, "scope.Wait", func() { err = scope.Wait() })
+	if !errors.Is(err, failure) {
+		t.Fatalf("Wait: got %v, want %v", err, failure)
+	}
+}
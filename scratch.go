@@ -0,0 +1,47 @@
+package main
+
+// ! maxScratchBuffers bounds how many released buffers a worker keeps around for reuse.
+const maxScratchBuffers = 8
+
+// ! WorkerScratch is per-worker scratch space handed to tasks submitted with SubmitWithScratch.
+// ! It behaves like a sync.Pool of byte buffers, except that it belongs to a single worker:
+// ! only one task uses it at a time, so it needs no synchronization and never contends
+// ! with other workers. Tasks must not keep buffers, or the scratch itself, after returning.
+type WorkerScratch struct {
+	workerId int
+	buffers  [][]byte //! Released buffers available for reuse.
+}
+
+func newWorkerScratch(workerId int) *WorkerScratch {
+	return &WorkerScratch{workerId: workerId}
+}
+
+// ! WorkerId returns the id of the worker that owns this scratch space.
+func (scratch *WorkerScratch) WorkerId() int {
+	return scratch.workerId
+}
+
+// ! Buffer returns a zero-length buffer with at least the given capacity, reusing a
+// ! released one when possible.
+func (scratch *WorkerScratch) Buffer(capacity int) []byte {
+	for index := len(scratch.buffers) - 1; index >= 0; index-- {
+		buffer := scratch.buffers[index]
+		if cap(buffer) < capacity {
+			continue
+		}
+		last := len(scratch.buffers) - 1
+		scratch.buffers[index] = scratch.buffers[last]
+		scratch.buffers[last] = nil
+		scratch.buffers = scratch.buffers[:last]
+		return buffer[:0]
+	}
+	return make([]byte, 0, capacity)
+}
+
+// ! Release hands a buffer back so a later task on the same worker can reuse it.
+func (scratch *WorkerScratch) Release(buffer []byte) {
+	if buffer == nil || len(scratch.buffers) >= maxScratchBuffers {
+		return
+	}
+	scratch.buffers = append(scratch.buffers, buffer[:0])
+}
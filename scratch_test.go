@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+// ! benchmarkBufferSize is a variable so the compiler cannot place the buffers on the stack.
+var benchmarkBufferSize = 64 << 10
+
+// ! fillBuffer stands in for a task that builds a payload in a temporary buffer.
+func fillBuffer(buffer []byte) []byte {
+	for index := 0; index < 256; index++ {
+		buffer = append(buffer, byte(index))
+	}
+	return buffer
+}
+
+func BenchmarkBufferPerTask(b *testing.B) {
+	pool, err := New(WithWorkers(4), WithQueueSize(64))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		pool.SubmitFunc(func() {
+			fillBuffer(make([]byte, 0, benchmarkBufferSize))
+		})
+	}
+	pool.Close()
+}
+
+func BenchmarkWorkerScratch(b *testing.B) {
+	pool, err := New(WithWorkers(4), WithQueueSize(64))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		pool.SubmitWithScratch(func(scratch *WorkerScratch) {
+			scratch.Release(fillBuffer(scratch.Buffer(benchmarkBufferSize)))
+		})
+	}
+	pool.Close()
+}
+
+func TestWorkerScratchReusesBuffers(t *testing.T) {
+	scratch := newWorkerScratch(1)
+	first := scratch.Buffer(128)
+	scratch.Release(first)
+	second := scratch.Buffer(64)
+	if cap(second) != cap(first) || &second[:1][0] != &first[:1][0] {
+		t.Fatalf("Buffer did not reuse the released buffer")
+	}
+	if len(scratch.Buffer(256)) != 0 {
+		t.Fatalf("Buffer returned a non-empty buffer")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// ! ShardedPool partitions keyed work across independent pools, for submission rates at which
+// ! a single shared queue becomes the bottleneck, and to isolate noisy keys: every key always
+// ! goes to the same shard, and a shard's backlog, admission limits and workers are its own, so
+// ! one hot key only slows the keys that share its shard. Tasks of one key therefore keep the
+// ! order they get from their shard's queue.
+type ShardedPool struct {
+	shards []*Pool
+}
+
+// ! NewShardedPool creates shards pools, each configured with options, so WithWorkers and
+// ! WithQueueSize apply per shard. A name given with WithName is suffixed with the shard's
+// ! index in Shards, so shard 0 of "orders" registers as "orders/0". Likewise each shard spills
+// ! to its own subdirectory of the WithDiskSpill directory, named after its index, so keep the
+// ! number of shards across restarts for every spilled task to be recovered. It returns
+// ! ErrNoShards for fewer than one shard; if New fails for a shard, the shards created so far
+// ! are closed and its error is returned.
+func NewShardedPool(shards int, options ...Option) (*ShardedPool, error) {
+	if shards < 1 {
+		return nil, ErrNoShards
+	}
+	sharded := &ShardedPool{shards: make([]*Pool, 0, shards)}
+	for index := range shards {
+		shard, err := New(append(options[:len(options):len(options)], withShardIndex(index))...)
+		if err != nil {
+			sharded.Close()
+			return nil, err
+		}
+		sharded.shards = append(sharded.shards, shard)
+	}
+	return sharded, nil
+}
+
+// ! withShardIndex tells a shard's pool its index, once the shared options have been applied.
+func withShardIndex(index int) Option {
+	return func(pool *Pool) {
+		pool.name = memberName(pool.name, index)
+		if pool.diskSpill != nil {
+			pool.diskSpill.dir = filepath.Join(pool.diskSpill.dir, strconv.Itoa(index))
+		}
+	}
+}
+
+// ! memberName is the registry name of one of several pools created from the same options.
+func memberName(name string, member int) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d", name, member)
+}
+
+// ! Submit submits work to the shard that owns key, see Pool.Submit.
+func (sharded *ShardedPool) Submit(key string, work Task) (*TaskHandle, error) {
+	return sharded.Shard(key).Submit(work)
+}
+
+// ! Shard returns the pool that owns key, for the Pool methods ShardedPool does not mirror.
+func (sharded *ShardedPool) Shard(key string) *Pool {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	return sharded.shards[hash.Sum64()%uint64(len(sharded.shards))]
+}
+
+// ! Shards returns every shard, in a stable order.
+func (sharded *ShardedPool) Shards() []*Pool {
+	return append([]*Pool(nil), sharded.shards...)
+}
+
+// ! ShardStats returns each shard's Stats, in the order of Shards, for spotting an unbalanced
+// ! key distribution.
+func (sharded *ShardedPool) ShardStats() []Stats {
+	stats := make([]Stats, len(sharded.shards))
+	for index, shard := range sharded.shards {
+		stats[index] = shard.Stats()
+	}
+	return stats
+}
+
+// ! Stats sums up the shards' Stats. ConcurrencyLimit and RetryBudgetRemaining are summed over
+// ! the shards that have them, or stay -1 when none does.
+func (sharded *ShardedPool) Stats() Stats {
+	total := Stats{ConcurrencyLimit: -1, RetryBudgetRemaining: -1}
+	for _, shard := range sharded.ShardStats() {
+		total.Submitted += shard.Submitted
+		total.Completed += shard.Completed
+		total.Failed += shard.Failed
+		total.Cancelled += shard.Cancelled
+		total.Skipped += shard.Skipped
+		total.Shed += shard.Shed
+		total.Expired += shard.Expired
+		total.Retries += shard.Retries
+		total.Panics += shard.Panics
+		total.Leaked += shard.Leaked
+		total.LeakedRunning += shard.LeakedRunning
+		total.QuarantinedWorkers += shard.QuarantinedWorkers
+		total.SubmitBlocked += shard.SubmitBlocked
+		total.SubmitBlockedTotal += shard.SubmitBlockedTotal
+		total.BucketDropped += shard.BucketDropped
+		total.BucketDelayed += shard.BucketDelayed
+		total.ResultsDropped += shard.ResultsDropped
+		total.CPUTime += shard.CPUTime
+		total.ConcurrencyLimit = addLimit(total.ConcurrencyLimit, shard.ConcurrencyLimit)
+		total.RetryBudgetRemaining = addLimit(total.RetryBudgetRemaining, shard.RetryBudgetRemaining)
+	}
+	return total
+}
+
+// ! addLimit adds two Stats values that use -1 for "not configured".
+func addLimit(total, shard int) int {
+	switch {
+	case shard < 0:
+		return total
+	case total < 0:
+		return shard
+	default:
+		return total + shard
+	}
+}
+
+// ! Wait blocks until every shard has been idle, see Pool.Wait. Shards are waited for one after
+// ! the other, so a shard may have taken on new work by the time Wait returns.
+func (sharded *ShardedPool) Wait() {
+	for _, shard := range sharded.shards {
+		shard.Wait()
+	}
+}
+
+// ! Shutdown shuts every shard down in parallel under the same ctx, see Pool.Shutdown, and
+// ! returns the tasks all of them gave up on, with the first shard's error.
+func (sharded *ShardedPool) Shutdown(ctx context.Context) ([]TaskInfo, error) {
+	unprocessed := make([][]TaskInfo, len(sharded.shards))
+	errs := make([]error, len(sharded.shards))
+	var shutdowns sync.WaitGroup
+	for index, shard := range sharded.shards {
+		shutdowns.Go(func() { unprocessed[index], errs[index] = shard.shutdownCollecting(ctx) })
+	}
+	shutdowns.Wait()
+	sharded.rethrowPanic()
+
+	var all []TaskInfo
+	var firstErr error
+	for index := range sharded.shards {
+		all = append(all, unprocessed[index]...)
+		if firstErr == nil {
+			firstErr = errs[index]
+		}
+	}
+	return all, firstErr
+}
+
+// ! Close closes every shard in parallel, see Pool.Close.
+func (sharded *ShardedPool) Close() {
+	var closes sync.WaitGroup
+	for _, shard := range sharded.shards {
+		closes.Go(shard.closeAndWait)
+	}
+	closes.Wait()
+	sharded.rethrowPanic()
+}
+
+// ! rethrowPanic re-raises, on the caller's goroutine, a panic a shard holds under the Rethrow
+// ! policy, see Pool.Close.
+func (sharded *ShardedPool) rethrowPanic() {
+	for _, shard := range sharded.shards {
+		shard.rethrowPanic()
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShardedPoolIsolatesKeys(t *testing.T) {
+	sharded, err := NewShardedPool(4, WithWorkers(1))
+	if err != nil {
+		t.Fatalf("NewShardedPool: %v", err)
+	}
+	hot := sharded.Shard("hot")
+	cold := "cold"
+	for index := 0; sharded.Shard(cold) == hot; index++ {
+		cold = "cold" + string(rune('a'+index))
+	}
+
+	release := make(chan struct{})
+	sharded.Submit("hot", TaskFunc(func(context.Context) error { <-release; return nil }))
+	sharded.Submit("hot", noopTask) //! Queued behind the blocked task.
+	coldHandle, _ := sharded.Submit(cold, noopTask)
+	returnsWithin(t, time.Second, "a task on another shard", func() { <-coldHandle.Done() })
+
+	close(release)
+	sharded.Wait()
+	if stats := sharded.Stats(); stats.Submitted != 3 || stats.Completed != 3 {
+		t.Errorf("aggregate stats submitted %d and completed %d, want 3 and 3", stats.Submitted, stats.Completed)
+	}
+	if perShard := sharded.ShardStats(); len(perShard) != 4 {
+		t.Errorf("got stats for %d shards, want 4", len(perShard))
+	}
+	if _, err := sharded.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+	if _, err := sharded.Submit("hot", noopTask); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit after Shutdown returned %v, want ErrPoolClosed", err)
+	}
+	if _, err := NewShardedPool(0); !errors.Is(err, ErrNoShards) {
+		t.Errorf("NewShardedPool(0) returned %v, want ErrNoShards", err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"time"
+)
+
+// ! WithLoadShedding protects important work during a traffic surge. Once the queue has held
+// ! more than queueThreshold tasks for at least window, the pool is overloaded: tasks with a
+// ! priority below minPriority are rejected by Submit with ErrTaskShed, and those already
+// ! queued are dropped with ErrTaskShed when a worker reaches them instead of being run.
+// ! Shedding stops as soon as the queue is back at or below queueThreshold. Tasks submitted
+// ! without a priority count as priority 0. Shed tasks are counted in Stats.
+func WithLoadShedding(minPriority int, queueThreshold int, window time.Duration) Option {
+	return func(pool *Pool) {
+		pool.loadShedding = &loadShedding{
+			minPriority:    minPriority,
+			queueThreshold: queueThreshold,
+			window:         window,
+		}
+	}
+}
+
+// ! loadShedding tracks how long the queue has been above its threshold.
+type loadShedding struct {
+	minPriority    int
+	queueThreshold int
+	window         time.Duration
+	aboveSince     time.Time //! When the queue last rose above the threshold; zero while it is at or below it.
+	overloaded     bool
+}
+
+// ! updateOverload re-evaluates the overload state after the queue length changed.
+// ! The pool mutex must be held.
+func (pool *Pool) updateOverload() {
+	shedding := pool.loadShedding
+	if shedding == nil {
+		return
+	}
+	if pool.queue.len() <= shedding.queueThreshold {
+		shedding.aboveSince = time.Time{}
+		shedding.overloaded = false
+		return
+	}
+	now := pool.clock.Now()
+	if shedding.aboveSince.IsZero() {
+		shedding.aboveSince = now
+	}
+	shedding.overloaded = now.Sub(shedding.aboveSince) >= shedding.window
+}
+
+// ! shouldShed reports whether a task of the given priority is to be dropped right now.
+// ! The pool mutex must be held.
+func (pool *Pool) shouldShed(priority int) bool {
+	if pool.loadShedding == nil {
+		return false
+	}
+	pool.updateOverload()
+	return pool.loadShedding.overloaded && priority < pool.loadShedding.minPriority
+}
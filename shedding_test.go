@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadSheddingDropsLowPriorityWorkUntilTheBacklogRecovers(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithLoadShedding(5, 2, time.Second))
+	release := occupy(t, pool)
+	var backlog []*TaskHandle
+	for range 5 {
+		handle, err := pool.Submit(noopTask)
+		if err != nil {
+			t.Fatalf("shed before the queue had been above its threshold for the window: %v", err)
+		}
+		backlog = append(backlog, handle)
+	}
+
+	clock.advance(time.Second)
+	_, err := pool.Submit(noopTask)
+	assertRejected(t, err, RejectedShed, ErrTaskShed)
+	important, err := pool.SubmitWithPriority(5, noopTask)
+	if err != nil {
+		t.Fatalf("an important task was refused under overload: %v", err)
+	}
+
+	close(release)
+	pool.Wait()
+	if important.Err() != nil {
+		t.Errorf("the important task ended with %v, want it run", important.Err())
+	}
+	for index, handle := range backlog[:2] {
+		if !errors.Is(handle.Err(), ErrTaskShed) {
+			t.Errorf("backlog task %d ended with %v while the queue was still over its threshold, want ErrTaskShed",
+				index, handle.Err())
+		}
+	}
+	for index, handle := range backlog[2:] {
+		if handle.Err() != nil {
+			t.Errorf("backlog task %d ended with %v once the queue had recovered, want it run", index+2, handle.Err())
+		}
+	}
+	if shed := pool.Stats().Shed; shed != 3 {
+		t.Errorf("Stats.Shed = %d, want the refused task and the two dropped from the queue", shed)
+	}
+}
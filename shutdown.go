@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ! Shutdown is a graceful Close with a deadline. It stops accepting tasks and lets the
+// ! workers keep draining the queue in strict priority order until it is empty or ctx ends,
+// ! so if the grace window runs out, what was given up on is the least important work:
+// ! WithPriorityAntiStarvation stops serving plain tasks ahead of their turn for the rest of
+// ! the pool's life. Tasks waiting out a retry backoff (see WithRetryBackoff) are given up on at once
+// ! rather than holding up the shutdown, and delayed tasks that are not due are cancelled.
+// ! If ctx ends first, whatever is still queued is cancelled, the contexts of running tasks
+// ! are cancelled, and Shutdown returns ctx.Err() without waiting for those tasks to return.
+// ! Either way it returns every task it gave up on, all of which are marked cancelled.
+// ! Shutdown must be called at most once. Close may run concurrently with it, and then returns
+// ! once the pool has wound down, as it does after an earlier Close.
+func (pool *Pool) Shutdown(ctx context.Context) ([]TaskInfo, error) {
+	unprocessed, err := pool.shutdownCollecting(ctx)
+	pool.rethrowPanic()
+	return unprocessed, err
+}
+
+// ! shutdownCollecting is Shutdown without rethrowing a panic, so it can run on any goroutine.
+func (pool *Pool) shutdownCollecting(ctx context.Context) ([]TaskInfo, error) {
+	pool.mutex.Lock()
+	pool.unprocessed = []TaskInfo{}
+	pool.mutex.Unlock()
+
+	err := pool.shutdown(ctx)
+
+	pool.mutex.Lock()
+	unprocessed := pool.unprocessed
+	pool.unprocessed = nil
+	pool.mutex.Unlock()
+	return unprocessed, err
+}
+
+// ! shutdown is Shutdown without collecting the tasks it gives up on and without rethrowing a
+// ! panic, so it can run on any goroutine.
+func (pool *Pool) shutdown(ctx context.Context) error {
+	pool.shutdownOnce.Do(func() { close(pool.shuttingDown) })
+	pool.mutex.Lock()
+	pool.queue.strictPriority = true //! The grace window goes to the most important work first.
+	pool.mutex.Unlock()
+
+	closed := make(chan struct{})
+	pool.spawn(pool.closeAndWait, func() { close(closed) })
+
+	var err error
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		err = ctx.Err()
+		pool.mutex.Lock()
+		dropped := pool.queue.removeAll()
+		dropped = append(dropped, pool.diskSpill.removeAll()...)
+		pool.mutex.Unlock()
+		for _, droppedTask := range dropped {
+			pool.cancelTask(droppedTask)
+		}
+		pool.cancelPoolContext()
+	}
+	return err
+}
+
+// ! WithShutdownHook registers a function to run exactly once when the pool has wound down,
+// ! after the last worker has exited and every result sink has been flushed and closed, for
+// ! cleanup such as closing a connection pool the tasks shared or flushing a metrics exporter.
+// ! It runs at the end of Close, however the pool was closed, including a Shutdown whose
+// ! deadline passed: the hook then runs in the background once the running tasks have returned
+// ! from their cancelled contexts. The context returned by NewWithContext is done only after
+// ! the hooks have run. Several hooks run in the order they were registered.
+func WithShutdownHook(hook func()) Option {
+	return func(pool *Pool) {
+		pool.shutdownHooks = append(pool.shutdownHooks, hook)
+	}
+}
+
+// ! recordUnprocessed notes a task given up on while a Shutdown is collecting them.
+func (pool *Pool) recordUnprocessed(droppedTask *task) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if pool.unprocessed != nil {
+		pool.unprocessed = append(pool.unprocessed, infoOf(droppedTask))
+	}
+}
+
+// ! NewWithContext is New for pools that live inside an application's context tree: once
+// ! parent is cancelled, the pool shuts down gracefully in the background, as Shutdown with
+// ! defaultShutdownGrace to finish the queue. The returned context is done once the pool has
+// ! stopped, however that came about, so other subsystems can wait for it or derive from it.
+// ! Close and Wait keep working as usual, and are where a panic held under the Rethrow policy
+// ! is re-raised; the background shutdown never re-raises it itself. A pool that is already
+// ! closed when parent is cancelled is left alone.
+func NewWithContext(parent context.Context, options ...Option) (*Pool, context.Context, error) {
+	pool, err := New(options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	stop := context.AfterFunc(parent, func() {
+		pool.mutex.Lock()
+		closed := pool.closed
+		pool.mutex.Unlock()
+		if closed {
+			return
+		}
+		graceContext, cancel := context.WithTimeout(context.Background(), defaultShutdownGrace)
+		defer cancel()
+		pool.shutdown(graceContext)
+	})
+	context.AfterFunc(pool.poolContext, func() { stop() })
+	return pool, pool.poolContext, nil
+}
+
+// ! shutdownProgressInterval is how often ShutdownWithProgress reports.
+const shutdownProgressInterval = time.Second
+
+// ! ShutdownWithProgress is Shutdown that calls progress with the number of tasks still queued
+// ! or running when it starts and then every second, for shutdown logs along the lines of
+// ! "draining: 42 tasks remaining". Reports stop once Shutdown returns, so none follows its
+// ! return; progress runs on a timer goroutine, one call at a time.
+func (pool *Pool) ShutdownWithProgress(ctx context.Context, progress func(remaining int)) ([]TaskInfo, error) {
+	reporter := &shutdownReporter{pool: pool, progress: progress}
+	reporter.report()
+	defer reporter.stop()
+	return pool.Shutdown(ctx)
+}
+
+// ! shutdownReporter calls ShutdownWithProgress's callback on an interval until stopped.
+type shutdownReporter struct {
+	pool     *Pool
+	progress func(remaining int)
+	mutex    sync.Mutex //! Held while progress runs, so stop waits for a call in progress.
+	stopped  bool
+	timer    Timer
+}
+
+// ! report calls progress and arms the timer for the next report.
+func (reporter *shutdownReporter) report() {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	if reporter.stopped {
+		return
+	}
+	pool := reporter.pool
+	pool.mutex.Lock()
+	remaining := pool.outstanding
+	pool.mutex.Unlock()
+	reporter.progress(remaining)
+	reporter.timer = pool.clock.AfterFunc(shutdownProgressInterval, reporter.report)
+}
+
+func (reporter *shutdownReporter) stop() {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	reporter.stopped = true
+	reporter.timer.Stop()
+}
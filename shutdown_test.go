@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownInterruptsLongBackoff(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithRetry(3), WithRetryBackoff(30*time.Second, time.Minute))
+	failed := make(chan struct{})
+	handle, err := pool.Submit(TaskFunc(func(context.Context) error {
+		select {
+		case <-failed:
+		default:
+			close(failed)
+		}
+		return errors.New("downstream unavailable")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-failed
+	time.Sleep(10 * time.Millisecond) //! The task is now waiting out its 30s backoff.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started := time.Now()
+	unprocessed, err := pool.Shutdown(ctx)
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("Shutdown took %v with a task in backoff", elapsed)
+	}
+	if err != nil {
+		t.Fatalf("Shutdown returned %v, want nil as it finished before its deadline", err)
+	}
+	if len(unprocessed) != 1 || unprocessed[0].Id != handle.Id() {
+		t.Fatalf("Shutdown returned unprocessed tasks %v, want the task in backoff", unprocessed)
+	}
+	if !handle.Cancelled() || !errors.Is(handle.Err(), ErrTaskCancelled) {
+		t.Fatalf("task in backoff finished with %v, want it cancelled", handle.Err())
+	}
+	if count := pool.GoroutineCount(); count != 0 {
+		t.Fatalf("%d goroutines left after Shutdown", count)
+	}
+}
+
+func TestShutdownDeadlineCancelsQueuedTasks(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := make(chan struct{})
+	defer close(release)
+	pool.SubmitFunc(func() { <-release })
+	queued, _ := pool.SubmitFunc(func() {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	unprocessed, err := pool.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown returned %v, want the deadline error", err)
+	}
+	if len(unprocessed) != 1 || unprocessed[0].Id != queued.Id() || !queued.Cancelled() {
+		t.Fatalf("Shutdown returned unprocessed tasks %v, want the queued task", unprocessed)
+	}
+	//! The closing goroutine waits for the stuck task and stays counted until it returns.
+	if count := pool.GoroutineCount(); count < 2 {
+		t.Fatalf("GoroutineCount is %d, want the stuck worker and the closing goroutine", count)
+	}
+}
+
+func TestNewWithContextLeavesRethrowToClose(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	pool, poolContext, err := NewWithContext(parent, WithWorkers(1), WithPanicPolicy(Rethrow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	pool.SubmitFunc(func() {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		panic("boom")
+	})
+	<-started
+	cancelParent() //! The background shutdown is under way when the task panics.
+
+	select {
+	case <-poolContext.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the pool did not stop after its parent context was cancelled")
+	}
+	defer func() {
+		var panicError *PanicError
+		if recovered, _ := recover().(error); !errors.As(recovered, &panicError) {
+			t.Fatalf("Close re-raised %v, want the task's panic", recovered)
+		}
+	}()
+	pool.Close()
+	t.Fatal("Close did not re-raise the task's panic")
+}
+
+func TestShutdownHookRunsOnceAfterForcedShutdown(t *testing.T) {
+	var calls atomic.Int32
+	var taskReturned atomic.Bool
+	hookRan := make(chan struct{})
+	pool := newTestPool(t, WithWorkers(1), WithShutdownHook(func() {
+		if calls.Add(1) == 1 && taskReturned.Load() {
+			close(hookRan)
+		}
+	}))
+	started := make(chan struct{})
+	pool.Submit(TaskFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond) //! Winds down slowly, after Shutdown has given up.
+		taskReturned.Store(true)
+		return ctx.Err()
+	}))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want the deadline", err)
+	}
+	select {
+	case <-hookRan:
+	case <-time.After(time.Second):
+		t.Fatal("the shutdown hook did not run after the last task returned")
+	}
+	pool.Close()
+	if calls.Load() != 1 {
+		t.Fatalf("the shutdown hook ran %d times", calls.Load())
+	}
+}
+
+func TestShutdownDrainsByPriority(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithPriorityAntiStarvation(2))
+	release := make(chan struct{})
+	pool.SubmitFunc(func() { <-release })
+	var highRan atomic.Int32
+	for range 3 {
+		pool.Submit(TaskFunc(func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() })) //! Holds the worker to the deadline.
+		pool.SubmitWithPriority(10, TaskFunc(func(context.Context) error { highRan.Add(1); return nil }))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	shutdown := make(chan []TaskInfo)
+	go func() {
+		unprocessed, _ := pool.Shutdown(ctx)
+		shutdown <- unprocessed
+	}()
+	for started := false; !started; {
+		pool.mutex.Lock()
+		started = pool.queue.strictPriority
+		pool.mutex.Unlock()
+	}
+	close(release)
+	unprocessed := <-shutdown
+	if highRan.Load() != 3 {
+		t.Errorf("%d high-priority tasks ran within the grace window, want all 3", highRan.Load())
+	}
+	for _, info := range unprocessed {
+		if info.Priority != 0 {
+			t.Errorf("Shutdown gave up on task %d of priority %d", info.Id, info.Priority)
+		}
+	}
+}
+
+func TestShutdownWithProgressReportsUntilDone(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock))
+	release := make(chan struct{})
+	pool.SubmitFunc(func() { <-release })
+	pool.SubmitFunc(func() {})
+	pool.SubmitFunc(func() {})
+
+	reports := make(chan int, 10)
+	shutDown := make(chan struct{})
+	go func() {
+		pool.ShutdownWithProgress(context.Background(), func(remaining int) { reports <- remaining })
+		close(shutDown)
+	}()
+	if remaining := <-reports; remaining != 3 {
+		t.Fatalf("the first report said %d remaining, want 3", remaining)
+	}
+	clock.advance(shutdownProgressInterval)
+	if remaining := <-reports; remaining != 3 {
+		t.Fatalf("the second report said %d remaining, want 3", remaining)
+	}
+	close(release)
+	<-shutDown
+	clock.advance(shutdownProgressInterval)
+	if len(reports) != 0 {
+		t.Fatalf("got a report after Shutdown returned: %d remaining", <-reports)
+	}
+}
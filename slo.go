@@ -0,0 +1,53 @@
+package main
+
+import (
+	"time"
+)
+
+// ! LatencyComponent names the part of a task's end-to-end latency that was larger.
+type LatencyComponent int
+
+const (
+	//! QueueWait means the task spent longer waiting for a worker than running.
+	QueueWait LatencyComponent = iota
+	//! Execution means the task spent longer running, retries included, than waiting.
+	Execution
+)
+
+func (component LatencyComponent) String() string {
+	if component == QueueWait {
+		return "queue wait"
+	}
+	return "execution"
+}
+
+// ! WithLatencySLO calls onBreach for every task whose end-to-end latency, from Submit until
+// ! it finished running, exceeds threshold. total is that latency, and dominant tells whether
+// ! queue wait or execution made up most of it. onBreach runs on the worker that ran the
+// ! task, before the task's handle is marked done, so it should be quick.
+func WithLatencySLO(threshold time.Duration, onBreach func(taskId int, total time.Duration, dominant LatencyComponent)) Option {
+	return func(pool *Pool) {
+		pool.latencySLO = threshold
+		pool.onLatencyBreach = onBreach
+	}
+}
+
+// ! checkLatency reports a breach of the latency SLO, if one is configured.
+func (pool *Pool) checkLatency(finishedTask *task, startedAt time.Time, finishedAt time.Time) {
+	if pool.onLatencyBreach == nil {
+		return
+	}
+	queuedAt := finishedTask.enqueuedAt
+	if queuedAt.IsZero() {
+		queuedAt = startedAt //! Synchronous tasks run inline and never wait in the queue.
+	}
+	total := finishedAt.Sub(queuedAt)
+	if total <= pool.latencySLO {
+		return
+	}
+	dominant := Execution
+	if startedAt.Sub(queuedAt) > finishedAt.Sub(startedAt) {
+		dominant = QueueWait
+	}
+	pool.onLatencyBreach(finishedTask.id, total, dominant)
+}
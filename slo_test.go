@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencySLOForSynchronousTasks(t *testing.T) {
+	var breaches []time.Duration
+	pool := newTestPool(t, WithSynchronous(), WithLatencySLO(20*time.Millisecond,
+		func(taskId int, total time.Duration, dominant LatencyComponent) {
+			breaches = append(breaches, total)
+			if dominant != Execution {
+				t.Errorf("breach of an inline task blamed on %v", dominant)
+			}
+		}))
+
+	pool.SubmitFunc(func() {})
+	if len(breaches) != 0 {
+		t.Fatalf("a quick inline task breached the SLO with a latency of %v", breaches[0])
+	}
+	pool.SubmitFunc(func() { time.Sleep(30 * time.Millisecond) })
+	if len(breaches) != 1 || breaches[0] < 30*time.Millisecond || breaches[0] > time.Second {
+		t.Fatalf("a slow inline task reported breaches %v, want one of about 30ms", breaches)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ! WithSlowTaskTracking keeps the n slowest tasks that finished running, by how long they ran,
+// ! for SlowestTasks, so the worst offenders of a run can be found without instrumenting every
+// ! task. Tracking costs one heap operation per slow task, under a lock of its own; tasks
+// ! faster than the current n-th slowest only take a comparison. Reset clears the list.
+func WithSlowTaskTracking(n int) Option {
+	return func(pool *Pool) {
+		pool.slowTasks = &slowTasks{limit: n}
+	}
+}
+
+// ! SlowestTasks returns the slowest tasks seen since the pool was created or last Reset,
+// ! slowest first, with ExecDuration set. It returns nil without WithSlowTaskTracking.
+func (pool *Pool) SlowestTasks() []TaskInfo {
+	return pool.slowTasks.snapshot()
+}
+
+// ! slowTasks is a bounded min-heap of the slowest tasks: the fastest of them sits at the
+// ! root, ready to be replaced by a slower newcomer.
+type slowTasks struct {
+	mutex   sync.Mutex
+	limit   int
+	slowest slowHeap
+}
+
+// ! record considers a task that just finished for the list. It is safe on a nil tracker.
+func (tracker *slowTasks) record(finishedTask *task, duration time.Duration) {
+	if tracker == nil || tracker.limit <= 0 {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if len(tracker.slowest) == tracker.limit && duration <= tracker.slowest[0].ExecDuration {
+		return
+	}
+	info := TaskInfo{
+		Id:           finishedTask.id,
+		Priority:     finishedTask.priority, //! Only queued and held tasks can still be boosted.
+		Tags:         finishedTask.tags,
+		EnqueuedAt:   finishedTask.enqueuedAt,
+		ExecDuration: duration,
+	}
+	if len(tracker.slowest) < tracker.limit {
+		heap.Push(&tracker.slowest, info)
+		return
+	}
+	tracker.slowest[0] = info
+	heap.Fix(&tracker.slowest, 0)
+}
+
+func (tracker *slowTasks) snapshot() []TaskInfo {
+	if tracker == nil {
+		return nil
+	}
+	tracker.mutex.Lock()
+	infos := append([]TaskInfo(nil), tracker.slowest...)
+	tracker.mutex.Unlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ExecDuration > infos[j].ExecDuration })
+	return infos
+}
+
+func (tracker *slowTasks) reset() {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.slowest = nil
+}
+
+// ! slowHeap implements heap.Interface, ordered by ExecDuration, fastest first.
+type slowHeap []TaskInfo
+
+func (slowest slowHeap) Len() int           { return len(slowest) }
+func (slowest slowHeap) Less(i, j int) bool { return slowest[i].ExecDuration < slowest[j].ExecDuration }
+func (slowest slowHeap) Swap(i, j int)      { slowest[i], slowest[j] = slowest[j], slowest[i] }
+
+func (slowest *slowHeap) Push(info any) {
+	*slowest = append(*slowest, info.(TaskInfo))
+}
+
+func (slowest *slowHeap) Pop() any {
+	old := *slowest
+	last := old[len(old)-1]
+	*slowest = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlowTaskTrackingKeepsTheSlowest(t *testing.T) {
+	pool := newTestPool(t, WithSlowTaskTracking(3))
+	for id, millis := range []int{5, 1, 9, 3, 7, 2, 8} {
+		pool.slowTasks.record(&task{id: id + 1}, time.Duration(millis)*time.Millisecond)
+	}
+	slowest := pool.SlowestTasks()
+	want := []int{3, 7, 5} //! The ids of the 9ms, 8ms and 7ms tasks.
+	if len(slowest) != len(want) {
+		t.Fatalf("SlowestTasks returned %v, want tasks %v", slowest, want)
+	}
+	for index, info := range slowest {
+		if info.Id != want[index] {
+			t.Fatalf("SlowestTasks returned %v, want tasks %v", slowest, want)
+		}
+	}
+
+	pool.Reset()
+	if slowest := pool.SlowestTasks(); len(slowest) != 0 {
+		t.Fatalf("SlowestTasks after Reset returned %v", slowest)
+	}
+	pool.SubmitWait(func() error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	if slowest := pool.SlowestTasks(); len(slowest) != 1 || slowest[0].ExecDuration < 5*time.Millisecond {
+		t.Fatalf("SlowestTasks returned %v after a 5ms task", slowest)
+	}
+}
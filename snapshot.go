@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ! savedTask is how SaveQueue writes one task: the caller's encoding of it plus the metadata
+// ! the pool needs to queue it the same way again.
+type savedTask struct {
+	Priority    int               `json:"priority,omitempty"`
+	Prioritized bool              `json:"prioritized,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Data        []byte            `json:"data"`
+}
+
+// ! SaveQueue takes every queued task out of the pool and writes it to path, with its priority
+// ! and tags, so a later process can pick the backlog up again with LoadQueue; call it before
+// ! Close or Shutdown, which would otherwise run or cancel those tasks. Only tasks submitted as
+// ! a Task value can be encoded: closures, and tasks spilled to disk (see WithDiskSpill, which
+// ! persists them by itself), stay queued. The file is written under a temporary name and
+// ! renamed into place. If writing fails, the tasks go back into the queue and the error is
+// ! returned; otherwise their handles finish with ErrTaskSaved, without onCancel callbacks,
+// ! as the work was handed over rather than dropped.
+func (pool *Pool) SaveQueue(path string, encode func(Task) []byte) error {
+	pool.mutex.Lock()
+	saved := pool.queue.removeMatching(func(queued *task) bool { return queued.work != nil })
+	pool.spaceAvailable.Broadcast()
+	pool.mutex.Unlock()
+	sort.Slice(saved, func(i, j int) bool { return saved[i].id < saved[j].id })
+
+	records := make([]savedTask, len(saved))
+	for index, queued := range saved {
+		records[index].Priority = queued.priority
+		records[index].Prioritized = queued.prioritized
+		records[index].Tags = queued.tags
+		records[index].Data = encode(queued.work)
+	}
+	if err := writeQueueFile(path, records); err != nil {
+		pool.mutex.Lock()
+		for _, queued := range saved {
+			pool.push(queued)
+		}
+		pool.mutex.Unlock()
+		return fmt.Errorf("worker pool: saving the queue to %s: %w", path, err)
+	}
+	for _, handedOver := range saved {
+		pool.counters.cancelled.Add(1)
+		handedOver.handle.finish(outcomeCancelled, ErrTaskSaved)
+		pool.forgetProgress(handedOver)
+		pool.finishTask()
+	}
+	return nil
+}
+
+// ! writeQueueFile writes the records as JSON, atomically replacing whatever was at path.
+func writeQueueFile(path string, records []savedTask) error {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	return nil
+}
+
+// ! LoadQueue submits every task a SaveQueue wrote to path, in their original submission
+// ! order and with their priority and tags, then removes the file so that the backlog is not
+// ! loaded twice. Each task goes through the same admission as Submit and waits for queue
+// ! space. A missing file is not an error: there was simply nothing to resume. If the pool
+// ! refuses some tasks, the file is atomically rewritten to hold only those, so a later
+// ! LoadQueue retries them without submitting the accepted ones again, and the returned
+// ! error joins the refusals, each naming the position of its task in the file that was read.
+func (pool *Pool) LoadQueue(path string, decode func([]byte) Task) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("worker pool: loading the queue from %s: %w", path, err)
+	}
+	var records []savedTask
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("worker pool: loading the queue from %s: %w", path, err)
+	}
+
+	var errs []error
+	var refused []savedTask
+	for index, record := range records {
+		work := decode(record.Data)
+		_, err := pool.enqueue(&task{
+			work:        work,
+			run:         runTask(work),
+			priority:    record.Priority,
+			prioritized: record.Prioritized,
+			tags:        record.Tags,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("saved task %d: %w", index, err))
+			refused = append(refused, record)
+		}
+	}
+	if len(refused) == 0 {
+		return os.Remove(path)
+	}
+	if err := writeQueueFile(path, refused); err != nil {
+		errs = append(errs, fmt.Errorf("worker pool: keeping the refused tasks in %s: %w", path, err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// ! namedTask is a Task that can be written to disk: it records its name when it runs.
+type namedTask struct {
+	name  string
+	mutex *sync.Mutex
+	ran   *[]string
+}
+
+func (work namedTask) Run(context.Context) error {
+	work.mutex.Lock()
+	defer work.mutex.Unlock()
+	*work.ran = append(*work.ran, work.name)
+	return nil
+}
+
+func TestSaveAndLoadQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	var mutex sync.Mutex
+	var ran []string
+	named := func(name string) Task { return namedTask{name: name, mutex: &mutex, ran: &ran} }
+	encode := func(work Task) []byte { return []byte(work.(namedTask).name) }
+	decode := func(data []byte) Task { return named(string(data)) }
+
+	first := newTestPool(t, WithWorkers(1))
+	started, release := make(chan struct{}), make(chan struct{})
+	first.SubmitFunc(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	plain, _ := first.Submit(named("plain"))
+	first.SubmitWithPriority(5, named("urgent"))
+	first.SubmitTagged(map[string]string{"user": "ada"}, named("tagged"))
+	closure, _ := first.SubmitFunc(func() {})
+
+	if err := first.SaveQueue(path, encode); err != nil {
+		t.Fatalf("SaveQueue: %v", err)
+	}
+	close(release)
+	first.Close()
+	if !errors.Is(plain.Err(), ErrTaskSaved) || closure.Err() != nil || len(ran) != 0 {
+		t.Fatalf("after SaveQueue the saved task reported %v, the closure %v, and %v ran",
+			plain.Err(), closure.Err(), ran)
+	}
+
+	second := newTestPool(t, WithWorkers(1))
+	if err := second.LoadQueue(path, decode); err != nil {
+		t.Fatalf("LoadQueue: %v", err)
+	}
+	second.Wait()
+	slices.Sort(ran)
+	if !slices.Equal(ran, []string{"plain", "tagged", "urgent"}) {
+		t.Fatalf("loaded tasks ran as %v", ran)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadQueue left the file behind: %v", err)
+	}
+	if err := second.LoadQueue(path, decode); err != nil {
+		t.Fatalf("LoadQueue without a file: %v", err)
+	}
+}
+
+func TestSavedQueueKeepsPriorityAndTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	pool := newTestPool(t, WithWorkers(1))
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	pool.SubmitFunc(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	var mutex sync.Mutex
+	var ran []string
+	pool.SubmitWithPriority(7, namedTask{name: "a", mutex: &mutex, ran: &ran})
+	pool.SubmitTagged(map[string]string{"user": "ada"}, namedTask{name: "b", mutex: &mutex, ran: &ran})
+	if err := pool.SaveQueue(path, func(work Task) []byte { return []byte(work.(namedTask).name) }); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newTestPool(t, WithWorkers(1))
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	defer close(unblock)
+	restored.SubmitFunc(func() {
+		close(blocked)
+		<-unblock
+	})
+	<-blocked
+	restored.LoadQueue(path, func(data []byte) Task { return namedTask{name: string(data), mutex: &mutex, ran: &ran} })
+	pending := restored.PendingTasks()
+	if len(pending) != 2 || pending[0].Priority != 7 || pending[1].Tags["user"] != "ada" {
+		t.Fatalf("restored tasks are %v, want priority 7 and the user tag kept", pending)
+	}
+}
+
+func TestLoadQueueKeepsOnlyRefusedTasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	var recorder spillRecorder
+	records := []savedTask{{Data: []byte("paid")}, {Tags: map[string]string{"tier": "free"}, Data: []byte("free")}}
+	if err := writeQueueFile(path, records); err != nil {
+		t.Fatal(err)
+	}
+	decode := func(data []byte) Task { return recorder.task(string(data)) }
+
+	picky := newTestPool(t, WithWorkers(1), WithAdmissionController(func(info TaskInfo) (bool, string) {
+		return info.Tags["tier"] != "free", "free tier paused"
+	}))
+	if err := picky.LoadQueue(path, decode); !errors.Is(err, ErrAdmissionDenied) {
+		t.Fatalf("LoadQueue with a refusal: got %v, want ErrAdmissionDenied", err)
+	}
+	picky.Wait()
+
+	permissive := newTestPool(t, WithWorkers(1))
+	if err := permissive.LoadQueue(path, decode); err != nil {
+		t.Fatalf("LoadQueue of the refused rest: %v", err)
+	}
+	permissive.Wait()
+	if names := recorder.names(); !slices.Equal(names, []string{"free", "paid"}) {
+		t.Fatalf("ran %v, want each saved task once", names)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("the file is left behind once every task was accepted: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ! spillFileSuffix marks the files WithDiskSpill writes, so that unrelated files in the
+// ! directory are left alone.
+const spillFileSuffix = ".task"
+
+// ! WithDiskSpill lets the queue overflow to disk instead of blocking Submit once it is full.
+// ! Overflowing tasks are encoded and written to dir, one file per task, and read back in
+// ! submission order as the in-memory queue frees up. A task's file is removed only after the
+// ! task has finished, so tasks that were spilled or running when the process died are found
+// ! again by New on restart and run again: delivery is at-least-once, and tasks must tolerate
+// ! running twice, unless WithDeliverySemantics(AtMostOnce) is given. Only tasks submitted as a
+// ! Task value can be spilled; closures submitted through SubmitFunc and friends still block on
+// ! a full queue. Priority and tags are not written to disk, so spilled tasks run in FIFO order
+// ! among themselves. If encode fails, Submit returns its error; if decode fails, the task
+// ! fails with that error.
+func WithDiskSpill(dir string, encode func(Task) ([]byte, error), decode func([]byte) (Task, error)) Option {
+	return func(pool *Pool) {
+		pool.diskSpill = &diskSpill{dir: dir, encode: encode, decode: decode}
+	}
+}
+
+// ! diskSpill is the on-disk tail of the queue. The pool mutex guards it.
+type diskSpill struct {
+	dir     string
+	encode  func(Task) ([]byte, error)
+	decode  func([]byte) (Task, error)
+	nextSeq int64   //! Sequence number of the next file, so file names sort in submission order.
+	pending []*task //! Spilled tasks, oldest first. Only id, handle and spillPath are set.
+}
+
+// ! len returns how many tasks are waiting on disk. It is safe on a nil spill.
+func (spill *diskSpill) len() int {
+	if spill == nil {
+		return 0
+	}
+	return len(spill.pending)
+}
+
+// ! write encodes the task into a new file and keeps only a skeleton of it in memory.
+// ! The file is written under a temporary name and renamed into place, so a crash never
+// ! leaves a half-written task behind.
+func (spill *diskSpill) write(spilledTask *task) error {
+	data, err := spill.encode(spilledTask.work)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(spill.dir, fmt.Sprintf("%020d%s", spill.nextSeq, spillFileSuffix))
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	spill.nextSeq++
+	spilledTask.spillPath = path
+	spilledTask.work = nil
+	spilledTask.run = nil
+	spill.pending = append(spill.pending, spilledTask)
+	return nil
+}
+
+// ! read loads and decodes the oldest spilled task, filling in its run function. A task
+// ! that cannot be read back is still returned, and fails with the error when it runs.
+func (spill *diskSpill) read() *task {
+	spilledTask := spill.pending[0]
+	spill.pending[0] = nil
+	spill.pending = spill.pending[1:]
+
+	data, err := os.ReadFile(spilledTask.spillPath)
+	if err == nil {
+		var work Task
+		if work, err = spill.decode(data); err == nil {
+			spilledTask.work = work
+			spilledTask.run = runTask(work)
+			return spilledTask
+		}
+	}
+	err = fmt.Errorf("worker pool: reading spilled task %s: %w", spilledTask.spillPath, err)
+	spilledTask.run = func(context.Context, *WorkerScratch) error { return err }
+	return spilledTask
+}
+
+// ! removeAll takes every spilled task off the spill without reading it back. It is safe
+// ! on a nil spill.
+func (spill *diskSpill) removeAll() []*task {
+	if spill == nil {
+		return nil
+	}
+	removed := spill.pending
+	spill.pending = nil
+	return removed
+}
+
+// ! canSpill reports whether the task may go to disk instead of waiting for queue space.
+func (pool *Pool) canSpill(newTask *task) bool {
+	return pool.diskSpill != nil && newTask.work != nil
+}
+
+// ! refillFromSpill moves spilled tasks back into the queue while it has room.
+// ! The pool mutex must be held.
+func (pool *Pool) refillFromSpill() {
+	for pool.diskSpill.len() > 0 && pool.queue.len() < pool.queueSize {
+		unspilled := pool.diskSpill.read()
+		if pool.delivery == AtMostOnce {
+			pool.forgetSpilled(unspilled)
+			unspilled.spillPath = ""
+		}
+		pool.push(unspilled)
+	}
+}
+
+// ! forgetSpilled deletes the file of a task that came from disk once it has finished.
+func (pool *Pool) forgetSpilled(finishedTask *task) {
+	if finishedTask.spillPath == "" {
+		return
+	}
+	if err := os.Remove(finishedTask.spillPath); err != nil && !os.IsNotExist(err) {
+		pool.logger.Printf("could not remove spilled task %d: %v", finishedTask.id, err)
+	}
+}
+
+// ! recoverSpilled registers the tasks a previous process left in the spill directory, so
+// ! that they run before anything submitted to this pool. It is called by New, and creates the
+// ! directory if it is missing.
+func (pool *Pool) recoverSpilled() {
+	if err := os.MkdirAll(pool.diskSpill.dir, 0o700); err != nil {
+		pool.logger.Printf("could not create spill directory %s: %v", pool.diskSpill.dir, err)
+		return
+	}
+	entries, err := os.ReadDir(pool.diskSpill.dir)
+	if err != nil {
+		pool.logger.Printf("could not read spill directory %s: %v", pool.diskSpill.dir, err)
+		return
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), spillFileSuffix) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var seq int64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(name, spillFileSuffix), "%d", &seq); err == nil && seq >= pool.diskSpill.nextSeq {
+			pool.diskSpill.nextSeq = seq + 1
+		}
+		recoveredTask := &task{spillPath: filepath.Join(pool.diskSpill.dir, name)}
+		pool.register(recoveredTask)
+		pool.diskSpill.pending = append(pool.diskSpill.pending, recoveredTask)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! spillRecorder makes namedTasks that spill as their name, and remembers which ran.
+type spillRecorder struct {
+	mutex sync.Mutex
+	ran   []string
+}
+
+func (recorder *spillRecorder) task(name string) Task {
+	return namedTask{name: name, mutex: &recorder.mutex, ran: &recorder.ran}
+}
+
+// ! spill returns the WithDiskSpill option for the recorder's tasks.
+func (recorder *spillRecorder) spill(dir string) Option {
+	encode := func(work Task) ([]byte, error) { return []byte(work.(namedTask).name), nil }
+	decode := func(data []byte) (Task, error) { return recorder.task(string(data)), nil }
+	return WithDiskSpill(dir, encode, decode)
+}
+
+// ! names returns the names of the tasks that ran, sorted.
+func (recorder *spillRecorder) names() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return slices.Sorted(slices.Values(recorder.ran))
+}
+
+// ! writeSpilled leaves a spilled task behind in dir, as a crashed process would.
+func writeSpilled(t *testing.T, dir, name string, seq int) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%020d%s", seq, spillFileSuffix))
+	if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ! spilledFiles counts the spilled tasks waiting in dir.
+func spilledFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), spillFileSuffix) {
+			count++
+		}
+	}
+	return count
+}
+
+// ! occupy keeps the pool's only worker busy with a task that cannot spill until the
+// ! returned channel is closed.
+func occupy(t *testing.T, pool *Pool) chan struct{} {
+	t.Helper()
+	started, release := make(chan struct{}), make(chan struct{})
+	if _, err := pool.SubmitFunc(func() {
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	return release
+}
+
+func TestDiskSpillOverflowsAndRunsInSubmissionOrder(t *testing.T) {
+	dir := t.TempDir()
+	var recorder spillRecorder
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(1), recorder.spill(dir))
+	release := occupy(t, pool)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		returnsWithin(t, time.Second, "a submit to a full queue", func() {
+			if _, err := pool.Submit(recorder.task(name)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if spilled := spilledFiles(t, dir); spilled != 3 {
+		t.Errorf("%d tasks on disk with a queue of one, want 3", spilled)
+	}
+
+	close(release)
+	pool.Wait()
+	recorder.mutex.Lock()
+	ran := slices.Clone(recorder.ran)
+	recorder.mutex.Unlock()
+	if !slices.Equal(ran, []string{"a", "b", "c", "d"}) {
+		t.Errorf("ran %v, want submission order", ran)
+	}
+	if spilled := spilledFiles(t, dir); spilled != 0 {
+		t.Errorf("%d spill files left once every task finished", spilled)
+	}
+}
+
+func TestDiskSpillRecoversTasksLeftByACrash(t *testing.T) {
+	dir := t.TempDir()
+	writeSpilled(t, dir, "second", 7)
+	writeSpilled(t, dir, "first", 3)
+	var recorder spillRecorder
+	pool := newTestPool(t, WithWorkers(1), recorder.spill(dir))
+	pool.Submit(recorder.task("submitted"))
+	pool.Wait()
+	recorder.mutex.Lock()
+	ran := slices.Clone(recorder.ran)
+	recorder.mutex.Unlock()
+	if !slices.Equal(ran, []string{"first", "second", "submitted"}) {
+		t.Errorf("ran %v, want the recovered tasks in spill order before the new one", ran)
+	}
+	if spilled := spilledFiles(t, dir); spilled != 0 {
+		t.Errorf("%d spill files left once the recovered tasks ran", spilled)
+	}
+}
+
+func TestShutdownTimeoutCancelsSpilledTasks(t *testing.T) {
+	dir := t.TempDir()
+	var recorder spillRecorder
+	pool, err := New(WithWorkers(1), WithQueueSize(1), recorder.spill(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := occupy(t, pool)
+	defer close(release)
+	pool.Submit(recorder.task("queued"))
+	spilled, _ := pool.Submit(recorder.task("spilled"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	unprocessed, err := pool.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown returned %v, want its deadline", err)
+	}
+	if len(unprocessed) != 2 {
+		t.Errorf("Shutdown gave up on %d tasks, want the queued and the spilled one", len(unprocessed))
+	}
+	if !errors.Is(spilled.Err(), ErrTaskCancelled) {
+		t.Errorf("the spilled task ended with %v, want ErrTaskCancelled", spilled.Err())
+	}
+	if files := spilledFiles(t, dir); files != 0 {
+		t.Errorf("%d spill files left for cancelled tasks, which would run again on restart", files)
+	}
+}
+
+func TestShardedPoolSpillsToPerShardDirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeSpilled(t, filepath.Join(dir, "0"), "first", 0)
+	writeSpilled(t, filepath.Join(dir, "1"), "second", 0)
+	var recorder spillRecorder
+	sharded, err := NewShardedPool(2, WithWorkers(1), recorder.spill(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharded.Close()
+	if names := recorder.names(); !slices.Equal(names, []string{"first", "second"}) {
+		t.Fatalf("recovered %v, want each shard's task exactly once", names)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"runtime"
+)
+
+// ! WithSpinBeforePark makes an idle worker poll for a new task up to iterations times,
+// ! yielding the processor between polls, before it parks until woken. When tasks arrive in
+// ! rapid bursts, the next one is usually picked up during the spin, without the scheduler
+// ! latency of waking a parked goroutine, at the price of CPU burnt by idle workers; with fewer
+// ! processors than busy goroutines the spinning mostly yields and gains little. Zero, the
+// ! default, parks at once. See BenchmarkSpinBeforePark for the tradeoff on your hardware.
+func WithSpinBeforePark(iterations int) Option {
+	return func(pool *Pool) {
+		pool.spinBeforePark = iterations
+	}
+}
+
+// ! spinForTask releases the pool mutex and polls until a task is pushed or the spin budget
+// ! runs out, then takes the mutex again. The caller must check the queue afterwards either
+// ! way, since a push can land just as the spin ends.
+func (pool *Pool) spinForTask() {
+	seen := pool.pushes.Load()
+	pool.mutex.Unlock()
+	defer pool.mutex.Lock()
+	for range pool.spinBeforePark {
+		if pool.pushes.Load() != seen {
+			return
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"runtime/metrics"
+	"testing"
+	"time"
+)
+
+func TestSpinBeforeParkStillRunsEverything(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2), WithSpinBeforePark(100))
+	for index := range 50 {
+		pool.Submit(noopTask)
+		if index%10 == 9 {
+			time.Sleep(time.Millisecond) //! Let the workers go idle now and then.
+		}
+	}
+	returnsWithin(t, time.Second, "Wait with spinning workers", pool.Wait)
+	if completed := pool.Stats().Completed; completed != 50 {
+		t.Fatalf("%d tasks completed, want 50", completed)
+	}
+	returnsWithin(t, time.Second, "Close with spinning workers", pool.Close)
+}
+
+// ! BenchmarkSpinBeforePark measures the round trip of a task submitted to an idle worker,
+// ! and reports the CPU time the process spent per task next to it.
+func BenchmarkSpinBeforePark(b *testing.B) {
+	for _, benchmark := range []struct {
+		name       string
+		iterations int
+	}{{"park", 0}, {"spin100", 100}, {"spin1000", 1000}} {
+		b.Run(benchmark.name, func(b *testing.B) {
+			pool, err := New(WithWorkers(1), WithSpinBeforePark(benchmark.iterations))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer pool.Close()
+			cpuBefore := processCPUSeconds()
+			for b.Loop() {
+				handle, _ := pool.Submit(TaskFunc(func(context.Context) error { return nil }))
+				<-handle.Done()
+			}
+			b.ReportMetric((processCPUSeconds()-cpuBefore)*1e9/float64(b.N), "cpu-ns/op")
+		})
+	}
+}
+
+func processCPUSeconds() float64 {
+	sample := []metrics.Sample{{Name: "/cpu/classes/total:cpu-seconds"}}
+	metrics.Read(sample)
+	return sample[0].Value.Float64()
+}
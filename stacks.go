@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+)
+
+// ! poolLabelKey is the pprof label every worker goroutine of a pool carries. Its value
+// ! tells pools apart, so DumpStacks can pick out one pool's workers.
+const poolLabelKey = "worker-pool"
+
+// ! labelWorker tags the calling worker goroutine with pprof labels. Goroutines it starts,
+// ! such as the ones running timed attempts, inherit them.
+func (pool *Pool) labelWorker(workerId int) {
+	labels := pprof.Labels(poolLabelKey, fmt.Sprintf("%p", pool), "worker", pool.workerName(workerId))
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), labels))
+}
+
+// ! DumpStacks returns the stack traces of this pool's worker goroutines only, including the
+// ! goroutines running timed-out or leaked tasks, in the format of the pprof goroutine profile
+// ! at debug level 1. Goroutines with identical stacks are grouped, with a count in front.
+// ! It is meant for finding out where workers are stuck when the pool seems wedged.
+func (pool *Pool) DumpStacks() []byte {
+	var profile bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&profile, 1)
+
+	marker := []byte(fmt.Sprintf("%q:%q", poolLabelKey, fmt.Sprintf("%p", pool)))
+	var dump bytes.Buffer
+	for _, group := range bytes.Split(profile.Bytes(), []byte("\n\n")) {
+		if bytes.Contains(group, marker) {
+			dump.Write(group)
+			dump.WriteString("\n\n")
+		}
+	}
+	return dump.Bytes()
+}
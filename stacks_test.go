@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// ! parkedInDumpStacksTest blocks until release is closed, under a name DumpStacks can show.
+//
+//go:noinline
+func parkedInDumpStacksTest(release <-chan struct{}) {
+	<-release
+}
+
+func TestDumpStacksShowsOnlyThisPoolsWorkers(t *testing.T) {
+	for name, options := range map[string][]Option{
+		"untimed": {WithWorkers(2)},
+		"timed":   {WithWorkers(2), WithTaskTimeout(time.Minute, time.Minute)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pool := newTestPool(t, options...)
+			other := newTestPool(t, WithWorkers(1))
+			if err := other.WaitReady(context.Background(), 1); err != nil {
+				t.Fatal(err) //! Its worker has labelled itself by now.
+			}
+			release := make(chan struct{})
+			started := make(chan struct{})
+			pool.Submit(TaskFunc(func(context.Context) error {
+				close(started)
+				parkedInDumpStacksTest(release)
+				return nil
+			}))
+			<-started
+			defer close(release)
+
+			dump := pool.DumpStacks()
+			if !bytes.Contains(dump, []byte("parkedInDumpStacksTest")) {
+				t.Errorf("the dump does not show the parked task:\n%s", dump)
+			}
+			if bytes.Contains(dump, []byte("testing.tRunner")) {
+				t.Errorf("the dump includes the test's own goroutine:\n%s", dump)
+			}
+			if otherDump := other.DumpStacks(); bytes.Contains(otherDump, []byte("parkedInDumpStacksTest")) || len(otherDump) == 0 {
+				t.Errorf("the other pool's dump shows the wrong workers:\n%s", otherDump)
+			}
+		})
+	}
+}
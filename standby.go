@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// ! StandbyPool keeps a primary pool serving tasks and a second, identically configured pool
+// ! warm in reserve: its workers are started and their init (see WithWorkerInit) has run. If
+// ! the primary stalls, Promote swaps the standby in without any cold start.
+type StandbyPool struct {
+	mutex       sync.Mutex
+	options     []Option
+	primary     *Pool
+	standby     *Pool
+	generations int //! How many pools have been created, numbering them for WithName.
+}
+
+// ! NewStandbyPool creates the primary and the standby pool from the same options. A name given
+// ! with WithName is suffixed with the number of each pool created, so "orders" registers its
+// ! primary as "orders/1", its standby as "orders/2", and the standby a Promote warms up next
+// ! as "orders/3". WithDiskSpill is refused with ErrStandbyDiskSpill: both pools would share
+// ! its directory, each recovering the other's files, and tasks spilled by a primary would be
+// ! stranded on Promote. It returns the first error from New, or from WaitReady while waiting
+// ! for every standby worker to be ready, in which case both pools are closed again.
+func NewStandbyPool(ctx context.Context, options ...Option) (*StandbyPool, error) {
+	standbyPool := &StandbyPool{options: options}
+	primary, err := standbyPool.newPool()
+	if err != nil {
+		return nil, err
+	}
+	standby, err := standbyPool.newWarmPool(ctx)
+	if err != nil {
+		go primary.closeAndWait()
+		return nil, err
+	}
+	standbyPool.primary, standbyPool.standby = primary, standby
+	return standbyPool, nil
+}
+
+// ! newPool creates the next pool from the shared options.
+func (standbyPool *StandbyPool) newPool() (*Pool, error) {
+	standbyPool.mutex.Lock()
+	standbyPool.generations++
+	generation := standbyPool.generations
+	standbyPool.mutex.Unlock()
+	options := standbyPool.options
+	return New(append(options[:len(options):len(options)], withStandbyGeneration(generation))...)
+}
+
+// ! withStandbyGeneration tells a StandbyPool's pool its number, once the shared options have
+// ! been applied.
+func withStandbyGeneration(generation int) Option {
+	return func(pool *Pool) {
+		pool.name = memberName(pool.name, generation)
+		pool.standbyMember = true
+	}
+}
+
+// ! newWarmPool creates the next pool and waits until all of its workers are ready.
+func (standbyPool *StandbyPool) newWarmPool(ctx context.Context) (*Pool, error) {
+	pool, err := standbyPool.newPool()
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.WaitReady(ctx, pool.totalWorkers); err != nil {
+		go pool.closeAndWait()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// ! Primary returns the pool currently serving tasks. Submit to it through this method
+// ! every time, rather than keeping the result, so that submissions follow a Promote.
+func (standbyPool *StandbyPool) Primary() *Pool {
+	standbyPool.mutex.Lock()
+	defer standbyPool.mutex.Unlock()
+	return standbyPool.primary
+}
+
+// ! Promote makes the standby the primary. Tasks still waiting in the old primary's queue
+// ! move to the new primary with their handles intact, so their submitters notice nothing.
+// ! Tasks that are already running and delayed tasks whose timer has not fired stay with the
+// ! old primary, which is closed in the background and may take as long as its stalled tasks
+// ! do; a panic it holds under the Rethrow policy is not re-raised there, as nobody is waiting
+// ! to receive it. The swap itself is immediate; Promote then warms up a new standby from the
+// ! same options before it returns, and a concurrent Promote returns ErrNoStandby meanwhile.
+// ! If creating the new standby fails, its error is returned and further Promotes return
+// ! ErrNoStandby.
+func (standbyPool *StandbyPool) Promote(ctx context.Context) error {
+	standbyPool.mutex.Lock()
+	oldPrimary, newPrimary := standbyPool.primary, standbyPool.standby
+	if newPrimary == nil {
+		standbyPool.mutex.Unlock()
+		return ErrNoStandby
+	}
+	standbyPool.primary, standbyPool.standby = newPrimary, nil
+	standbyPool.mutex.Unlock()
+
+	newPrimary.adopt(oldPrimary.release())
+	go oldPrimary.closeAndWait() //! Not Close, whose rethrown panic would crash the process from here.
+
+	standby, err := standbyPool.newWarmPool(ctx)
+	if err != nil {
+		return err
+	}
+	standbyPool.mutex.Lock()
+	standbyPool.standby = standby
+	standbyPool.mutex.Unlock()
+	return nil
+}
+
+// ! Close closes both pools, waiting for the primary's tasks as Close does.
+func (standbyPool *StandbyPool) Close() {
+	standbyPool.mutex.Lock()
+	primary, standby := standbyPool.primary, standbyPool.standby
+	standbyPool.standby = nil
+	standbyPool.mutex.Unlock()
+
+	if standby != nil {
+		standby.Close()
+	}
+	primary.Close()
+}
+
+// ! release takes every queued task out of the pool and stops accounting for it, so that
+// ! another pool can adopt it.
+func (pool *Pool) release() []*task {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	released := pool.queue.removeAll()
+	pool.outstanding -= len(released)
+	pool.updateOverload()
+	pool.spaceAvailable.Broadcast()
+	pool.broadcastStateChange()
+	return released
+}
+
+// ! adopt queues tasks released by another pool, keeping their ids, handles, and queue wait
+// ! times. The queue may briefly hold more than its size.
+func (pool *Pool) adopt(adopted []*task) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	for _, adoptedTask := range adopted {
+		adoptedTask.handle.pool = pool
+		pool.outstanding++
+		pool.queue.push(adoptedTask)
+		pool.signalTaskAvailable(adoptedTask)
+	}
+	pool.updateOverload()
+	storeMax(&pool.counters.peakQueueDepth, int64(pool.queue.len()))
+}
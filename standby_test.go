@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromoteMovesQueuedTasksToTheStandby(t *testing.T) {
+	standbyPool, err := NewStandbyPool(context.Background(), WithWorkers(1))
+	if err != nil {
+		t.Fatalf("NewStandbyPool: %v", err)
+	}
+	defer standbyPool.Close()
+	oldPrimary := standbyPool.Primary()
+	started, release := make(chan struct{}), make(chan struct{})
+	stalled, _ := oldPrimary.SubmitFunc(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	queued, _ := oldPrimary.SubmitFunc(func() {})
+
+	if err := standbyPool.Promote(context.Background()); err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	if standbyPool.Primary() == oldPrimary {
+		t.Fatalf("Promote kept the old primary")
+	}
+	returnsWithin(t, time.Second, "the moved task", func() { <-queued.Done() })
+	if queued.Err() != nil {
+		t.Fatalf("the moved task reported %v", queued.Err())
+	}
+	select {
+	case <-stalled.Done():
+		t.Fatalf("the stalled task finished before it was released")
+	default:
+	}
+	close(release)
+	returnsWithin(t, time.Second, "the stalled task", func() { <-stalled.Done() })
+}
+
+func TestStandbyPoolRefusesDiskSpill(t *testing.T) {
+	var recorder spillRecorder
+	_, err := NewStandbyPool(context.Background(), WithWorkers(1), WithName("orders"), recorder.spill(t.TempDir()))
+	if !errors.Is(err, ErrStandbyDiskSpill) {
+		t.Fatalf("NewStandbyPool with WithDiskSpill: got %v, want ErrStandbyDiskSpill", err)
+	}
+	if _, ok := PoolByName("orders/1"); ok {
+		t.Fatalf("the refused primary is registered")
+	}
+}
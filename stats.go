@@ -0,0 +1,171 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ! Stats is a point-in-time snapshot of what a pool has done since it was created.
+type Stats struct {
+	Submitted int64 //! Tasks accepted by the pool.
+	Completed int64 //! Tasks that finished without an error.
+	Failed    int64 //! Tasks whose final attempt returned an error.
+	Cancelled int64 //! Tasks dropped from the queue before they ran.
+	Skipped   int64 //! Tasks dropped at dispatch because their guard returned false.
+	Shed      int64 //! Tasks rejected or dropped by load shedding.
+	Expired   int64 //! Tasks dropped at dispatch for outliving the queue TTL or their deadline.
+	Retries   int64 //! Extra attempts made for failing tasks.
+	Panics    int64 //! Task attempts that panicked, whatever the panic policy.
+	Leaked    int64 //! Task attempts abandoned after ignoring their timeout.
+
+	//! Abandoned tasks that are still running right now, each holding on to a goroutine.
+	LeakedRunning int64
+	//! Worker slots retired by WithWorkerQuarantine.
+	QuarantinedWorkers int64
+
+	//! Submissions that had to wait for queue space or a rate-limit token, and their total wait.
+	//! Rising values mean producers are held back by backpressure.
+	SubmitBlocked      int64
+	SubmitBlockedTotal time.Duration
+
+	//! Tasks rejected because the leaky bucket was full, see WithLeakyBucket.
+	BucketDropped int64
+	//! Dispatches that waited for their leaky-bucket slot.
+	BucketDelayed int64
+
+	//! Results the Results channel had no room for, see WithStrictResultDelivery.
+	ResultsDropped int64
+
+	//! Estimated CPU time of all finished tasks, see WithCPUTimeBudget for how it is measured.
+	CPUTime time.Duration
+
+	//! How many tasks the adaptive concurrency controller lets run at once, or -1 without WithAdaptiveConcurrency.
+	ConcurrencyLimit int
+	//! How many more retries the retry budget allows in the current window, or -1 without WithRetryBudget.
+	RetryBudgetRemaining int
+}
+
+// ! poolCounters holds the live counters behind Stats. They are updated without taking the pool mutex.
+type poolCounters struct {
+	submitted atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	cancelled atomic.Int64
+	skipped   atomic.Int64
+	shed      atomic.Int64
+	expired   atomic.Int64
+	retries   atomic.Int64
+	panics    atomic.Int64
+	leaked    atomic.Int64
+
+	bucketDropped atomic.Int64
+	bucketDelayed atomic.Int64
+
+	resultsDropped atomic.Int64
+	cpuNanos       atomic.Int64
+
+	submitBlocked      atomic.Int64
+	submitBlockedNanos atomic.Int64
+
+	leakedRunning  atomic.Int64 //! Not cleared by Reset, since it describes live goroutines.
+	quarantined    atomic.Int64 //! Not cleared by Reset, since quarantined slots stay retired.
+	peakQueueDepth atomic.Int64 //! High-water mark of the queue length since creation or the last Reset.
+}
+
+// ! Stats returns a snapshot of the pool's counters.
+func (pool *Pool) Stats() Stats {
+	stats := Stats{
+		Submitted:            pool.counters.submitted.Load(),
+		Completed:            pool.counters.completed.Load(),
+		Failed:               pool.counters.failed.Load(),
+		Cancelled:            pool.counters.cancelled.Load(),
+		Skipped:              pool.counters.skipped.Load(),
+		Shed:                 pool.counters.shed.Load(),
+		Expired:              pool.counters.expired.Load(),
+		Retries:              pool.counters.retries.Load(),
+		Panics:               pool.counters.panics.Load(),
+		Leaked:               pool.counters.leaked.Load(),
+		LeakedRunning:        pool.counters.leakedRunning.Load(),
+		QuarantinedWorkers:   pool.counters.quarantined.Load(),
+		SubmitBlocked:        pool.counters.submitBlocked.Load(),
+		SubmitBlockedTotal:   time.Duration(pool.counters.submitBlockedNanos.Load()),
+		BucketDropped:        pool.counters.bucketDropped.Load(),
+		BucketDelayed:        pool.counters.bucketDelayed.Load(),
+		ResultsDropped:       pool.counters.resultsDropped.Load(),
+		CPUTime:              time.Duration(pool.counters.cpuNanos.Load()),
+		ConcurrencyLimit:     pool.adaptiveConcurrency.currentLimit(),
+		RetryBudgetRemaining: -1,
+	}
+	if pool.retryBudget != nil {
+		stats.RetryBudgetRemaining = pool.retryBudget.remaining()
+	}
+	return stats
+}
+
+// ! GoroutineCount returns the exact number of goroutines the pool owns right now: its workers,
+// ! the goroutines running timed attempts (including leaked ones), the result batcher,
+// ! SubmitAll's watchers, unfinished SubmitBatchAsync batches and the goroutine closing the
+// ! pool for a Shutdown. It drops to zero once a closed pool has fully wound down, which makes
+// ! it handy for asserting in tests that nothing leaked. Short-lived timer callbacks are not
+// ! counted.
+func (pool *Pool) GoroutineCount() int {
+	return int(pool.goroutines.Load())
+}
+
+// ! spawn starts run on a new goroutine that is counted by GoroutineCount while it runs.
+// ! exited, if not nil, is called on that goroutine after it has stopped being counted, so
+// ! whoever waits for it to signal sees an up-to-date count.
+func (pool *Pool) spawn(run func(), exited func()) {
+	pool.goroutines.Add(1)
+	go func() {
+		defer func() {
+			pool.goroutines.Add(-1)
+			if exited != nil {
+				exited()
+			}
+		}()
+		run()
+	}()
+}
+
+// ! PeakQueueDepth returns the largest number of tasks that have been waiting in the queue
+// ! at the same time since the pool was created or last Reset. A peak close to the queue
+// ! size means producers came close to being blocked by backpressure.
+func (pool *Pool) PeakQueueDepth() int {
+	return int(pool.counters.peakQueueDepth.Load())
+}
+
+// ! Reset sets the counters reported by Stats and StatsByClass and the peak queue depth back to
+// ! zero, and empties the list of SlowestTasks.
+// ! It does not affect queued or running tasks.
+func (pool *Pool) Reset() {
+	pool.counters.submitted.Store(0)
+	pool.counters.completed.Store(0)
+	pool.counters.failed.Store(0)
+	pool.counters.cancelled.Store(0)
+	pool.counters.skipped.Store(0)
+	pool.counters.shed.Store(0)
+	pool.counters.expired.Store(0)
+	pool.counters.retries.Store(0)
+	pool.counters.panics.Store(0)
+	pool.counters.leaked.Store(0)
+	pool.counters.submitBlocked.Store(0)
+	pool.counters.submitBlockedNanos.Store(0)
+	pool.counters.resultsDropped.Store(0)
+	pool.counters.cpuNanos.Store(0)
+	pool.counters.bucketDropped.Store(0)
+	pool.counters.bucketDelayed.Store(0)
+	pool.counters.peakQueueDepth.Store(0)
+	pool.slowTasks.reset()
+	pool.classStats.byClass.Clear()
+}
+
+// ! storeMax raises counter to value if value is larger, without taking a lock.
+func storeMax(counter *atomic.Int64, value int64) {
+	for {
+		current := counter.Load()
+		if value <= current || counter.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
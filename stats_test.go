@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestPeakQueueDepthSurvivesUntilReset(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithSlowTaskTracking(2))
+	release := occupy(t, pool)
+	for range 4 {
+		pool.SubmitTagged(map[string]string{ClassTag: "resize"}, noopTask)
+	}
+	if peak := pool.PeakQueueDepth(); peak != 4 {
+		t.Errorf("PeakQueueDepth = %d with four tasks waiting, want 4", peak)
+	}
+	close(release)
+	pool.Wait()
+	if peak := pool.PeakQueueDepth(); peak != 4 {
+		t.Errorf("PeakQueueDepth = %d once the queue drained, want the high-water mark of 4", peak)
+	}
+	if completed := pool.StatsByClass()["resize"].Completed; completed != 4 {
+		t.Fatalf("%d resize tasks completed before Reset, want 4", completed)
+	}
+
+	pool.Reset()
+	if peak := pool.PeakQueueDepth(); peak != 0 {
+		t.Errorf("PeakQueueDepth = %d after Reset, want 0", peak)
+	}
+	if classes := pool.StatsByClass(); len(classes) != 0 {
+		t.Errorf("StatsByClass after Reset = %v, want no classes", classes)
+	}
+	if slowest := pool.SlowestTasks(); len(slowest) != 0 {
+		t.Errorf("SlowestTasks after Reset = %v, want none", slowest)
+	}
+	if stats := pool.Stats(); stats.Completed != 0 || stats.Submitted != 0 {
+		t.Errorf("Stats after Reset = %+v, want the counters cleared", stats)
+	}
+}
+
+func TestResetKeepsLeakedRunning(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithTaskTimeout(time.Millisecond, time.Minute),
+		WithLogger(log.New(io.Discard, "", 0)))
+	stuck := make(chan struct{})
+	defer close(stuck)
+	pool.Submit(stuckTask(stuck))
+	abandonAfterGrace(t, pool, clock, 1)
+
+	pool.Reset()
+	if stats := pool.Stats(); stats.Leaked != 0 || stats.LeakedRunning != 1 {
+		t.Errorf("Leaked %d and LeakedRunning %d after Reset, want 0 and the abandoned task still running",
+			stats.Leaked, stats.LeakedRunning)
+	}
+}
+
+// ! blockedSubmit submits noopTask on a goroutine of its own and returns once the submission
+// ! is waiting to be admitted, which under WithFairSubmit holding the pool mutex proves.
+func blockedSubmit(t *testing.T, pool *Pool) <-chan error {
+	t.Helper()
+	submitted := make(chan error)
+	go func() {
+		_, err := pool.Submit(noopTask)
+		submitted <- err
+	}()
+	returnsWithin(t, time.Second, "the submission to block", func() {
+		for submittersInLine(pool) != 1 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	return submitted
+}
+
+func TestSubmitBlockedCountsWaitsForQueueSpace(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithQueueSize(1), WithClock(clock), WithFairSubmit())
+	release := occupy(t, pool)
+	pool.Submit(noopTask)
+	submitted := blockedSubmit(t, pool)
+	clock.advance(4 * time.Second)
+	close(release)
+	if err := <-submitted; err != nil {
+		t.Fatal(err)
+	}
+	if stats := pool.Stats(); stats.SubmitBlocked != 1 || stats.SubmitBlockedTotal != 4*time.Second {
+		t.Errorf("SubmitBlocked %d for %v, want 1 for 4s", stats.SubmitBlocked, stats.SubmitBlockedTotal)
+	}
+}
+
+func TestSubmitBlockedCountsWaitsForRateLimitTokens(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithRateLimit(1, 1), WithFairSubmit())
+	pool.Submit(noopTask) //! Takes the only token.
+	submitted := blockedSubmit(t, pool)
+	clock.advance(time.Second) //! Fires the timer the submitter sleeps on until its token.
+	returnsWithin(t, time.Second, "the submission once a token was earned", func() {
+		if err := <-submitted; err != nil {
+			t.Error(err)
+		}
+	})
+	if stats := pool.Stats(); stats.SubmitBlocked != 1 || stats.SubmitBlockedTotal != time.Second {
+		t.Errorf("SubmitBlocked %d for %v, want 1 for 1s", stats.SubmitBlocked, stats.SubmitBlockedTotal)
+	}
+}
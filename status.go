@@ -0,0 +1,133 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ! defaultStatusRetention is how many finished tasks Status remembers by default.
+const defaultStatusRetention = 1000
+
+// ! TaskStatus is where a task is in its lifecycle, see Status.
+type TaskStatus int
+
+const (
+	TaskNotFound  TaskStatus = iota //! Never submitted, or finished too long ago to be remembered.
+	TaskQueued                      //! Accepted and waiting: queued, delayed, held or spilled.
+	TaskRunning                     //! Being executed by a worker, retries included.
+	TaskCompleted                   //! Ran to the end without an error.
+	TaskFailed                      //! Its final attempt returned an error.
+	TaskCancelled                   //! Dropped without running to the end: cancelled, shed, expired or skipped.
+)
+
+func (status TaskStatus) String() string {
+	switch status {
+	case TaskQueued:
+		return "queued"
+	case TaskRunning:
+		return "running"
+	case TaskCompleted:
+		return "completed"
+	case TaskFailed:
+		return "failed"
+	case TaskCancelled:
+		return "cancelled"
+	default:
+		return "not found"
+	}
+}
+
+// ! WithStatusRetention sets how many finished tasks Status remembers, 1000 by default. Beyond
+// ! that, the task that least recently finished or was looked up is forgotten and reported as
+// ! TaskNotFound, so polling a task keeps its status alive. Each remembered task costs a few
+// ! dozen bytes; zero remembers none.
+func WithStatusRetention(finished int) Option {
+	return func(pool *Pool) {
+		pool.statuses.capacity = finished
+	}
+}
+
+// ! Status reports where the task with the given id is, for a polling API such as a job status
+// ! endpoint. Tasks that have not finished are always found; finished ones only while they are
+// ! among the last WithStatusRetention finished or looked up. Ids are those of TaskHandle.Id.
+func (pool *Pool) Status(id int) TaskStatus {
+	return pool.statuses.lookup(id)
+}
+
+// ! taskStatuses tracks unfinished tasks and an LRU of finished ones.
+type taskStatuses struct {
+	mutex    sync.Mutex
+	capacity int
+	live     map[int]TaskStatus
+	finished map[int]*list.Element //! Values are finishedStatus, most recently used at the front.
+	recency  list.List
+}
+
+// ! finishedStatus is an entry of the finished LRU.
+type finishedStatus struct {
+	id     int
+	status TaskStatus
+}
+
+// ! set records the status of an unfinished task.
+func (statuses *taskStatuses) set(id int, status TaskStatus) {
+	statuses.mutex.Lock()
+	defer statuses.mutex.Unlock()
+	if statuses.live == nil {
+		statuses.live = make(map[int]TaskStatus)
+	}
+	statuses.live[id] = status
+}
+
+// ! finish moves a task from the unfinished ones to the front of the LRU, evicting the least
+// ! recently used entry when it is full.
+func (statuses *taskStatuses) finish(id int, status TaskStatus) {
+	statuses.mutex.Lock()
+	defer statuses.mutex.Unlock()
+	delete(statuses.live, id)
+	if statuses.capacity <= 0 {
+		return
+	}
+	if statuses.finished == nil {
+		statuses.finished = make(map[int]*list.Element)
+	}
+	statuses.finished[id] = statuses.recency.PushFront(finishedStatus{id: id, status: status})
+	if statuses.recency.Len() > statuses.capacity {
+		oldest := statuses.recency.Back()
+		statuses.recency.Remove(oldest)
+		delete(statuses.finished, oldest.Value.(finishedStatus).id)
+	}
+}
+
+// ! forget drops an unfinished task that left the pool without finishing, see DrainTo.
+func (statuses *taskStatuses) forget(id int) {
+	statuses.mutex.Lock()
+	defer statuses.mutex.Unlock()
+	delete(statuses.live, id)
+}
+
+// ! lookup returns a task's status, refreshing it in the LRU if it has finished.
+func (statuses *taskStatuses) lookup(id int) TaskStatus {
+	statuses.mutex.Lock()
+	defer statuses.mutex.Unlock()
+	if status, ok := statuses.live[id]; ok {
+		return status
+	}
+	if element, ok := statuses.finished[id]; ok {
+		statuses.recency.MoveToFront(element)
+		return element.Value.(finishedStatus).status
+	}
+	return TaskNotFound
+}
+
+// ! statusOf is the final status of a task that ended with outcome and err.
+func statusOf(outcome taskOutcome, err error) TaskStatus {
+	switch {
+	case outcome != outcomeRan:
+		return TaskCancelled
+	case err != nil:
+		return TaskFailed
+	default:
+		return TaskCompleted
+	}
+}
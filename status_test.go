@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStatusFollowsLifecycle(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	running, _ := pool.SubmitFunc(func() { close(started); <-release })
+	<-started
+	queued, _ := pool.Submit(TaskFunc(func(context.Context) error { return errors.New("boom") }))
+	cancelled, _ := pool.Submit(noopTask)
+
+	if got := pool.Status(running.Id()); got != TaskRunning {
+		t.Errorf("running task is %v", got)
+	}
+	if got := pool.Status(queued.Id()); got != TaskQueued {
+		t.Errorf("queued task is %v", got)
+	}
+	cancelled.Cancel()
+	close(release)
+	pool.Wait()
+
+	for handle, want := range map[*TaskHandle]TaskStatus{running: TaskCompleted, queued: TaskFailed, cancelled: TaskCancelled} {
+		if got := pool.Status(handle.Id()); got != want {
+			t.Errorf("task %d is %v, want %v", handle.Id(), got, want)
+		}
+	}
+	if got := pool.Status(99); got != TaskNotFound {
+		t.Errorf("unknown task is %v", got)
+	}
+}
+
+func TestStatusRetentionEvictsLeastRecentlyUsed(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithStatusRetention(2))
+	var handles []*TaskHandle
+	for range 2 {
+		handle, _ := pool.Submit(noopTask)
+		handles = append(handles, handle)
+		<-handle.Done()
+	}
+	pool.Status(handles[0].Id()) //! Polled, so it outlives the second task.
+	third, _ := pool.Submit(noopTask)
+	<-third.Done()
+
+	if got := pool.Status(handles[0].Id()); got != TaskCompleted {
+		t.Errorf("polled task is %v, want completed", got)
+	}
+	if got := pool.Status(handles[1].Id()); got != TaskNotFound {
+		t.Errorf("least recently used task is %v, want not found", got)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"sync"
+)
+
+// ! ResultStream writes finished results to an io.Writer, see StreamResults.
+type ResultStream struct {
+	mutex  sync.Mutex //! Serializes writes so results from concurrent workers never interleave.
+	writer io.Writer
+	encode func(Result) ([]byte, error)
+	err    error
+}
+
+// ! StreamResults writes every result that finishes from now on to writer, serialized by
+// ! encode, for example as one JSON document per line. Each encoded result is written
+// ! with a single Write call while holding a lock, so output from concurrent workers is
+// ! never interleaved. The first encode or write error stops the stream, and is reported
+// ! by the returned stream's Err.
+func (pool *Pool) StreamResults(writer io.Writer, encode func(Result) ([]byte, error)) *ResultStream {
+	stream := &ResultStream{writer: writer, encode: encode}
+	pool.addResultSink(stream)
+	return stream
+}
+
+// ! Err returns the error that stopped the stream, if any.
+func (stream *ResultStream) Err() error {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return stream.err
+}
+
+func (stream *ResultStream) add(result Result) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	if stream.err != nil {
+		return
+	}
+	encoded, err := stream.encode(result)
+	if err == nil {
+		_, err = stream.writer.Write(encoded)
+	}
+	stream.err = err
+}
+
+func (stream *ResultStream) close() {}
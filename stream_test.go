@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+// ! overlapWriter records what is written to it and notices overlapping Write calls.
+type overlapWriter struct {
+	buffer     bytes.Buffer
+	writing    atomic.Bool
+	overlapped atomic.Bool
+}
+
+func (writer *overlapWriter) Write(data []byte) (int, error) {
+	if !writer.writing.CompareAndSwap(false, true) {
+		writer.overlapped.Store(true)
+		return 0, errors.New("overlapping write")
+	}
+	defer writer.writing.Store(false)
+	return writer.buffer.Write(data)
+}
+
+func encodeLine(result Result) ([]byte, error) {
+	line, err := json.Marshal(map[string]int{"task": result.TaskId})
+	return append(line, '\n'), err
+}
+
+func TestStreamResultsWritesOneLinePerResult(t *testing.T) {
+	pool, err := New(WithWorkers(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var writer overlapWriter
+	stream := pool.StreamResults(&writer, encodeLine)
+	for range 200 {
+		pool.Submit(noopTask)
+	}
+	pool.Close()
+
+	if writer.overlapped.Load() || stream.Err() != nil {
+		t.Fatalf("concurrent results were written at the same time: %v", stream.Err())
+	}
+	seen := map[int]bool{}
+	scanner := bufio.NewScanner(&writer.buffer)
+	for scanner.Scan() {
+		var line map[string]int
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %q is not a whole result: %v", scanner.Text(), err)
+		}
+		seen[line["task"]] = true
+	}
+	if len(seen) != 200 {
+		t.Errorf("%d distinct results streamed, want 200", len(seen))
+	}
+}
+
+func TestStreamResultsStopsAtTheFirstError(t *testing.T) {
+	pool, err := New(WithWorkers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errUnencodable := errors.New("unencodable")
+	var encoded atomic.Int32
+	stream := pool.StreamResults(&bytes.Buffer{}, func(result Result) ([]byte, error) {
+		if encoded.Add(1) == 2 {
+			return nil, errUnencodable
+		}
+		return fmt.Appendf(nil, "%d\n", result.TaskId), nil
+	})
+	for range 5 {
+		pool.Submit(noopTask)
+	}
+	pool.Close()
+	if !errors.Is(stream.Err(), errUnencodable) {
+		t.Errorf("Err = %v, want the encode error", stream.Err())
+	}
+	if got := encoded.Load(); got != 2 {
+		t.Errorf("encode ran %d times, want the stream to stop after it failed", got)
+	}
+}
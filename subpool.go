@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// ! SubPool is a slice of a parent pool's capacity: its tasks run on the parent's workers, but
+// ! no more than its own limit of them at once, and it can be closed or cancelled on its own,
+// ! for instance when the request it was carved out for ends, without touching the parent or
+// ! other sub-pools. Tasks beyond the limit wait in the sub-pool, in submission order, and do
+// ! not take up space in the parent's queue.
+type SubPool struct {
+	parent        *Pool
+	maxConcurrent int
+	context       context.Context //! Cancelled by Cancel, and with it the contexts of running tasks.
+	cancel        context.CancelFunc
+
+	mutex      sync.Mutex
+	backlog    []*subTask //! Tasks waiting for one of the sub-pool's slots, oldest first.
+	running    int
+	closed     bool
+	lastTaskId int
+	finished   sync.WaitGroup //! Counts tasks that have been accepted but not finished.
+}
+
+// ! subTask is a task waiting in a sub-pool together with the handle returned for it.
+type subTask struct {
+	work   Task
+	handle *TaskHandle
+}
+
+// ! SubPool returns a new sub-pool that runs at most maxConcurrent tasks at a time on this
+// ! pool's workers. maxConcurrent below 1 counts as 1.
+func (pool *Pool) SubPool(maxConcurrent int) *SubPool {
+	subContext, cancel := context.WithCancel(context.Background())
+	return &SubPool{parent: pool, maxConcurrent: max(maxConcurrent, 1), context: subContext, cancel: cancel}
+}
+
+// ! Submit queues a task in the sub-pool and returns a handle of the sub-pool's own; its ids
+// ! are sequential per sub-pool. Once the sub-pool is closed it returns ErrPoolClosed. If the
+// ! parent refuses the task later, the handle finishes with the parent's submission error.
+func (sub *SubPool) Submit(work Task) (*TaskHandle, error) {
+	sub.mutex.Lock()
+	if sub.closed {
+		rejection := &RejectionError{Reason: RejectedPoolClosed, QueueDepth: len(sub.backlog), Err: ErrPoolClosed}
+		sub.mutex.Unlock()
+		return nil, rejection
+	}
+	sub.lastTaskId++
+	waiting := &subTask{work: work, handle: newTaskHandle(sub.lastTaskId)}
+	sub.backlog = append(sub.backlog, waiting)
+	sub.finished.Add(1)
+	startable := sub.takeStartable()
+	sub.mutex.Unlock()
+
+	sub.start(startable)
+	return waiting.handle, nil
+}
+
+// ! Close stops the sub-pool from accepting tasks and waits until everything it accepted has
+// ! finished. It is idempotent. The parent pool keeps running.
+func (sub *SubPool) Close() {
+	sub.mutex.Lock()
+	sub.closed = true
+	sub.mutex.Unlock()
+	sub.finished.Wait()
+}
+
+// ! Cancel is a Close that does not wait for the work: tasks still waiting in the sub-pool are
+// ! cancelled, and the contexts of its running tasks are cancelled. It then waits for the
+// ! running tasks to return. Tasks the sub-pool already handed to the parent's queue run
+// ! anyway, but with a cancelled context.
+func (sub *SubPool) Cancel() {
+	sub.mutex.Lock()
+	sub.closed = true
+	dropped := sub.backlog
+	sub.backlog = nil
+	sub.mutex.Unlock()
+
+	sub.cancel()
+	for _, droppedTask := range dropped {
+		droppedTask.handle.finish(outcomeCancelled, ErrTaskCancelled)
+		sub.finished.Done()
+	}
+	sub.finished.Wait()
+}
+
+// ! takeStartable removes from the backlog as many tasks as there are free slots and counts
+// ! them as running. The sub-pool mutex must be held.
+func (sub *SubPool) takeStartable() []*subTask {
+	free := min(sub.maxConcurrent-sub.running, len(sub.backlog))
+	if free <= 0 {
+		return nil
+	}
+	startable := sub.backlog[:free:free]
+	sub.backlog = sub.backlog[free:]
+	sub.running += free
+	return startable
+}
+
+// ! start hands tasks to the parent, whose Submit may block, so the sub-pool mutex must not be held.
+func (sub *SubPool) start(startable []*subTask) {
+	for _, starting := range startable {
+		work := starting.work
+		parentHandle, err := sub.parent.Submit(TaskFunc(func(ctx context.Context) error {
+			runContext, cancel := context.WithCancel(ctx)
+			defer cancel()
+			stop := context.AfterFunc(sub.context, cancel)
+			defer stop()
+			return work.Run(runContext)
+		}))
+		if err != nil {
+			sub.finish(starting, outcomeRan, err)
+			continue
+		}
+		sub.parent.spawn(func() {
+			<-parentHandle.done
+			sub.finish(starting, taskOutcome(parentHandle.outcome.Load()), parentHandle.err)
+		}, nil)
+	}
+}
+
+// ! finish completes a task's sub-pool handle and frees its slot for the next waiting task.
+func (sub *SubPool) finish(finishedTask *subTask, outcome taskOutcome, err error) {
+	finishedTask.handle.finish(outcome, err)
+	sub.mutex.Lock()
+	sub.running--
+	startable := sub.takeStartable()
+	sub.mutex.Unlock()
+	sub.finished.Done()
+	sub.start(startable)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubPoolRunsNoMoreThanItsLimit(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(4))
+	sub := pool.SubPool(2)
+	started, release := make(chan struct{}, 4), make(chan struct{})
+	var handles []*TaskHandle
+	for range 4 {
+		handle, _ := sub.Submit(TaskFunc(func(context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}))
+		handles = append(handles, handle)
+	}
+	for range 2 {
+		<-started
+	}
+	select {
+	case <-started:
+		t.Fatal("a third task started beside two under a limit of 2")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if depth := len(pool.PendingTasks()); depth != 0 {
+		t.Errorf("the parent queue holds %d tasks, want the sub-pool's backlog kept out of it", depth)
+	}
+	close(release)
+	sub.Close()
+	for index, handle := range handles {
+		if handle.Id() != index+1 || handle.Err() != nil {
+			t.Errorf("task %d has id %d and ended with %v, want id %d and no error", index, handle.Id(), handle.Err(), index+1)
+		}
+	}
+	if _, err := sub.Submit(noopTask); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit to a closed sub-pool returned %v, want ErrPoolClosed", err)
+	}
+	if _, err := pool.Submit(noopTask); err != nil {
+		t.Errorf("the parent refused a task once its sub-pool closed: %v", err)
+	}
+}
+
+func TestSubPoolCancelLeavesTheParentAlone(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2))
+	sub := pool.SubPool(1)
+	started := make(chan struct{})
+	running, _ := sub.Submit(TaskFunc(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	<-started
+	waiting, _ := sub.Submit(noopTask)
+	release := make(chan struct{})
+	parentTask, _ := pool.Submit(TaskFunc(func(ctx context.Context) error {
+		<-release
+		return ctx.Err()
+	}))
+
+	returnsWithin(t, time.Second, "Cancel", sub.Cancel)
+	if !errors.Is(running.Err(), context.Canceled) {
+		t.Errorf("the running task ended with %v, want its context cancelled", running.Err())
+	}
+	if !errors.Is(waiting.Err(), ErrTaskCancelled) {
+		t.Errorf("the waiting task ended with %v, want ErrTaskCancelled", waiting.Err())
+	}
+	close(release)
+	<-parentTask.Done()
+	if parentTask.Err() != nil {
+		t.Errorf("the parent's own task ended with %v, want it untouched", parentTask.Err())
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+// ! WithSynchronous makes the pool run every task inline on the goroutine that submits it,
+// ! in submission order, without starting any worker goroutines. Submit returns only once
+// ! the task has finished, so by the time it returns the handle is already done and Wait
+// ! has nothing left to wait for. Everything else (retries, timeouts, panic policies, result
+// ! sinks, Stats) behaves as in a concurrent pool, which makes this mode a drop-in for unit
+// ! tests that want deterministic, debuggable runs of code built on the pool. Delayed tasks
+// ! run inline on their timer's goroutine. Queue-related options have no effect.
+func WithSynchronous() Option {
+	return func(pool *Pool) {
+		pool.synchronous = true
+	}
+}
+
+// ! runInline registers and executes a task on the calling goroutine. Each task gets a slot
+// ! of its own, so tasks that submit further tasks, or submitters on several goroutines,
+// ! never share scratch space.
+func (pool *Pool) runInline(newTask *task) (*TaskHandle, error) {
+	pool.mutex.Lock()
+	if pool.closed {
+		rejection := pool.reject(RejectedPoolClosed, ErrPoolClosed)
+		pool.mutex.Unlock()
+		return nil, rejection
+	}
+	pool.register(newTask)
+	pool.mutex.Unlock()
+
+	if !pool.waitForDependencies(newTask) {
+		pool.cancelTask(newTask)
+		return newTask.handle, nil
+	}
+	pool.execute(newTask, newWorkerSlot(syncWorkerId))
+	return newTask.handle, nil
+}
+
+// ! syncWorkerId is the worker id synchronous pools report through WorkerScratch.
+const syncWorkerId = 1
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+)
+
+// ! Task is a unit of work submitted to a Pool. Implementing tasks as types rather than
+// ! closures lets them carry metadata, be compared, and be serialized for persistence.
+// ! Run receives a context that is cancelled when the pool stops wanting the task to run.
+type Task interface {
+	Run(ctx context.Context) error
+}
+
+// ! TaskFunc adapts an ordinary function to the Task interface.
+type TaskFunc func(ctx context.Context) error
+
+// ! Run calls taskFunc(ctx).
+func (taskFunc TaskFunc) Run(ctx context.Context) error {
+	return taskFunc(ctx)
+}
+
+// ! runTask adapts a Task to the signature workers execute.
+func runTask(work Task) func(ctx context.Context, scratch *WorkerScratch) error {
+	return func(ctx context.Context, _ *WorkerScratch) error {
+		return work.Run(ctx)
+	}
+}
+
+// ! runFunc adapts a plain closure to the signature workers execute.
+func runFunc(run func()) func(ctx context.Context, scratch *WorkerScratch) error {
+	return func(context.Context, *WorkerScratch) error {
+		run()
+		return nil
+	}
+}
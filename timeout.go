@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ! States of a timed task attempt, moved forward with a single compare-and-swap so that
+// ! the attempt finishing and the worker abandoning it can never both win.
+const (
+	attemptRunning int32 = iota
+	attemptFinished
+	attemptAbandoned
+)
+
+// ! TimeoutFired says which of a task attempt's timeouts fired, see Result.Timeout.
+type TimeoutFired int
+
+const (
+	NoTimeout   TimeoutFired = iota //! The attempt ended on its own, or was cancelled for another reason.
+	SoftTimeout                     //! Its context was cancelled for running too long, and it returned within the grace period.
+	HardTimeout                     //! It had not returned by the end of the grace period either, see ErrTaskLeaked.
+)
+
+// ! String returns the timeout in lower case.
+func (fired TimeoutFired) String() string {
+	switch fired {
+	case NoTimeout:
+		return "none"
+	case SoftTimeout:
+		return "soft"
+	case HardTimeout:
+		return "hard"
+	default:
+		return fmt.Sprintf("TimeoutFired(%d)", int(fired))
+	}
+}
+
+// ! SubmitWithTimeouts is like Submit with two timeouts for each attempt, overriding those of
+// ! WithTaskTimeout: once soft has passed, the task's context is cancelled so it can wind down
+// ! and clean up; if it is still running once hard has passed, it is considered leaked and
+// ! abandoned, its worker moving on as a replacement, as for WithTaskTimeout's grace period.
+// ! Result.Timeout reports which of the two fired. A hard timeout not after soft abandons the
+// ! task as soon as soft fires. A soft timeout of zero falls back to WithTaskTimeout's; without
+// ! one, hard applies on its own, cancelling the task's context and abandoning it at once if it
+// ! is still running then, and Result.Timeout reports HardTimeout.
+func (pool *Pool) SubmitWithTimeouts(work Task, soft time.Duration, hard time.Duration) (*TaskHandle, error) {
+	return pool.enqueue(&task{work: work, run: runTask(work), timeout: soft, hardTimeout: hard})
+}
+
+// ! runAttemptWithTimeout runs one attempt with the given task context, enforcing the task's
+// ! own timeout or the pool's, if either is configured.
+// ! The attempt runs on its own goroutine so that the worker can walk away from it if it
+// ! ignores cancellation; the result's leaked field reports that this happened.
+func (pool *Pool) runAttemptWithTimeout(taskContext context.Context, currentTask *task, scratch *WorkerScratch) attemptResult {
+	timeout := pool.taskTimeout
+	if currentTask.timeout > 0 {
+		timeout = currentTask.timeout
+	}
+	hardOnly := timeout <= 0 && currentTask.hardTimeout > 0
+	if hardOnly {
+		timeout = currentTask.hardTimeout //! Cancelled and abandoned at once, see SubmitWithTimeouts.
+	}
+	if timeout <= 0 {
+		return pool.runAttempt(taskContext, currentTask, scratch)
+	}
+	ctx, cancel := context.WithTimeout(taskContext, timeout)
+	defer cancel()
+	gate := &outputGate{}
+	ctx = context.WithValue(ctx, outputGateKey{}, gate)
+
+	var state atomic.Int32
+	finished := make(chan attemptResult, 1)
+	var result attemptResult
+	pool.spawn(func() {
+		if pool.pinThreads {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+		}
+		result = pool.runAttempt(ctx, currentTask, scratch)
+	}, func() {
+		if state.CompareAndSwap(attemptRunning, attemptFinished) {
+			finished <- result
+			return
+		}
+		//! The worker gave up on this attempt long ago; it only has to be taken off the books.
+		pool.counters.leakedRunning.Add(-1)
+	})
+
+	select {
+	case result := <-finished:
+		return result
+	case <-ctx.Done():
+	}
+	fired := NoTimeout
+	switch {
+	case taskContext.Err() != nil: //! Cancelled by Cancel, Shutdown or the like.
+	case hardOnly:
+		fired = HardTimeout
+	default:
+		fired = SoftTimeout
+	}
+
+	gracePeriod := pool.leakGracePeriod
+	if currentTask.hardTimeout > 0 {
+		gracePeriod = max(currentTask.hardTimeout-timeout, 0)
+	}
+	graceOver := make(chan struct{})
+	grace := pool.clock.AfterFunc(gracePeriod, func() { close(graceOver) })
+	defer grace.Stop()
+	select {
+	case result := <-finished:
+		result.timeout = fired
+		return result
+	case <-graceOver:
+	}
+
+	pool.counters.leaked.Add(1)
+	if pool.counters.leakedRunning.Load() >= int64(pool.maxLeakedWorkers) {
+		pool.logger.Printf("task %d ignored its timeout, but %d leaked tasks are already running; %s keeps waiting",
+			currentTask.id, pool.maxLeakedWorkers, pool.workerName(scratch.workerId))
+		result := <-finished
+		result.timeout = HardTimeout
+		return result
+	}
+	pool.counters.leakedRunning.Add(1)
+	if !gate.abandon(&state) {
+		//! The attempt returned just as the grace period ran out.
+		pool.counters.leakedRunning.Add(-1)
+		pool.counters.leaked.Add(-1)
+		result := <-finished
+		result.timeout = fired
+		return result
+	}
+	pool.logger.Printf("task %d ignored its timeout of %v; %s abandons it and moves on",
+		currentTask.id, timeout, pool.workerName(scratch.workerId))
+	return attemptResult{err: ErrTaskLeaked, leaked: true, timeout: HardTimeout}
+}
+
+// ! outputGateKey is the context key under which a timed attempt's outputGate is stored.
+type outputGateKey struct{}
+
+// ! outputGate keeps an abandoned attempt from writing its output after the worker has moved
+// ! on, when a later attempt or the submitter may already be reading it.
+type outputGate struct {
+	mutex     sync.Mutex
+	abandoned bool
+}
+
+// ! abandon moves the attempt from running to abandoned and reports whether it won the race
+// ! against the attempt finishing. Once it has, deliverOutput no longer writes anything.
+func (gate *outputGate) abandon(state *atomic.Int32) bool {
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+	gate.abandoned = state.CompareAndSwap(attemptRunning, attemptAbandoned)
+	return gate.abandoned
+}
+
+// ! deliverOutput runs write, which stores what the task produced where its submitter will read
+// ! it, unless the attempt whose context ctx is has been abandoned for ignoring its timeout.
+// ! Helpers that hand task output back through shared variables, such as SubmitFuture, write
+// ! it through here so that a leaked attempt cannot overwrite it after the handle is done.
+func deliverOutput(ctx context.Context, write func()) {
+	gate, ok := ctx.Value(outputGateKey{}).(*outputGate)
+	if !ok {
+		write()
+		return
+	}
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+	if !gate.abandoned {
+		write()
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestSubmitWithTimeoutsReportsWhichFired(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(2), WithLogger(log.New(io.Discard, "", 0)))
+	results := pool.Results()
+	cleanedUp := make(chan struct{})
+	graceful, _ := pool.SubmitWithTimeouts(TaskFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cleanedUp) //! Cleanup between the soft and the hard timeout.
+		return ctx.Err()
+	}), 10*time.Millisecond, time.Second)
+	stuck := make(chan struct{})
+	defer close(stuck)
+	ignoring, _ := pool.SubmitWithTimeouts(TaskFunc(func(context.Context) error {
+		<-stuck
+		return nil
+	}), 10*time.Millisecond, 30*time.Millisecond)
+	returnsWithin(t, time.Second, "both timed tasks", func() { pool.Flush(graceful, ignoring) })
+
+	<-cleanedUp
+	fired := map[int]TimeoutFired{}
+	for range 2 {
+		result := <-results
+		fired[result.TaskId] = result.Timeout
+	}
+	if fired[graceful.Id()] != SoftTimeout || !errors.Is(graceful.Err(), context.DeadlineExceeded) {
+		t.Errorf("the cooperative task reported %v with %v, want a soft timeout", fired[graceful.Id()], graceful.Err())
+	}
+	if fired[ignoring.Id()] != HardTimeout || !errors.Is(ignoring.Err(), ErrTaskLeaked) {
+		t.Errorf("the stuck task reported %v with %v, want a hard timeout", fired[ignoring.Id()], ignoring.Err())
+	}
+}
+
+func TestSubmitWithTimeoutsAppliesHardAlone(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithLogger(log.New(io.Discard, "", 0)))
+	results := pool.Results()
+	stuck := make(chan struct{})
+	defer close(stuck)
+	handle, _ := pool.SubmitWithTimeouts(TaskFunc(func(context.Context) error {
+		<-stuck
+		return nil
+	}), 0, 20*time.Millisecond)
+	returnsWithin(t, time.Second, "the task with only a hard timeout", func() { <-handle.Done() })
+	if result := <-results; result.Timeout != HardTimeout || !errors.Is(handle.Err(), ErrTaskLeaked) {
+		t.Fatalf("reported %v with %v, want a hard timeout", result.Timeout, handle.Err())
+	}
+}
+
+// ! abandonAfterGrace advances the pool's fake clock until timed attempts have been given up
+// ! on leaked times; the grace timer is only set once the soft timeout has fired.
+func abandonAfterGrace(t *testing.T, pool *Pool, clock *fakeClock, leaked int64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Leaked < leaked {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d attempts leaked, want %d", pool.Stats().Leaked, leaked)
+		}
+		clock.advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// ! stuckTask ignores its context until stuck is closed.
+func stuckTask(stuck <-chan struct{}) Task {
+	return TaskFunc(func(context.Context) error {
+		<-stuck
+		return nil
+	})
+}
+
+func TestLeakedTaskIsAbandonedAndItsWorkerMovesOn(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithTaskTimeout(time.Millisecond, time.Minute),
+		WithLogger(log.New(io.Discard, "", 0)))
+	stuck := make(chan struct{})
+	leaking, _ := pool.Submit(stuckTask(stuck))
+	next, _ := pool.Submit(noopTask)
+	if finished(next) {
+		t.Fatal("the next task ran before the grace period of the stuck one was over")
+	}
+
+	abandonAfterGrace(t, pool, clock, 1)
+	returnsWithin(t, time.Second, "the task behind the leaked one", func() { <-next.Done() })
+	if !errors.Is(leaking.Err(), ErrTaskLeaked) {
+		t.Errorf("the stuck task ended with %v, want ErrTaskLeaked", leaking.Err())
+	}
+	if stats := pool.Stats(); stats.LeakedRunning != 1 {
+		t.Errorf("LeakedRunning = %d while the abandoned task still runs, want 1", stats.LeakedRunning)
+	}
+
+	close(stuck)
+	returnsWithin(t, time.Second, "the abandoned task to be taken off the books", func() {
+		for pool.Stats().LeakedRunning != 0 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	if stats := pool.Stats(); stats.Leaked != 1 {
+		t.Errorf("Leaked = %d once the abandoned task returned, want it to stay 1", stats.Leaked)
+	}
+}
+
+func TestMaxLeakedWorkersKeepsTheWorkerWaiting(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(1), WithClock(clock), WithTaskTimeout(time.Millisecond, time.Minute),
+		WithMaxLeakedWorkers(1), WithLogger(log.New(io.Discard, "", 0)))
+	stuck := make(chan struct{})
+	abandoned, _ := pool.Submit(stuckTask(stuck))
+	waitedOn, _ := pool.Submit(stuckTask(stuck))
+	next, _ := pool.Submit(noopTask)
+
+	abandonAfterGrace(t, pool, clock, 1)
+	returnsWithin(t, time.Second, "the first stuck task to be abandoned", func() { <-abandoned.Done() })
+	abandonAfterGrace(t, pool, clock, 2)
+	if finished(next) {
+		t.Fatal("the worker moved on past a second leaked task with the cap of one already reached")
+	}
+	if stats := pool.Stats(); stats.LeakedRunning != 1 {
+		t.Errorf("LeakedRunning = %d at the cap, want 1", stats.LeakedRunning)
+	}
+
+	close(stuck)
+	returnsWithin(t, time.Second, "the task behind the waited-on one", func() { <-next.Done() })
+	if err := waitedOn.Err(); err != nil {
+		t.Errorf("the task waited on past its grace period ended with %v, want its own result", err)
+	}
+}
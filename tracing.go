@@ -0,0 +1,21 @@
+package main
+
+import "context"
+
+// ! WithTraceExtractor copies the trace and span ids of the context given to SubmitCtx into the
+// ! task's Result (see Result.TraceId), so sinks such as WithResultBatcher, which run on their own
+// ! goroutines long after the context is gone, can still correlate results with their trace. The
+// ! extractor is called once per SubmitCtx, on the submitting goroutine, and returns empty ids
+// ! when the context carries no trace. Without it nothing is extracted and the ids stay empty.
+func WithTraceExtractor(extract func(ctx context.Context) (traceId, spanId string)) Option {
+	return func(pool *Pool) {
+		pool.traceExtractor = extract
+	}
+}
+
+// ! stampTrace records the trace of ctx on a task about to be submitted, if tracing is enabled.
+func (pool *Pool) stampTrace(ctx context.Context, newTask *task) {
+	if pool.traceExtractor != nil {
+		newTask.traceId, newTask.spanId = pool.traceExtractor(ctx)
+	}
+}
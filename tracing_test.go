@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+type traceKey struct{}
+
+func TestTraceIdsReachResults(t *testing.T) {
+	extract := func(ctx context.Context) (string, string) {
+		if trace, ok := ctx.Value(traceKey{}).(string); ok {
+			return trace, "span-of-" + trace
+		}
+		return "", ""
+	}
+	pool := newTestPool(t, WithWorkers(1), WithTraceExtractor(extract))
+	results := pool.Results()
+
+	traced := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	if _, err := pool.SubmitCtx(traced, TaskFunc(func(context.Context) error { return nil })); err != nil {
+		t.Fatalf("SubmitCtx: %v", err)
+	}
+	pool.SubmitFunc(func() {})
+	pool.Close()
+
+	var got []Result
+	for result := range results {
+		got = append(got, result)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	if got[0].TraceId != "trace-1" || got[0].SpanId != "span-of-trace-1" {
+		t.Fatalf("traced result carries %q/%q", got[0].TraceId, got[0].SpanId)
+	}
+	if got[1].TraceId != "" || got[1].SpanId != "" {
+		t.Fatalf("untraced result carries %q/%q", got[1].TraceId, got[1].SpanId)
+	}
+}
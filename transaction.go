@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ! TwoPhaseTask is one participant of a transaction run by SubmitTransaction.
+type TwoPhaseTask interface {
+	//! Prepare does the work and gets ready to make it permanent, without doing so yet.
+	Prepare(ctx context.Context) error
+	//! Commit makes the prepared work permanent. It is only called if every Prepare succeeded.
+	Commit(ctx context.Context) error
+	//! Rollback undoes whatever Prepare did. It is called on every participant, including the
+	//! one whose Prepare failed, so it must cope with a partial or missing prepare.
+	Rollback(ctx context.Context) error
+}
+
+var (
+	//! ErrTransactionAborted is returned by SubmitTransaction when a prepare failed and the transaction was rolled back.
+	ErrTransactionAborted = errors.New("worker pool: transaction aborted")
+	//! ErrPartialCommit is returned by SubmitTransaction when some commits failed after every prepare had succeeded.
+	ErrPartialCommit = errors.New("worker pool: transaction partially committed")
+)
+
+// ! SubmitTransaction runs an all-or-nothing batch on the pool and blocks until it is over.
+// ! The Prepare phases run in parallel first. If all of them succeed, the Commit phases run,
+// ! also in parallel, and SubmitTransaction returns nil. Otherwise every participant's Rollback
+// ! runs and the error wraps ErrTransactionAborted together with the prepare and rollback
+// ! errors. Once the commit decision is made it is not taken back: if some commits fail, the
+// ! others still commit, nothing is rolled back, and the error wraps ErrPartialCommit together
+// ! with the commit errors, leaving the repair to the caller. A participant the pool refuses
+// ! to run, or drops before running it, counts as failed with the submission error or the
+// ! reason it was dropped, such as ErrTaskCancelled; a rollback the pool refuses or drops is
+// ! run on the calling goroutine instead, so that no participant is left prepared.
+func (pool *Pool) SubmitTransaction(tasks []TwoPhaseTask) error {
+	prepareErrs := pool.runPhase(tasks, TwoPhaseTask.Prepare, false)
+	if prepareErrs == nil {
+		if commitErrs := pool.runPhase(tasks, TwoPhaseTask.Commit, false); commitErrs != nil {
+			return fmt.Errorf("%w: %w", ErrPartialCommit, commitErrs)
+		}
+		return nil
+	}
+	rollbackErrs := pool.runPhase(tasks, TwoPhaseTask.Rollback, true)
+	return fmt.Errorf("%w: %w", ErrTransactionAborted, errors.Join(prepareErrs, rollbackErrs))
+}
+
+// ! runPhase runs one phase of every participant in parallel across the pool, waits for all
+// ! of them, and joins their errors, or returns nil if none failed. With runRefused, a phase
+// ! the pool refuses to accept, or drops before it runs, runs on the calling goroutine instead.
+func (pool *Pool) runPhase(tasks []TwoPhaseTask, phase func(TwoPhaseTask, context.Context) error, runRefused bool) error {
+	errs := make([]error, len(tasks))
+	handles := make([]*TaskHandle, len(tasks))
+	for index, participant := range tasks {
+		handle, err := pool.Submit(TaskFunc(func(ctx context.Context) error {
+			return phase(participant, ctx)
+		}))
+		if err != nil && runRefused {
+			err = phase(participant, context.Background())
+		}
+		errs[index] = err
+		handles[index] = handle
+	}
+	pool.Flush(handles...)
+	for index, handle := range handles {
+		if handle == nil {
+			continue
+		}
+		errs[index] = handle.Err()
+		if taskOutcome(handle.outcome.Load()) == outcomeRan {
+			continue
+		}
+		//! Dropped before it ran, by CancelQueued, Shutdown, shedding or the queue TTL.
+		if errs[index] == nil {
+			errs[index] = fmt.Errorf("worker pool: transaction participant %d was dropped before it ran", index)
+		}
+		if runRefused {
+			errs[index] = phase(tasks[index], context.Background())
+		}
+	}
+	return errors.Join(errs...)
+}
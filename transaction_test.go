@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ! participant records the phases it went through.
+type participant struct {
+	mutex      sync.Mutex
+	prepared   bool
+	committed  bool
+	rolledBack bool
+	prepare    func() error
+}
+
+func (p *participant) Prepare(context.Context) error {
+	if p.prepare != nil {
+		if err := p.prepare(); err != nil {
+			return err
+		}
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.prepared = true
+	return nil
+}
+
+func (p *participant) Commit(context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.committed = true
+	return nil
+}
+
+func (p *participant) Rollback(context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.rolledBack = true
+	return nil
+}
+
+func TestTransactionCommitsWhenEveryPrepareSucceeds(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(3))
+	participants := []*participant{{}, {}, {}}
+	if err := pool.SubmitTransaction([]TwoPhaseTask{participants[0], participants[1], participants[2]}); err != nil {
+		t.Fatalf("SubmitTransaction: %v", err)
+	}
+	for index, p := range participants {
+		if !p.prepared || !p.committed || p.rolledBack {
+			t.Errorf("participant %d: prepared %v, committed %v, rolled back %v", index, p.prepared, p.committed, p.rolledBack)
+		}
+	}
+}
+
+func TestTransactionAbortsWhenAPrepareIsCancelled(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1))
+	started, release := make(chan struct{}), make(chan struct{})
+	first := &participant{prepare: func() error {
+		close(started)
+		<-release
+		return nil
+	}}
+	participants := []*participant{first, {}, {}}
+
+	var err error
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		err = pool.SubmitTransaction([]TwoPhaseTask{participants[0], participants[1], participants[2]})
+	}()
+	<-started
+	time.Sleep(10 * time.Millisecond) //! Let the other prepares reach the queue.
+	if dropped := pool.CancelQueued(); dropped != 2 {
+		t.Fatalf("CancelQueued dropped %d prepares, want 2", dropped)
+	}
+	close(release)
+	<-finished
+
+	if !errors.Is(err, ErrTransactionAborted) || !errors.Is(err, ErrTaskCancelled) {
+		t.Fatalf("SubmitTransaction returned %v, want an abort caused by the cancellation", err)
+	}
+	for index, p := range participants {
+		if p.committed || !p.rolledBack {
+			t.Errorf("participant %d: committed %v, rolled back %v", index, p.committed, p.rolledBack)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ! TypedPool wraps a Pool around a single handler function with typed input and output,
+// ! turning a bounded set of workers into a concurrency-capped, context-aware call.
+type TypedPool[T, R any] struct {
+	pool    *Pool
+	handler atomic.Pointer[func(ctx context.Context, input T) (R, error)] //! Swapped by SetHandler.
+
+	streams     atomic.Pointer[typedStreams[T, R]] //! Successes and Failures; nil until first asked for.
+	streamsOnce sync.Once
+}
+
+// ! NewTyped creates a typed pool with the given number of workers that runs handler for
+// ! every input. Further options configure the underlying Pool, and errors from New are returned as is.
+func NewTyped[T, R any](workers int, handler func(ctx context.Context, input T) (R, error), options ...Option) (*TypedPool[T, R], error) {
+	pool, err := New(append([]Option{WithWorkers(workers)}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	typed := &TypedPool[T, R]{pool: pool}
+	typed.handler.Store(&handler)
+	return typed, nil
+}
+
+// ! SetHandler replaces the handler without recreating the pool, as for a hot reload of the
+// ! task logic. Each input runs against the handler current when a worker picks it up, so the
+// ! queued inputs run with the new one, while the calls already in progress finish with the old.
+// ! handler must not be nil.
+func (typed *TypedPool[T, R]) SetHandler(handler func(ctx context.Context, input T) (R, error)) {
+	typed.handler.Store(&handler)
+}
+
+// ! Pool returns the underlying pool, for stats and other pool-wide operations.
+func (typed *TypedPool[T, R]) Pool() *Pool {
+	return typed.pool
+}
+
+// ! SubmitAndWait runs the handler for input on one of the workers and returns its output.
+// ! The handler's context is cancelled when ctx is, and SubmitAndWait returns ctx.Err() as
+// ! soon as ctx ends, without waiting for the handler. If the pool is closed, or closes before
+// ! the handler ran, as when Shutdown gives up on the queued input, it returns an error
+// ! matching ErrPoolClosed; one dropped by CancelQueued reports ErrTaskCancelled.
+func (typed *TypedPool[T, R]) SubmitAndWait(ctx context.Context, input T) (R, error) {
+	var output R
+	var zero R
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	handle, err := typed.pool.Submit(TaskFunc(func(taskContext context.Context) error {
+		if err := ctx.Err(); err != nil {
+			return err //! The caller gave up while the task was queued.
+		}
+		runContext, cancel := context.WithCancel(taskContext)
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+
+		handler := *typed.handler.Load()
+		value, handlerErr := handler(runContext, input)
+		deliverOutput(taskContext, func() { output = value })
+		return handlerErr
+	}))
+	if err != nil {
+		return zero, err
+	}
+	select {
+	case <-handle.Done():
+		if err := typed.pool.waitError(handle); err != nil {
+			return zero, err
+		}
+		return output, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// ! Close closes the underlying pool, see Pool.Close.
+func (typed *TypedPool[T, R]) Close() {
+	typed.pool.Close()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubmitAndWaitReportsShutdown(t *testing.T) {
+	release := make(chan struct{})
+	typed, err := NewTyped(1, func(ctx context.Context, input int) (int, error) {
+		if input == 0 {
+			<-release
+		}
+		return input * 2, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(release)
+	go typed.SubmitAndWait(context.Background(), 0) //! Occupies the only worker.
+	time.Sleep(10 * time.Millisecond)
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := typed.SubmitAndWait(context.Background(), 21)
+		waited <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	typed.Pool().Shutdown(ctx)
+	select {
+	case err := <-waited:
+		if !errors.Is(err, ErrPoolClosed) || !errors.Is(err, ErrTaskCancelled) {
+			t.Errorf("SubmitAndWait for an input Shutdown gave up on returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SubmitAndWait did not return once Shutdown gave up on its input")
+	}
+	if _, err := typed.SubmitAndWait(context.Background(), 1); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("SubmitAndWait on a closed pool returned %v", err)
+	}
+}
+
+func TestSubmitAndWaitReturnsOutput(t *testing.T) {
+	typed, err := NewTyped(2, func(ctx context.Context, input int) (int, error) { return input * 2, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer typed.Close()
+	if output, err := typed.SubmitAndWait(context.Background(), 21); output != 42 || err != nil {
+		t.Errorf("SubmitAndWait returned %d, %v, want 42, nil", output, err)
+	}
+}
+
+func TestSetHandlerAppliesAtDispatch(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	typed, err := NewTyped(1, func(ctx context.Context, input int) (string, error) {
+		if input == 0 {
+			close(started)
+			<-release
+		}
+		return "v1", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer typed.Close()
+	inFlight := make(chan string, 1)
+	go func() {
+		output, _ := typed.SubmitAndWait(context.Background(), 0)
+		inFlight <- output
+	}()
+	<-started
+	queued := make(chan string, 1)
+	go func() {
+		output, _ := typed.SubmitAndWait(context.Background(), 1)
+		queued <- output
+	}()
+	time.Sleep(10 * time.Millisecond) //! Let input 1 reach the queue.
+
+	typed.SetHandler(func(ctx context.Context, input int) (string, error) { return "v2", nil })
+	close(release)
+	if output := <-inFlight; output != "v1" {
+		t.Errorf("the call in progress returned %q, want the old handler's v1", output)
+	}
+	if output := <-queued; output != "v2" {
+		t.Errorf("the queued input returned %q, want the new handler's v2", output)
+	}
+}
+
+func TestSuccessesAndFailuresSplitOutcomes(t *testing.T) {
+	typed, err := NewTyped(2, func(ctx context.Context, input int) (int, error) {
+		if input%2 == 1 {
+			return 0, errors.New("odd input")
+		}
+		return input * 10, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	successes, failures := typed.Successes(), typed.Failures()
+	for input := range 6 {
+		if _, err := typed.Submit(input); err != nil {
+			t.Fatalf("Submit(%d): %v", input, err)
+		}
+	}
+	typed.Pool().Wait()
+	typed.Close()
+
+	sum := 0
+	for output := range successes {
+		sum += output
+	}
+	failed := 0
+	for failure := range failures {
+		if failure.Input%2 != 1 || failure.Err == nil {
+			t.Errorf("unexpected failure %+v", failure)
+		}
+		failed++
+	}
+	if sum != 60 || failed != 3 {
+		t.Errorf("successes summed to %d and %d inputs failed, want 60 and 3", sum, failed)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// ! FailedTask is what TypedPool.Failures delivers for an input whose task failed: the input
+// ! as submitted and the task's final error.
+type FailedTask[T any] struct {
+	Input T
+	Err   error
+}
+
+// ! Submit queues input for the handler without waiting for it, see Pool.Submit, and hands the
+// ! outcome to Successes or Failures once the task has finished, retries included. Inputs
+// ! dropped before they ran, as by CancelQueued, count as failures with the error the handle
+// ! reports. Outcomes of tasks finishing before the first call to Successes or Failures are
+// ! not delivered.
+func (typed *TypedPool[T, R]) Submit(input T) (*TaskHandle, error) {
+	var output R
+	return typed.pool.SubmitCallback(TaskFunc(func(ctx context.Context) error {
+		handler := *typed.handler.Load()
+		value, err := handler(ctx, input)
+		deliverOutput(ctx, func() { output = value })
+		return err
+	}), func(err error) { typed.publish(input, output, err) })
+}
+
+// ! Successes returns a channel receiving the output of every input given to Submit whose
+// ! handler succeeded, so a writer can stream them without branching on errors; failures go
+// ! to Failures instead. Both channels are created by the first call to either, are buffered
+// ! like Pool.Results and follow its delivery rules: outputs that do not fit are dropped and
+// ! counted in Stats as ResultsDropped, unless WithStrictResultDelivery makes the finishing
+// ! goroutine wait, in which case both channels must be read. They are closed once Close has
+// ! finished.
+func (typed *TypedPool[T, R]) Successes() <-chan R {
+	return typed.outcomeStreams().successes
+}
+
+// ! Failures returns a channel receiving every input given to Submit whose task failed, with
+// ! its error, for a retry or alerting path; see Successes for how both channels behave.
+func (typed *TypedPool[T, R]) Failures() <-chan FailedTask[T] {
+	return typed.outcomeStreams().failures
+}
+
+// ! outcomeStreams returns the Successes and Failures channels, creating them on first use.
+func (typed *TypedPool[T, R]) outcomeStreams() *typedStreams[T, R] {
+	typed.streamsOnce.Do(func() {
+		pool := typed.pool
+		streams := &typedStreams[T, R]{
+			successes: make(chan R, pool.queueSize+pool.totalWorkers),
+			failures:  make(chan FailedTask[T], pool.queueSize+pool.totalWorkers),
+		}
+		typed.streams.Store(streams)
+		pool.addResultSink(streams) //! Only to be closed along with the other sinks.
+	})
+	return typed.streams.Load()
+}
+
+// ! publish hands a finished Submit task's outcome to its channel, if there are channels.
+func (typed *TypedPool[T, R]) publish(input T, output R, err error) {
+	streams := typed.streams.Load()
+	if streams == nil {
+		return
+	}
+	if err != nil {
+		sendOutcome(typed.pool, streams.failures, FailedTask[T]{Input: input, Err: err})
+	} else {
+		sendOutcome(typed.pool, streams.successes, output)
+	}
+}
+
+// ! sendOutcome delivers value on channel, dropping it when there is no room, unless the pool
+// ! has WithStrictResultDelivery.
+func sendOutcome[V any](pool *Pool, channel chan V, value V) {
+	if pool.strictResults {
+		channel <- value
+		return
+	}
+	select {
+	case channel <- value:
+	default:
+		pool.counters.resultsDropped.Add(1)
+	}
+}
+
+// ! typedStreams holds the Successes and Failures channels. It is a result sink only so that
+// ! Close closes the channels after the last outcome was sent.
+type typedStreams[T, R any] struct {
+	successes chan R
+	failures  chan FailedTask[T]
+	closing   sync.Once
+}
+
+func (streams *typedStreams[T, R]) add(Result) {}
+
+func (streams *typedStreams[T, R]) close() {
+	streams.closing.Do(func() {
+		close(streams.successes)
+		close(streams.failures)
+	})
+}
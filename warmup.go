@@ -0,0 +1,29 @@
+package main
+
+// ! WithWarmup runs warmup on every worker after its init (see WithWorkerInit) and before it
+// ! pulls its first task, to prime whatever makes tasks slow the first time, such as a
+// ! connection pool or a cache, so the first real task is as fast as the rest. Tasks submitted
+// ! meanwhile wait in the queue, and WaitReady counts a worker as ready only once it is warm.
+// ! Unlike a failing init, a warmup that returns an error or panics is logged and the worker
+// ! starts anyway, cold; use WithWorkerInit for priming the worker cannot do without. It runs
+// ! again for the replacement whenever a worker is recycled.
+func WithWarmup(warmup func(workerId int) error) Option {
+	return func(pool *Pool) {
+		pool.warmup = warmup
+	}
+}
+
+// ! warmUp runs the warmup hook for a starting worker, logging instead of failing.
+func (pool *Pool) warmUp(workerId int) {
+	if pool.warmup == nil {
+		return
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			pool.logger.Printf("%s panicked during warmup and starts cold: %v", pool.workerName(workerId), recovered)
+		}
+	}()
+	if err := pool.warmup(workerId); err != nil {
+		pool.logger.Printf("%s failed to warm up and starts cold: %v", pool.workerName(workerId), err)
+	}
+}
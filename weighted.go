@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ! WithConcurrencyBudget bounds the total cost of the tasks running at once, on top of the
+// ! worker count: with a budget of 10, ten tasks of cost 1 or two of cost 5 can run together.
+// ! Use it to cap aggregate resource use, such as memory or database rows touched, rather
+// ! than the raw number of tasks. Tasks submitted without a cost cost 1. A worker that picks
+// ! up a task waits until its cost fits in the budget; waiting tasks are served in order, so
+// ! a stream of cheap tasks cannot starve an expensive one. See SubmitWeighted.
+func WithConcurrencyBudget(total int) Option {
+	return func(pool *Pool) {
+		pool.budget = &weightedSemaphore{size: total}
+	}
+}
+
+// ! SubmitWeighted is like Submit, but the task takes cost units of the concurrency budget
+// ! while it runs (see WithConcurrencyBudget). A cost larger than the whole budget could never
+// ! run, so it is rejected with ErrCostExceedsBudget. Without a budget the cost is ignored.
+func (pool *Pool) SubmitWeighted(work Task, cost int) (*TaskHandle, error) {
+	if pool.budget != nil && cost > pool.budget.size {
+		return nil, pool.rejectUnlocked(RejectedCostExceedsBudget,
+			fmt.Errorf("%w: cost %d, budget %d", ErrCostExceedsBudget, cost, pool.budget.size))
+	}
+	return pool.enqueue(&task{work: work, run: runTask(work), cost: cost})
+}
+
+// ! SubmitWithRelease is like SubmitWeighted for tasks that only need the scarce resource behind
+// ! the concurrency budget for the first part of their run. run receives a release function;
+// ! calling it hands the task's budget units back at once, so a waiting task can start, while
+// ! run carries on with work that does not need the resource. Only the budget is freed: the
+// ! worker stays busy until run returns. Calling release again, or from another goroutine, is
+// ! harmless, and units not released early are returned when the task finishes. If the task
+// ! fails after releasing and is retried, the retry waits to take its units again first.
+// ! Without a budget, release does nothing.
+func (pool *Pool) SubmitWithRelease(cost int, run func(ctx context.Context, release func()) error) (*TaskHandle, error) {
+	if pool.budget != nil && cost > pool.budget.size {
+		return nil, pool.rejectUnlocked(RejectedCostExceedsBudget,
+			fmt.Errorf("%w: cost %d, budget %d", ErrCostExceedsBudget, cost, pool.budget.size))
+	}
+	return pool.enqueue(&task{cost: cost, run: func(ctx context.Context, _ *WorkerScratch) error {
+		lease, _ := ctx.Value(budgetLeaseKey{}).(*budgetLease)
+		return run(ctx, lease.release)
+	}})
+}
+
+// ! costOf returns how many budget units a task takes.
+func costOf(weightedTask *task) int {
+	if weightedTask.cost <= 0 {
+		return 1
+	}
+	return weightedTask.cost
+}
+
+// ! budgetLeaseKey is the context key under which a task's budgetLease is stored.
+type budgetLeaseKey struct{}
+
+// ! budgetLease is the share of the concurrency budget one running task holds. Its methods
+// ! are safe on a nil lease, which stands for a pool without a budget.
+type budgetLease struct {
+	semaphore *weightedSemaphore
+	units     int
+	mutex     sync.Mutex
+	held      bool
+}
+
+// ! leaseBudget blocks until the task's cost fits in the budget and returns the lease, or nil
+// ! without a budget.
+func (pool *Pool) leaseBudget(weightedTask *task) *budgetLease {
+	if pool.budget == nil {
+		return nil
+	}
+	lease := &budgetLease{semaphore: pool.budget, units: costOf(weightedTask)}
+	lease.reacquire()
+	return lease
+}
+
+// ! reacquire takes the units again if they were released, blocking until they fit.
+func (lease *budgetLease) reacquire() {
+	if lease == nil {
+		return
+	}
+	lease.mutex.Lock()
+	defer lease.mutex.Unlock()
+	if !lease.held {
+		lease.semaphore.acquire(lease.units)
+		lease.held = true
+	}
+}
+
+// ! release returns the units if they are still held.
+func (lease *budgetLease) release() {
+	if lease == nil {
+		return
+	}
+	lease.mutex.Lock()
+	defer lease.mutex.Unlock()
+	if lease.held {
+		lease.semaphore.release(lease.units)
+		lease.held = false
+	}
+}
+
+// ! weightedSemaphore hands out units of a fixed size in strict FIFO order.
+type weightedSemaphore struct {
+	mutex   sync.Mutex
+	size    int
+	used    int
+	waiters []semaphoreWaiter
+}
+
+// ! semaphoreWaiter is a blocked acquire, woken by closing ready.
+type semaphoreWaiter struct {
+	units int
+	ready chan struct{}
+}
+
+// ! acquire blocks until units are available and nobody queued earlier is still waiting.
+func (semaphore *weightedSemaphore) acquire(units int) {
+	semaphore.mutex.Lock()
+	if len(semaphore.waiters) == 0 && semaphore.used+units <= semaphore.size {
+		semaphore.used += units
+		semaphore.mutex.Unlock()
+		return
+	}
+	waiter := semaphoreWaiter{units: units, ready: make(chan struct{})}
+	semaphore.waiters = append(semaphore.waiters, waiter)
+	semaphore.mutex.Unlock()
+	<-waiter.ready
+}
+
+// ! release returns units and wakes as many waiters, in order, as now fit.
+func (semaphore *weightedSemaphore) release(units int) {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+	semaphore.used -= units
+	for len(semaphore.waiters) > 0 {
+		next := semaphore.waiters[0]
+		if semaphore.used+next.units > semaphore.size {
+			return
+		}
+		semaphore.used += next.units
+		semaphore.waiters = semaphore.waiters[1:]
+		close(next.ready)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+	"sync"
+)
+
+// ! WithOrderedWindow makes every result sink, such as Results or StreamResults, receive results
+// ! in submission order while holding at most n of them back: a result is passed on as soon as
+// ! all earlier tasks have finished, and a worker whose task is n or more ahead of the oldest
+// ! unfinished one waits before handing its result over, so a slow task applies backpressure
+// ! instead of letting the reorder buffer grow. Tasks dropped without running leave no gap. The
+// ! window relies on tasks being dispatched in submission order: priorities, delayed tasks and
+// ! dependencies let later tasks overtake earlier ones, and can leave every worker waiting for
+// ! a task that is still queued. Close lifts the window and passes on whatever is still held
+// ! back in order.
+func WithOrderedWindow(n int) Option {
+	return func(pool *Pool) {
+		window := &orderedWindow{size: max(n, 1), next: 1, pending: make(map[int]orderedEntry)}
+		window.changed = sync.NewCond(&window.mutex)
+		pool.orderedWindow = window
+	}
+}
+
+// ! orderedWindow reorders results by task id, see WithOrderedWindow.
+type orderedWindow struct {
+	mutex   sync.Mutex
+	changed *sync.Cond //! Broadcast whenever next advances or the window is lifted.
+	size    int
+	next    int                  //! Id of the next result to pass on.
+	pending map[int]orderedEntry //! Results, and tasks that produced none, waiting for earlier ids.
+	lifted  bool                 //! Set by Close; from then on results pass straight through.
+}
+
+// ! orderedEntry is a finished task waiting for its turn. Dropped tasks only take up their id.
+type orderedEntry struct {
+	result  Result
+	dropped bool
+}
+
+// ! publish passes result to deliver in id order, waiting while it is too far ahead of the
+// ! oldest unfinished task. Without a window the result is delivered at once.
+func (window *orderedWindow) publish(result Result, deliver func(Result)) {
+	if window == nil {
+		deliver(result)
+		return
+	}
+	window.mutex.Lock()
+	defer window.mutex.Unlock()
+	for !window.lifted && result.TaskId >= window.next+window.size {
+		window.changed.Wait()
+	}
+	if window.lifted {
+		deliver(result)
+		return
+	}
+	window.pending[result.TaskId] = orderedEntry{result: result}
+	window.flush(deliver)
+}
+
+// ! skip records that a task finished without a result, so later results need not wait for it.
+func (window *orderedWindow) skip(taskId int, deliver func(Result)) {
+	if window == nil {
+		return
+	}
+	window.mutex.Lock()
+	defer window.mutex.Unlock()
+	if window.lifted || taskId < window.next {
+		return
+	}
+	window.pending[taskId] = orderedEntry{dropped: true}
+	window.flush(deliver)
+}
+
+// ! flush passes on every result whose predecessors have all been passed on. The window
+// ! mutex must be held.
+func (window *orderedWindow) flush(deliver func(Result)) {
+	advanced := false
+	for {
+		entry, ok := window.pending[window.next]
+		if !ok {
+			break
+		}
+		delete(window.pending, window.next)
+		window.next++
+		advanced = true
+		if !entry.dropped {
+			deliver(entry.result)
+		}
+	}
+	if advanced {
+		window.changed.Broadcast()
+	}
+}
+
+// ! lift passes on whatever is still held back, in id order despite gaps, and releases every
+// ! waiting worker.
+func (window *orderedWindow) lift(deliver func(Result)) {
+	if window == nil {
+		return
+	}
+	window.mutex.Lock()
+	defer window.mutex.Unlock()
+	window.lifted = true
+	ids := make([]int, 0, len(window.pending))
+	for taskId := range window.pending {
+		ids = append(ids, taskId)
+	}
+	slices.Sort(ids)
+	for _, taskId := range ids {
+		if entry := window.pending[taskId]; !entry.dropped {
+			deliver(entry.result)
+		}
+	}
+	clear(window.pending)
+	window.changed.Broadcast()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrderedWindowDeliversInSubmissionOrder(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(4), WithOrderedWindow(3))
+	results := pool.Results()
+	for index := range 12 {
+		if index == 5 {
+			pool.SubmitGuarded(func() bool { return false }, func() {}) //! Leaves no result behind.
+			continue
+		}
+		pool.SubmitFunc(func() { time.Sleep(time.Duration(12-index) * time.Millisecond) })
+	}
+
+	want := 1
+	for range 11 {
+		result := <-results
+		if want == 6 {
+			want++
+		}
+		if result.TaskId != want {
+			t.Fatalf("got the result of task %d, want task %d", result.TaskId, want)
+		}
+		want++
+		pool.orderedWindow.mutex.Lock()
+		held := len(pool.orderedWindow.pending)
+		pool.orderedWindow.mutex.Unlock()
+		if held > 3 {
+			t.Fatalf("the window holds %d results, more than its size", held)
+		}
+	}
+}
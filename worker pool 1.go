@@ -1,32 +1,16 @@
 // ! This Go program demonstrates a simple task processing workflow using goroutines and channels.
-// ! It initializes a task queue, creates multiple worker goroutines to process tasks concurrently,
-// ! sends a specified number of tasks to the workers, and waits for all tasks to be completed
-// ! before printing a confirmation message. The use of a WaitGroup ensures that the main program
-// ! waits for all workers to finish processing before exiting.
+// ! It creates a worker pool, submits a specified number of tasks to it, waits for a few specific
+// ! tasks using their handles, and then closes the pool, which waits for all remaining tasks to be
+// ! completed before printing a confirmation message. The pool itself lives in pool.go.
 package main
 
 import (
 	"fmt"  //! For printing output.
-	"sync" //! To use synchronization primitives, specifically WaitGroup for ensuring all goroutines complete before the program exits.
 	"time" //! To simulate task processing time with Sleep.
 )
 
-// ! Worker function, This function simulates the task processing done by each worker.
-// ! workerId: A unique identifier for the worker.
-// ! tasksChannel: The channel from which the worker will fetch tasks.
-// ! waitGroup: A pointer to the sync.WaitGroup, used to signal when the worker has completed all its tasks.
-func worker(workerId int, tasksChannel chan int, waitGroup *sync.WaitGroup) {
-	//! This ensures that waitGroup.Done() is called when the worker function exits. It's used to signal that a goroutine has finished its work, and the WaitGroup can proceed.
-	//! Done decrements the [WaitGroup] counter by one.
-	defer waitGroup.Done()
-	//! This loop reads tasks from the tasks channel until it's closed. Each task is processed by the worker.
-	for taskId := range tasksChannel {
-		executeTask(workerId, taskId)
-	}
-}
-
-func executeTask(workerId int, taskId int) {
-	fmt.Printf("Worker %d processing task %d\n", workerId, taskId)
+func executeTask(taskId int) {
+	fmt.Printf("Processing task %d\n", taskId)
 	//! simulates a task that takes 1 second to process.
 	time.Sleep(time.Second)
 }
@@ -37,46 +21,45 @@ func main() {
 	const totalWorkers = 3
 	//! The total number of tasks to be processed (10 tasks in this case).
 	const totalRequestsAllowed = 10
-	//! Creates a buffered channel that can hold up to 10 tasksChannel. A buffered channel is useful here because it allows tasksChannel to be queued while the workers are still processing others.
-	tasksChannel := make(chan int, totalRequestsAllowed) //! Buffered Channel
-	//! Initializes a WaitGroup that will be used to wait for all workers to finish processing their tasks.
-	var waitGroup sync.WaitGroup
-
-	//! Start workers
-	//! Loops over the number of workers (3 workers in this case).
-	for workerIndex := 1; workerIndex <= totalWorkers; workerIndex++ {
-		//! This increments the WaitGroup counter by 1, indicating that there is one more goroutine to wait for.
-		waitGroup.Add(1)
-		//! Launches a new goroutine that calls the worker function. The worker is assigned an ID (workerIndex), the task channel, and the WaitGroup.
-		go worker(workerIndex, tasksChannel, &waitGroup)
+	//! Creates the pool. Its queue can hold up to 10 tasks, which allows tasks to be queued while the workers are still processing others.
+	pool, err := New(WithWorkers(totalWorkers), WithQueueSize(totalRequestsAllowed))
+	if err != nil {
+		fmt.Println("Could not create the pool:", err)
+		return
 	}
 
 	//! Send tasks to the task queue
-	//! Sends 10 tasks into the tasks channel.
+	//! Submits 10 tasks and keeps the handle of each one.
+	handles := make([]*TaskHandle, 0, totalRequestsAllowed)
 	for taskIndex := 1; taskIndex <= totalRequestsAllowed; taskIndex++ {
-		tasksChannel <- taskIndex
+		taskId := taskIndex
+		handle, err := pool.SubmitFunc(func() { executeTask(taskId) })
+		if err != nil {
+			fmt.Println("Could not submit task:", err)
+			return
+		}
+		handles = append(handles, handle)
 	}
 
-	//! Closes the tasks channel once all tasks have been sent. This signals the workers that no more tasks are coming, and they should stop processing when the channel is empty.
-	close(tasksChannel)
+	//! Blocks until the first three tasks are done, regardless of how far the rest of the queue has progressed.
+	pool.Flush(handles[:3]...)
+	fmt.Println("First three tasks processed.")
 
-	//! This blocks the main program from exiting until all workers have completed their tasks. Each worker signals completion using wg.Done().
-	waitGroup.Wait()
+	//! Closes the pool once all tasks have been sent. This blocks until the workers have processed everything left in the queue and exited.
+	pool.Close()
 
 	//! Once all tasks are processed and all workers finish their work, the program prints a confirmation message.
 	fmt.Println("All tasks processed.")
 }
 
 //? How It Works:-
-//! Goroutines: The main program creates a pool of workers (goroutines), each of which processes tasks from the channel.
-//! Task Distribution: Tasks are distributed across the workers through the channel, and the workers process them in parallel. Since the channel is buffered, the program can queue tasks even if all workers are busy, avoiding unnecessary blocking.
-//! Synchronization: The sync.WaitGroup ensures that the main program waits for all workers to finish processing before exiting. This prevents the program from exiting prematurely.
+//! Goroutines: The pool creates a set of workers (goroutines), each of which processes tasks from the queue.
+//! Task Distribution: Tasks are distributed across the workers through the queue, and the workers process them in parallel. Since the queue is bounded, the program can queue tasks even if all workers are busy, and Submit blocks only once the queue is full.
+//! Synchronization: Each submitted task gets a handle; Flush waits for specific handles, while Close waits for every worker to finish before the program exits.
 
 //? Complete Workflow Summary:-
-//! Initialize Task Queue: A buffered channel (tasks) is created to hold the tasks to be processed by workers.
-//! Create Workers: Launch numWorkers worker goroutines. Each worker pulls tasks from the tasks channel and processes them.
-//! Send Tasks: The main program sends numReq tasks to the tasks channel.
-//! Close the Channel: The main program closes the tasks channel to indicate no more tasks will be added.
-//! Workers Process Tasks: Workers process tasks concurrently, reading from the channel and simulating task processing.
-//! Wait for Completion: The main program waits for all workers to finish processing using sync.WaitGroup.
+//! Initialize the Pool: New starts the workers and prepares a bounded queue for the tasks.
+//! Send Tasks: The main program submits totalRequestsAllowed tasks and keeps their handles.
+//! Wait for Specific Tasks: Flush blocks until the first three tasks have finished.
+//! Close the Pool: Close stops accepting tasks and waits for the workers to drain the queue.
 //! Final Message: After all tasks are processed, the program prints a confirmation and exits.
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// ! workerSlot is the per-worker state a worker goroutine carries from task to task.
+type workerSlot struct {
+	id         int
+	scratch    *WorkerScratch //! Owned by the slot, so tasks can reuse buffers without any locking.
+	panicTimes []time.Time    //! Recent task panics on this slot, oldest first, see WithWorkerQuarantine.
+	tasksRun   int            //! Tasks this slot has executed, see WithMaxTasksPerWorker.
+
+	capabilities []string //! What the worker advertises, see WithWorkerCapabilities.
+}
+
+func newWorkerSlot(workerId int) *workerSlot {
+	return &workerSlot{id: workerId, scratch: newWorkerScratch(workerId)}
+}
+
+// ! recordPanic notes that a task panicked on this slot. Only the slot's own worker calls it.
+func (slot *workerSlot) recordPanic(at time.Time) {
+	slot.panicTimes = append(slot.panicTimes, at)
+}
+
+// ! WithWorkerQuarantine retires a worker slot whose tasks panic maxPanics times within
+// ! window, on the assumption that something about that worker is broken. The slot is not
+// ! replaced, so the pool shrinks by one worker, and a critical event is logged. If every
+// ! slot ends up quarantined, the pool closes itself and cancels whatever is still queued,
+// ! since nothing would ever run it. Stats reports the number of quarantined slots.
+func WithWorkerQuarantine(maxPanics int, window time.Duration) Option {
+	return func(pool *Pool) {
+		pool.quarantinePanics = maxPanics
+		pool.quarantineWindow = window
+	}
+}
+
+// ! quarantineIfNeeded retires the slot if it has panicked too often recently, and reports
+// ! whether its worker should exit.
+func (pool *Pool) quarantineIfNeeded(slot *workerSlot) bool {
+	if pool.quarantinePanics <= 0 || len(slot.panicTimes) == 0 {
+		return false
+	}
+	now := pool.clock.Now()
+	expired := 0
+	for expired < len(slot.panicTimes) && now.Sub(slot.panicTimes[expired]) > pool.quarantineWindow {
+		expired++
+	}
+	slot.panicTimes = slot.panicTimes[expired:]
+	if len(slot.panicTimes) < pool.quarantinePanics {
+		return false
+	}
+
+	pool.counters.quarantined.Add(1)
+	pool.mutex.Lock()
+	pool.activeWorkers--
+	remaining := pool.activeWorkers
+	pool.broadcastStateChange() //! WaitReady may now know that it cannot succeed.
+	pool.mutex.Unlock()
+
+	pool.logger.Printf("CRITICAL: %s panicked %d times within %v and is quarantined; %d workers remain",
+		pool.workerName(slot.id), len(slot.panicTimes), pool.quarantineWindow, remaining)
+	if remaining == 0 {
+		pool.logger.Printf("CRITICAL: every worker is quarantined; closing the pool and cancelling queued tasks")
+		pool.abort()
+	}
+	return true
+}
+
+// ! WithMaxTasksPerWorker retires each worker after it has executed maxTasks tasks and
+// ! replaces it with a fresh one under the same id, much like recycling database connections.
+// ! This keeps memory flat when task code leaks a little per call: the replacement starts
+// ! with a new goroutine and stack and a new WorkerScratch, and runs WithWorkerInit afresh
+// ! while the old worker runs WithWorkerTeardown. The replacement is started before the old
+// ! worker exits, so throughput does not dip. Zero, the default, never recycles.
+func WithMaxTasksPerWorker(maxTasks int) Option {
+	return func(pool *Pool) {
+		pool.maxTasksPerWorker = maxTasks
+	}
+}
+
+// ! recycleIfNeeded counts a finished task against the slot and, once the slot has reached
+// ! its limit, starts a replacement worker. It reports whether the current worker should exit.
+func (pool *Pool) recycleIfNeeded(slot *workerSlot) bool {
+	if pool.maxTasksPerWorker <= 0 {
+		return false
+	}
+	slot.tasksRun++
+	if slot.tasksRun < pool.maxTasksPerWorker {
+		return false
+	}
+	pool.workersWaitGroup.Add(1)
+	pool.spawn(func() { pool.worker(slot.id) }, pool.workersWaitGroup.Done)
+	return true
+}
+
+// ! WithWorkerNamePrefix names the pool's workers prefix-worker-1, prefix-worker-2 and so on,
+// ! in log lines and in the "worker" pprof label, so that with several pools in one process
+// ! every log line and profile sample can be traced back to its pool. Without a prefix the
+// ! workers are named worker-1, worker-2, and so on.
+func WithWorkerNamePrefix(prefix string) Option {
+	return func(pool *Pool) {
+		pool.workerNamePrefix = prefix
+	}
+}
+
+// ! workerName returns the display name of the worker with the given id.
+func (pool *Pool) workerName(workerId int) string {
+	if pool.workerNamePrefix == "" {
+		return fmt.Sprintf("worker-%d", workerId)
+	}
+	return fmt.Sprintf("%s-worker-%d", pool.workerNamePrefix, workerId)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var panickingTask = TaskFunc(func(context.Context) error { panic("corrupted worker state") })
+
+func TestMaxTasksPerWorkerRecyclesAfterEachBatch(t *testing.T) {
+	var inits, teardowns atomic.Int32
+	pool, err := New(WithWorkers(1), WithMaxTasksPerWorker(3),
+		WithWorkerInit(func(int) error { inits.Add(1); return nil }),
+		WithWorkerTeardown(func(int) { teardowns.Add(1) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 7 {
+		pool.Submit(noopTask)
+	}
+	pool.Wait()
+	if got := inits.Load(); got != 3 {
+		t.Errorf("7 tasks at 3 per worker ran %d inits, want 3", got)
+	}
+	returnsWithin(t, time.Second, "the two recycled workers to tear down", func() {
+		for teardowns.Load() != 2 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	pool.Close()
+	if got := teardowns.Load(); got != 3 {
+		t.Errorf("%d teardowns once the pool closed, want one for every worker", got)
+	}
+}
+
+func TestRecycledWorkerIsReplacedBeforeItExits(t *testing.T) {
+	var teardowns atomic.Int32
+	tornDown := make(chan struct{})
+	pool := newTestPool(t, WithWorkers(1), WithMaxTasksPerWorker(1),
+		WithWorkerTeardown(func(int) {
+			if teardowns.Add(1) == 1 {
+				<-tornDown //! The first worker is still on its way out.
+			}
+		}))
+	defer close(tornDown)
+	pool.Submit(noopTask)
+	second, _ := pool.Submit(noopTask)
+	returnsWithin(t, time.Second, "the replacement to run a task while the old worker tears down", func() {
+		<-second.Done()
+	})
+}
+
+func TestWorkerQuarantineRetiresTheSlotThatKeepsPanicking(t *testing.T) {
+	clock := newFakeClock()
+	pool := newTestPool(t, WithWorkers(2), WithClock(clock), WithWorkerQuarantine(2, time.Minute),
+		WithLogger(log.New(io.Discard, "", 0)))
+	release := make(chan struct{})
+	busy := make(chan struct{})
+	pool.SubmitFunc(func() { //! Keeps one worker out of the way, so every panic hits the other.
+		close(busy)
+		<-release
+	})
+	<-busy
+
+	first, _ := pool.Submit(panickingTask)
+	<-first.Done()
+	clock.advance(2 * time.Minute)
+	second, _ := pool.Submit(panickingTask)
+	<-second.Done()
+	if quarantined := pool.Stats().QuarantinedWorkers; quarantined != 0 {
+		t.Fatalf("%d slots quarantined for panics further apart than the window", quarantined)
+	}
+
+	third, _ := pool.Submit(panickingTask)
+	<-third.Done()
+	returnsWithin(t, time.Second, "the slot to be quarantined", func() {
+		for pool.Stats().QuarantinedWorkers != 1 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	close(release)
+	after, _ := pool.Submit(noopTask)
+	returnsWithin(t, time.Second, "a task on the remaining worker", func() { <-after.Done() })
+}
+
+func TestWorkerQuarantineOfTheLastSlotClosesThePool(t *testing.T) {
+	pool := newTestPool(t, WithWorkers(1), WithWorkerQuarantine(1, time.Minute),
+		WithLogger(log.New(io.Discard, "", 0)))
+	release := occupy(t, pool)
+	pool.Submit(panickingTask)
+	stranded, _ := pool.Submit(noopTask)
+	close(release)
+
+	returnsWithin(t, time.Second, "the task stranded behind the last quarantined slot", func() { <-stranded.Done() })
+	if !errors.Is(stranded.Err(), ErrTaskCancelled) {
+		t.Errorf("the stranded task ended with %v, want ErrTaskCancelled", stranded.Err())
+	}
+	if _, err := pool.Submit(noopTask); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit after every slot was quarantined returned %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestWorkerNamePrefixNamesWorkersInLogsAndProfiles(t *testing.T) {
+	var logged bytes.Buffer
+	pool := newTestPool(t, WithWorkers(1), WithWorkerNamePrefix("ingest"), WithLogger(log.New(&logged, "", 0)),
+		WithWarmup(func(int) error { return errors.New("cache unavailable") }))
+	if err := pool.WaitReady(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(logged.Bytes(), []byte("ingest-worker-1 failed to warm up")) {
+		t.Errorf("log %q does not name the worker with its prefix", logged.String())
+	}
+	if dump := pool.DumpStacks(); !bytes.Contains(dump, []byte(`"worker":"ingest-worker-1"`)) {
+		t.Errorf("the worker's pprof label is not prefixed:\n%s", dump)
+	}
+	if name := newTestPool(t).workerName(2); name != "worker-2" {
+		t.Errorf("without a prefix the worker is named %q, want worker-2", name)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ! WithCooperativeYield lets running tasks give way to more important ones. Long-running
+// ! tasks call Yield at convenient points, such as once per loop iteration; with this option,
+// ! Yield calls runtime.Gosched whenever a task of higher priority is running elsewhere in the
+// ! pool, so on a loaded scheduler the important goroutines get more turns. This is best-effort:
+// ! the Go scheduler has no notion of priority, and yielding only reorders goroutines that are
+// ! ready to run, so it cannot hold back low-priority tasks that never call Yield.
+func WithCooperativeYield() Option {
+	return func(pool *Pool) {
+		pool.cooperativeYield = &cooperativeYield{running: make(map[int]int)}
+	}
+}
+
+// ! Yield is a scheduling point for the task whose context ctx is, see WithCooperativeYield.
+// ! It is cheap, and does nothing outside a task or in a pool without cooperative yielding.
+func Yield(ctx context.Context) {
+	state, ok := ctx.Value(yieldKey{}).(*yieldState)
+	if !ok {
+		return
+	}
+	if int64(state.priority) < state.yield.top.Load() {
+		runtime.Gosched()
+	}
+}
+
+// ! yieldKey is the context key under which a task's yieldState is stored.
+type yieldKey struct{}
+
+// ! yieldState is what Yield needs to know about the calling task.
+type yieldState struct {
+	yield    *cooperativeYield
+	priority int
+}
+
+// ! cooperativeYield tracks the priorities of the running tasks, so Yield can compare against
+// ! the highest of them without taking a lock.
+type cooperativeYield struct {
+	mutex   sync.Mutex
+	running map[int]int  //! Number of running tasks per priority.
+	top     atomic.Int64 //! Highest priority in running, or math.MinInt64 when nothing runs.
+}
+
+// ! enter records a task starting at the given priority and returns its context for Yield.
+func (yield *cooperativeYield) enter(ctx context.Context, priority int) context.Context {
+	yield.mutex.Lock()
+	yield.running[priority]++
+	yield.updateTop()
+	yield.mutex.Unlock()
+	return context.WithValue(ctx, yieldKey{}, &yieldState{yield: yield, priority: priority})
+}
+
+// ! leave records that a task at the given priority has finished.
+func (yield *cooperativeYield) leave(priority int) {
+	yield.mutex.Lock()
+	if yield.running[priority]--; yield.running[priority] == 0 {
+		delete(yield.running, priority)
+	}
+	yield.updateTop()
+	yield.mutex.Unlock()
+}
+
+// ! updateTop recomputes the highest running priority. The mutex must be held; the map only
+// ! has one entry per distinct priority, so this stays cheap.
+func (yield *cooperativeYield) updateTop() {
+	top := int64(math.MinInt64)
+	for priority := range yield.running {
+		top = max(top, int64(priority))
+	}
+	yield.top.Store(top)
+}
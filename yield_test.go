@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+// ! spin burns CPU for a fixed number of iterations, calling Yield between them.
+func spin(ctx context.Context, iterations int) int {
+	sum := 0
+	for index := range iterations {
+		sum += index * index
+		if index%64 == 0 {
+			Yield(ctx)
+		}
+	}
+	return sum
+}
+
+// ! benchmarkHighPriorityLatency measures how long a high-priority task takes from Submit to
+// ! done while twice as many low-priority tasks as there are CPUs keep the scheduler busy.
+func benchmarkHighPriorityLatency(b *testing.B, options ...Option) {
+	background := 2 * runtime.GOMAXPROCS(0)
+	pool, err := New(append([]Option{WithWorkers(background + 1), WithQueueSize(background + 1)}, options...)...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	for range background {
+		pool.Submit(TaskFunc(func(taskContext context.Context) error {
+			for ctx.Err() == nil {
+				spin(taskContext, 1<<10)
+			}
+			return nil
+		}))
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		handle, err := pool.SubmitWithPriority(10, TaskFunc(func(taskContext context.Context) error {
+			spin(taskContext, 1<<16)
+			return nil
+		}))
+		if err != nil {
+			b.Fatal(err)
+		}
+		<-handle.Done()
+	}
+	b.StopTimer()
+	stop()
+	pool.Close()
+}
+
+func BenchmarkHighPriorityLatency(b *testing.B) {
+	b.Run("without-yield", func(b *testing.B) { benchmarkHighPriorityLatency(b) })
+	b.Run("cooperative-yield", func(b *testing.B) { benchmarkHighPriorityLatency(b, WithCooperativeYield()) })
+}